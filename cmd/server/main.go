@@ -22,6 +22,14 @@ var log logger.Logger
 
 // @host localhost:8080
 // @BasePath /
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
 	log = logger.NewLogger("main")
 	// Load environment variables
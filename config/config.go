@@ -2,13 +2,24 @@ package config
 
 import (
 	"os"
+	"time"
 )
 
 type Config struct {
-	MongoURI string
-	Port     string
-	Language string
-	I18NPath string
+	MongoURI    string
+	Port        string
+	Language    string
+	I18NPath    string
+	JWTSecret   string
+	JWKSPath    string
+	JWTIssuer   string
+	JWTTokenTTL time.Duration
+	// VoucherReservationTTL is how long a voucher is held for a user during checkout
+	VoucherReservationTTL time.Duration
+	// AdminEmail names the user made an admin at startup, registered with
+	// AdminPassword if they have no account yet
+	AdminEmail    string
+	AdminPassword string
 	// Add other configuration fields as needed
 }
 
@@ -16,10 +27,19 @@ var AppConfig *Config
 
 func LoadConfig() *Config {
 	AppConfig = &Config{
-		MongoURI: getEnv("MONGO_URI", "mongodb://localhost:27017"),
-		Port:     getEnv("PORT", "8080"),
-		Language: getEnv("LANGUAGE", "en"),
-		I18NPath: getEnv("I18N_PATH", "../../locales"),
+		MongoURI:    getEnv("MONGO_URI", "mongodb://localhost:27017"),
+		Port:        getEnv("PORT", "8080"),
+		Language:    getEnv("LANGUAGE", "en"),
+		I18NPath:    getEnv("I18N_PATH", "../../locales"),
+		JWTSecret:   getEnv("JWT_SECRET", ""),
+		JWKSPath:    getEnv("JWKS_PATH", ""),
+		JWTIssuer:   getEnv("JWT_ISSUER", "trinity"),
+		JWTTokenTTL: getEnvDuration("JWT_TOKEN_TTL", 24*time.Hour),
+
+		VoucherReservationTTL: getEnvDuration("VOUCHER_RESERVATION_TTL", 15*time.Minute),
+
+		AdminEmail:    getEnv("ADMIN_EMAIL", ""),
+		AdminPassword: getEnv("ADMIN_PASSWORD", ""),
 	}
 	return AppConfig
 }
@@ -30,3 +50,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
@@ -19,29 +19,43 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/campaigns": {
+        "/api-keys": {
             "get": {
-                "description": "Retrieve a list of all promotional campaigns",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Retrieve all partner API keys without their secrets",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Campaign"
+                    "APIKey"
                 ],
-                "summary": "List all campaigns",
+                "summary": "List partner API keys",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Campaign"
+                                "$ref": "#/definitions/model.APIKey"
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -51,7 +65,12 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Create a new promotional campaign",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create an API key scoped to campaigns and actions. The key is only returned once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -59,17 +78,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Campaign"
+                    "APIKey"
                 ],
-                "summary": "Create a new campaign",
+                "summary": "Create a partner API key",
                 "parameters": [
                     {
-                        "description": "Campaign Data",
-                        "name": "campaign",
+                        "description": "API key data",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/campaign.CreateCampaignRequest"
+                            "$ref": "#/definitions/apikey.CreateKeyRequest"
                         }
                     }
                 ],
@@ -77,7 +96,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Campaign"
+                            "$ref": "#/definitions/apikey.CreateKeyResponse"
                         }
                     },
                     "400": {
@@ -86,6 +105,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -95,53 +126,162 @@ const docTemplate = `{
                 }
             }
         },
-        "/campaigns/{id}/vouchers": {
+        "/api-keys/{id}/revoke": {
             "post": {
-                "description": "Generate vouchers for the specified campaign",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Revoke an API key so it can no longer authenticate",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Campaign"
+                    "APIKey"
                 ],
-                "summary": "Generate vouchers for a campaign",
+                "summary": "Revoke a partner API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Campaign ID",
+                        "description": "API key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
-                    {
-                        "description": "Number of vouchers to generate",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/campaign.GenerateVouchersRequest"
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
+                }
+            }
+        },
+        "/campaigns": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a list of all promotional campaigns",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
                 ],
+                "summary": "List all campaigns",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Voucher"
+                                "$ref": "#/definitions/campaign.CampaignResponse"
                             }
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new promotional campaign. Conditions are compiled before saving and compile errors report their line and column.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Create a new campaign",
+                "parameters": [
+                    {
+                        "description": "Campaign Data",
+                        "name": "campaign",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/campaign.CreateCampaignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.CampaignResponse"
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -151,9 +291,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/purchases": {
+        "/campaigns/templates": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve all campaign templates by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "List campaign templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.CampaignTemplate"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Process a subscription purchase with optional voucher code",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Save campaign settings under a name so campaigns can be created from them",
                 "consumes": [
                     "application/json"
                 ],
@@ -161,25 +350,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Purchase"
+                    "Campaign"
                 ],
-                "summary": "Process a subscription purchase",
+                "summary": "Save a campaign template",
                 "parameters": [
                     {
-                        "description": "Purchase data",
+                        "description": "Template settings",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/purchase.ProcessPurchaseRequest"
+                            "$ref": "#/definitions/campaign.CreateTemplateRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Purchase"
+                            "$ref": "#/definitions/model.CampaignTemplate"
                         }
                     },
                     "400": {
@@ -188,6 +377,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -197,9 +404,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/vouchers/redeem": {
+        "/campaigns/templates/{name}/campaigns": {
             "post": {
-                "description": "Redeem a voucher using code and user ID",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a campaign with a template's settings, replacing only the fields given in the request. Besides the fields a campaign update accepts, the code format and automatic application can be set. Start and end dates are required.",
                 "consumes": [
                     "application/json"
                 ],
@@ -207,29 +419,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Voucher"
+                    "Campaign"
                 ],
-                "summary": "Redeem a voucher",
+                "summary": "Create a campaign from a template",
                 "parameters": [
                     {
-                        "description": "Voucher redemption data",
-                        "name": "request",
+                        "type": "string",
+                        "description": "Template name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Settings replacing the template's",
+                        "name": "campaign",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/voucher.RedeemVoucherRequest"
+                            "$ref": "#/definitions/campaign.CreateFromTemplateRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Voucher"
+                            "$ref": "#/definitions/campaign.CampaignResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.ConditionErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/response.ErrorResponse"
                         }
@@ -242,88 +479,2364 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "campaign.CreateCampaignRequest": {
-            "type": "object",
-            "required": [
-                "description",
-                "discount",
-                "end_date",
-                "max_users",
-                "name",
-                "start_date"
-            ],
-            "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "discount": {
-                    "type": "number"
-                },
-                "end_date": {
-                    "type": "string"
-                },
-                "max_users": {
+        },
+        "/campaigns/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change the given campaign settings and leave the others as they are. Conditions are compiled before saving and compile errors report their line and column.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Update a campaign",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Settings to change",
+                        "name": "campaign",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/campaign.UpdateCampaignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.CampaignResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.ConditionErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/clone": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a campaign with the settings of another, moved to a new start date for the same length of time. Vouchers, counters and spend are not copied.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Clone a campaign",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New name and start date",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/campaign.CloneCampaignRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.CampaignResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the audit entries of a campaign, newest first. Each entry names the actor, the time and the kind of change, with the changed fields before and after.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "List a campaign's change history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AuditEntry"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/results": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report for each variant of the campaign how many users were bucketed into it at checkout, how many redeemed and purchased, the share who purchased, and the revenue and discount of their purchases",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Report a campaign's A/B test results",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/campaign.VariantResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/vouchers": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Generate vouchers for the specified campaign. With user_ids, one personal voucher is generated per user that only that user can redeem. With async set, the vouchers are generated in a background job whose progress is available at /jobs/{id}. If generation fails part way, the 500 response lists the vouchers created before the failure.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Generate vouchers for a campaign",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Number of vouchers to generate",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/campaign.GenerateVouchersRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Voucher"
+                            }
+                        }
+                    },
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/model.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.PartialVouchersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/vouchers/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Stream every voucher code of the campaign with its status and expiry date as a CSV or XLSX file",
+                "produces": [
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Export a campaign's vouchers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "xlsx"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/vouchers/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Upload a CSV file whose first column holds externally generated codes. A check character is appended to every code, and codes are inserted until the campaign is full and each row is reported as accepted, duplicate, invalid or over_capacity. Codes must match the campaign's code format prefix and alphabet when it has one. Accepted rows carry the voucher_code to hand out. If the import fails part way, the 500 response carries the report, with the rows not yet inserted marked skipped.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Import voucher codes into a campaign",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "CSV file of codes",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.ImportVouchersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.PartialImportResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/vouchers/revoke": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Void every unused voucher of the campaign, or only those matching the listed codes and issue dates, so they can no longer be redeemed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Revoke a campaign's unused vouchers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Revocation reason and filter",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/campaign.RevokeVouchersRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/campaign.RevokeVouchersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/campaigns/{id}/vouchers/shared": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Create a shared code that up to max_redemptions users can redeem, each at most per_user_limit times (default 1). A check character is appended to the code, and the response holds the code to hand out.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Campaign"
+                ],
+                "summary": "Create a multi-use voucher",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Campaign ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Shared voucher data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/campaign.CreateSharedVoucherRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Voucher"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Report the status, progress and errors of a background job",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Job"
+                ],
+                "summary": "Get a background job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Job"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchases": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Process a subscription purchase for the authenticated user with optional voucher codes. The combination of codes giving the lowest total is applied, following each campaign's stacking policy.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Purchase"
+                ],
+                "summary": "Process a subscription purchase",
+                "parameters": [
+                    {
+                        "description": "Purchase data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/purchase.ProcessPurchaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Purchase"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.RuleErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/purchases/quote": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Price a purchase for the authenticated user without making it. The voucher codes and the automatic campaigns the order qualifies for are combined into the lowest total, following each campaign's stacking policy.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Purchase"
+                ],
+                "summary": "Price a subscription purchase",
+                "parameters": [
+                    {
+                        "description": "Purchase data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/purchase.ProcessPurchaseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/purchase.QuoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.RuleErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/login": {
+            "post": {
+                "description": "Verify email and password and issue an access token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "description": "Login data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/user.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve the profile of the calling user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/register": {
+            "post": {
+                "description": "Create a user account with email and password, and optionally the locale and country used by campaign eligibility rules",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "Registration data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Assign one of the admin, marketer, support or customer roles to a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Change a user's role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vouchers/redeem": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    },
+                    {
+                        "APIKeyAuth": []
+                    }
+                ],
+                "description": "Redeem a voucher code for the authenticated user, or for user_id when called by a partner API key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Voucher"
+                ],
+                "summary": "Redeem a voucher",
+                "parameters": [
+                    {
+                        "description": "Voucher redemption data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/voucher.RedeemVoucherRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Voucher"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vouchers/reserve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Hold a single-use voucher for the authenticated user so nobody else can redeem it until the hold expires. Completing a purchase with the code converts the hold into a redemption.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Voucher"
+                ],
+                "summary": "Reserve a voucher during checkout",
+                "parameters": [
+                    {
+                        "description": "Voucher to reserve",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/voucher.ReserveVoucherRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Voucher"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vouchers/{code}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Show a voucher to the authenticated user. For campaigns whose vouchers are valid for a number of days from first view, the first call starts that period and sets the expiry date.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Voucher"
+                ],
+                "summary": "View a voucher",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Voucher code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "silver",
+                            "gold"
+                        ],
+                        "type": "string",
+                        "description": "Plan of the order to validate against",
+                        "name": "plan",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Amount of the order to validate against",
+                        "name": "amount",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Voucher"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.RuleErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/vouchers/{code}/revoke": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Void a voucher, for example after its code leaked, so it can no longer be redeemed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Voucher"
+                ],
+                "summary": "Revoke a voucher",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Voucher code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Revocation reason",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/voucher.RevokeVoucherRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Voucher"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apikey.CreateKeyRequest": {
+            "type": "object",
+            "required": [
+                "actions",
+                "campaign_ids",
+                "name"
+            ],
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "campaign_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "apikey.CreateKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "$ref": "#/definitions/model.APIKey"
+                },
+                "key": {
+                    "type": "string"
+                }
+            }
+        },
+        "campaign.CampaignResponse": {
+            "type": "object",
+            "properties": {
+                "automatic": {
+                    "description": "Automatic campaigns need no code: they apply at checkout to every\neligible order within their dates, each purchase taking one of MaxUsers",
+                    "type": "boolean"
+                },
+                "budget": {
+                    "description": "Budget caps the total discount the campaign gives away; Spent is\nwhat its purchases have been discounted so far",
+                    "type": "number"
+                },
+                "code_format": {
+                    "$ref": "#/definitions/model.CodeFormat"
+                },
+                "condition": {
+                    "description": "Condition is an expression over the purchase that must hold for the\ncampaign to apply, e.g. plan == \"gold\" \u0026\u0026 now.weekday in [6, 7]",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "eligibility": {
+                    "description": "Eligibility restricts which users and orders the campaign's vouchers\napply to; without it they apply to everyone",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.EligibilityRules"
+                        }
+                    ]
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issued": {
+                    "description": "Issued counts the vouchers generated, still stored as used_users from\nbefore redemptions were counted on their own",
+                    "type": "integer"
+                },
+                "max_issued": {
+                    "description": "MaxIssued caps how many vouchers can be issued; it defaults to\nMaxUsers and can exceed it to over-issue codes",
+                    "type": "integer"
+                },
+                "max_users": {
+                    "description": "MaxUsers caps how often the campaign can be redeemed",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "redeemed": {
+                    "type": "integer"
+                },
+                "remaining": {
+                    "type": "integer"
+                },
+                "spent": {
+                    "type": "number"
+                },
+                "stacking": {
+                    "description": "Stacking decides whether the discount combines with other campaigns\non one purchase; without it the campaign is exclusive",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.StackingPolicy"
+                        }
+                    ]
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/model.CampaignStatus"
+                },
+                "tiers": {
+                    "description": "Tiers replace Discount for the orders they match, for example a\nhigher discount on gold or on orders above a price",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiscountTier"
+                    }
+                },
+                "validity": {
+                    "description": "Validity makes each voucher expire relative to when it was issued or\nfirst viewed; without it vouchers expire at EndDate",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.VoucherValidity"
+                        }
+                    ]
+                },
+                "variants": {
+                    "description": "Variants split the campaign's users into an A/B test, each variant\ngiving its own discount in place of Discount",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CampaignVariant"
+                    }
+                }
+            }
+        },
+        "campaign.CloneCampaignRequest": {
+            "type": "object",
+            "required": [
+                "start_date"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Name defaults to the name of the campaign being cloned",
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "StartDate is when the clone starts, RFC 3339; it runs as long as the original",
+                    "type": "string"
+                }
+            }
+        },
+        "campaign.ConditionErrorResponse": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "campaign.CreateCampaignRequest": {
+            "type": "object",
+            "required": [
+                "description",
+                "discount",
+                "end_date",
+                "max_users",
+                "name",
+                "start_date"
+            ],
+            "properties": {
+                "automatic": {
+                    "description": "Automatic applies the discount at checkout without a code",
+                    "type": "boolean"
+                },
+                "budget": {
+                    "description": "Budget caps the total discount given, in the currency of the prices",
+                    "type": "number"
+                },
+                "code_format": {
+                    "$ref": "#/definitions/model.CodeFormat"
+                },
+                "condition": {
+                    "description": "Condition is an expression over the purchase that must hold, e.g.\nplan == \"gold\" \u0026\u0026 user.purchases == 0 \u0026\u0026 now.weekday in [6, 7]",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "eligibility": {
+                    "description": "Eligibility restricts which users and orders the vouchers apply to",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.EligibilityRules"
+                        }
+                    ]
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "max_issued": {
+                    "description": "MaxIssued allows issuing more codes than MaxUsers lets redeem",
+                    "type": "integer"
+                },
+                "max_users": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "stacking": {
+                    "description": "Stacking decides whether the discount combines with other campaigns;\ncampaigns are exclusive by default",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.StackingPolicy"
+                        }
+                    ]
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "tiers": {
+                    "description": "Tiers replace the discount for the plans or order amounts they name",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiscountTier"
+                    }
+                },
+                "validity": {
+                    "description": "Validity makes vouchers valid for a number of days from issue or first\nview, capped by the end date",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.VoucherValidity"
+                        }
+                    ]
+                },
+                "variants": {
+                    "description": "Variants run an A/B test, each giving its own discount to the share\nof users its weight buckets into it",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CampaignVariant"
+                    }
+                }
+            }
+        },
+        "campaign.CreateFromTemplateRequest": {
+            "type": "object",
+            "properties": {
+                "automatic": {
+                    "type": "boolean"
+                },
+                "budget": {
+                    "description": "Budget replaces the campaign budget; zero removes it",
+                    "type": "number",
+                    "minimum": 0
+                },
+                "code_format": {
+                    "$ref": "#/definitions/model.CodeFormat"
+                },
+                "condition": {
+                    "description": "Condition replaces the campaign condition; an empty string removes it",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "eligibility": {
+                    "$ref": "#/definitions/model.EligibilityRules"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "max_issued": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "max_users": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "stacking": {
+                    "$ref": "#/definitions/model.StackingPolicy"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "tiers": {
+                    "description": "Tiers replaces the tier table; an empty list removes it",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiscountTier"
+                    }
+                },
+                "validity": {
+                    "$ref": "#/definitions/model.VoucherValidity"
+                },
+                "variants": {
+                    "description": "Variants replaces the A/B test variants; an empty list ends the test.\nChanging weights moves users between variants.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CampaignVariant"
+                    }
+                }
+            }
+        },
+        "campaign.CreateSharedVoucherRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "max_redemptions"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "max_redemptions": {
+                    "type": "integer"
+                },
+                "per_user_limit": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "campaign.CreateTemplateRequest": {
+            "type": "object",
+            "required": [
+                "discount",
+                "name"
+            ],
+            "properties": {
+                "automatic": {
+                    "type": "boolean"
+                },
+                "budget": {
+                    "type": "number"
+                },
+                "code_format": {
+                    "$ref": "#/definitions/model.CodeFormat"
+                },
+                "condition": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "eligibility": {
+                    "$ref": "#/definitions/model.EligibilityRules"
+                },
+                "max_issued": {
+                    "type": "integer"
+                },
+                "max_users": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "stacking": {
+                    "$ref": "#/definitions/model.StackingPolicy"
+                },
+                "tiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiscountTier"
+                    }
+                },
+                "validity": {
+                    "$ref": "#/definitions/model.VoucherValidity"
+                }
+            }
+        },
+        "campaign.GenerateVouchersRequest": {
+            "type": "object",
+            "properties": {
+                "async": {
+                    "description": "Async generates the vouchers in a background job and returns the job",
+                    "type": "boolean"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "user_ids": {
+                    "description": "UserIds assigns one voucher to each user; count defaults to its length",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "campaign.ImportRow": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/campaign.ImportStatus"
+                },
+                "voucher_code": {
+                    "description": "VoucherCode is the code as stored, with its check character, for\naccepted rows",
+                    "type": "string"
+                }
+            }
+        },
+        "campaign.ImportStatus": {
+            "type": "string",
+            "enum": [
+                "accepted",
+                "duplicate",
+                "invalid",
+                "over_capacity",
+                "skipped"
+            ],
+            "x-enum-varnames": [
+                "ImportAccepted",
+                "ImportDuplicate",
+                "ImportInvalid",
+                "ImportOverCapacity",
+                "ImportSkipped"
+            ]
+        },
+        "campaign.ImportVouchersResponse": {
+            "type": "object",
+            "properties": {
+                "accepted": {
+                    "type": "integer"
+                },
+                "duplicate": {
+                    "type": "integer"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "over_capacity": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/campaign.ImportRow"
+                    }
+                },
+                "skipped": {
+                    "type": "integer"
+                }
+            }
+        },
+        "campaign.PartialImportResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "report": {
+                    "$ref": "#/definitions/campaign.ImportVouchersResponse"
+                }
+            }
+        },
+        "campaign.PartialVouchersResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "vouchers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Voucher"
+                    }
+                }
+            }
+        },
+        "campaign.RevokeVouchersRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "issued_after": {
+                    "description": "IssuedAfter and IssuedBefore are RFC 3339 timestamps bounding when\nthe vouchers were generated",
+                    "type": "string"
+                },
+                "issued_before": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "campaign.RevokeVouchersResponse": {
+            "type": "object",
+            "properties": {
+                "revoked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "campaign.UpdateCampaignRequest": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "description": "Budget replaces the campaign budget; zero removes it",
+                    "type": "number",
+                    "minimum": 0
+                },
+                "condition": {
+                    "description": "Condition replaces the campaign condition; an empty string removes it",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "eligibility": {
+                    "$ref": "#/definitions/model.EligibilityRules"
+                },
+                "end_date": {
+                    "type": "string"
+                },
+                "max_issued": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "max_users": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "stacking": {
+                    "$ref": "#/definitions/model.StackingPolicy"
+                },
+                "start_date": {
+                    "type": "string"
+                },
+                "tiers": {
+                    "description": "Tiers replaces the tier table; an empty list removes it",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiscountTier"
+                    }
+                },
+                "validity": {
+                    "$ref": "#/definitions/model.VoucherValidity"
+                },
+                "variants": {
+                    "description": "Variants replaces the A/B test variants; an empty list ends the test.\nChanging weights moves users between variants.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CampaignVariant"
+                    }
+                }
+            }
+        },
+        "campaign.VariantResult": {
+            "type": "object",
+            "properties": {
+                "conversion": {
+                    "description": "Conversion is the share of the variant's users who made a purchase",
+                    "type": "number"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "discount_given": {
+                    "description": "DiscountGiven is what the variant's discount took off those purchases",
+                    "type": "number"
+                },
+                "purchases": {
+                    "type": "integer"
+                },
+                "redemptions": {
+                    "type": "integer"
+                },
+                "revenue": {
+                    "description": "Revenue is what the variant's purchases were paid, after discounts",
+                    "type": "number"
+                },
+                "users": {
+                    "description": "Users counts the users bucketed into the variant at checkout",
+                    "type": "integer"
+                },
+                "variant": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.APIKey": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "campaign_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.AuditAction": {
+            "type": "string",
+            "enum": [
+                "create",
+                "update",
+                "status_change",
+                "generate_vouchers",
+                "revoke_vouchers"
+            ],
+            "x-enum-varnames": [
+                "AuditCreate",
+                "AuditUpdate",
+                "AuditStatusChange",
+                "AuditGenerateVouchers",
+                "AuditRevokeVouchers"
+            ]
+        },
+        "model.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/model.AuditAction"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "at": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "campaign_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note": {
+                    "description": "Note describes changes that are not to campaign fields, such as how\nmany vouchers were revoked and why",
+                    "type": "string"
+                }
+            }
+        },
+        "model.CampaignStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "exhausted"
+            ],
+            "x-enum-varnames": [
+                "CampaignActive",
+                "CampaignExhausted"
+            ]
+        },
+        "model.CampaignTemplate": {
+            "type": "object",
+            "properties": {
+                "automatic": {
+                    "type": "boolean"
+                },
+                "budget": {
+                    "type": "number"
+                },
+                "code_format": {
+                    "$ref": "#/definitions/model.CodeFormat"
+                },
+                "condition": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "discount": {
+                    "type": "number"
+                },
+                "eligibility": {
+                    "$ref": "#/definitions/model.EligibilityRules"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "max_issued": {
+                    "type": "integer"
+                },
+                "max_users": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "stacking": {
+                    "$ref": "#/definitions/model.StackingPolicy"
+                },
+                "tiers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiscountTier"
+                    }
+                },
+                "validity": {
+                    "$ref": "#/definitions/model.VoucherValidity"
+                }
+            }
+        },
+        "model.CampaignVariant": {
+            "type": "object",
+            "properties": {
+                "discount": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.CodeFormat": {
+            "type": "object",
+            "properties": {
+                "alphabet": {
+                    "type": "string"
+                },
+                "group_size": {
                     "type": "integer"
                 },
-                "name": {
+                "length": {
+                    "type": "integer"
+                },
+                "prefix": {
                     "type": "string"
                 },
-                "start_date": {
+                "separator": {
                     "type": "string"
                 }
             }
         },
-        "campaign.GenerateVouchersRequest": {
+        "model.DiscountLine": {
             "type": "object",
-            "required": [
-                "count"
-            ],
             "properties": {
-                "count": {
-                    "type": "integer"
+                "amount": {
+                    "type": "number"
+                },
+                "automatic": {
+                    "type": "boolean"
+                },
+                "campaign_id": {
+                    "type": "string"
+                },
+                "percent": {
+                    "type": "number"
+                },
+                "variant": {
+                    "description": "Variant is the campaign variant whose discount was given",
+                    "type": "string"
+                },
+                "voucher_code": {
+                    "description": "VoucherCode is the code the discount was redeemed with; automatic\ncampaigns apply without one",
+                    "type": "string"
                 }
             }
         },
-        "model.Campaign": {
+        "model.DiscountTier": {
             "type": "object",
             "properties": {
-                "description": {
-                    "type": "string"
-                },
                 "discount": {
                     "type": "number"
                 },
-                "end_date": {
+                "max_amount": {
+                    "type": "number"
+                },
+                "min_amount": {
+                    "type": "number"
+                },
+                "plan": {
+                    "$ref": "#/definitions/model.SubscriptionPlan"
+                }
+            }
+        },
+        "model.EligibilityRules": {
+            "type": "object",
+            "properties": {
+                "countries": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "first_purchase_only": {
+                    "type": "boolean"
+                },
+                "locales": {
+                    "description": "Locales match a user's locale exactly or by language, so \"en\" allows \"en-GB\"",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "min_order_amount": {
+                    "type": "number"
+                },
+                "plans": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.SubscriptionPlan"
+                    }
+                },
+                "registered_after": {
                     "type": "string"
                 },
-                "id": {
+                "registered_before": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Job": {
+            "type": "object",
+            "properties": {
+                "campaign_id": {
                     "type": "string"
                 },
-                "max_users": {
-                    "type": "integer"
+                "completed_at": {
+                    "type": "string"
                 },
-                "name": {
+                "created_at": {
                     "type": "string"
                 },
-                "start_date": {
+                "created_by": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "generated": {
+                    "type": "integer"
+                },
+                "id": {
                     "type": "string"
                 },
-                "used_users": {
+                "status": {
+                    "$ref": "#/definitions/model.JobStatus"
+                },
+                "total": {
                     "type": "integer"
+                },
+                "type": {
+                    "$ref": "#/definitions/model.JobType"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
+        "model.JobStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "completed",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "JobPending",
+                "JobRunning",
+                "JobCompleted",
+                "JobFailed"
+            ]
+        },
+        "model.JobType": {
+            "type": "string",
+            "enum": [
+                "generate_vouchers"
+            ],
+            "x-enum-varnames": [
+                "JobGenerateVouchers"
+            ]
+        },
         "model.Purchase": {
             "type": "object",
             "properties": {
                 "amount": {
                     "type": "number"
                 },
-                "discount": {
-                    "type": "number"
+                "discounts": {
+                    "description": "Discounts lists every discount applied, in the order they were applied",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiscountLine"
+                    }
                 },
                 "id": {
                     "type": "string"
@@ -339,9 +2852,43 @@ const docTemplate = `{
                 },
                 "user_id": {
                     "type": "string"
+                }
+            }
+        },
+        "model.Role": {
+            "type": "string",
+            "enum": [
+                "admin",
+                "marketer",
+                "support",
+                "customer"
+            ],
+            "x-enum-varnames": [
+                "RoleAdmin",
+                "RoleMarketer",
+                "RoleSupport",
+                "RoleCustomer"
+            ]
+        },
+        "model.StackingMode": {
+            "type": "string",
+            "enum": [
+                "exclusive",
+                "stackable"
+            ],
+            "x-enum-varnames": [
+                "StackExclusive",
+                "StackStackable"
+            ]
+        },
+        "model.StackingPolicy": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "$ref": "#/definitions/model.StackingMode"
                 },
-                "voucher_code": {
-                    "type": "string"
+                "priority": {
+                    "type": "integer"
                 }
             }
         },
@@ -356,9 +2903,46 @@ const docTemplate = `{
                 "PlanGold"
             ]
         },
+        "model.User": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/model.Role"
+                }
+            }
+        },
+        "model.ValidityStart": {
+            "type": "string",
+            "enum": [
+                "issue",
+                "first_view"
+            ],
+            "x-enum-varnames": [
+                "ValidFromIssue",
+                "ValidFromFirstView"
+            ]
+        },
         "model.Voucher": {
             "type": "object",
             "properties": {
+                "assigned_user_id": {
+                    "type": "string"
+                },
                 "campaign_id": {
                     "type": "string"
                 },
@@ -368,14 +2952,75 @@ const docTemplate = `{
                 "expiry_date": {
                     "type": "string"
                 },
+                "first_viewed_at": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "job_id": {
+                    "description": "JobID is the background job that generated the voucher, if any",
+                    "type": "string"
+                },
+                "legacy": {
+                    "description": "Legacy is set on vouchers generated before codes carried a check\ncharacter, which are exempt from the check character test",
+                    "type": "boolean"
+                },
+                "max_redemptions": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "$ref": "#/definitions/model.VoucherMode"
+                },
+                "per_user_limit": {
+                    "type": "integer"
+                },
+                "redemption_count": {
+                    "type": "integer"
+                },
+                "reserved_by": {
+                    "type": "string"
+                },
+                "reserved_until": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_reason": {
+                    "type": "string"
+                },
                 "used": {
                     "type": "boolean"
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "validity_days": {
+                    "description": "ValidityDays is set on vouchers whose validity starts at first view;\nuntil then ExpiryDate holds the campaign end as an upper bound",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.VoucherMode": {
+            "type": "string",
+            "enum": [
+                "single",
+                "multi"
+            ],
+            "x-enum-varnames": [
+                "VoucherSingleUse",
+                "VoucherMultiUse"
+            ]
+        },
+        "model.VoucherValidity": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer"
+                },
+                "from": {
+                    "$ref": "#/definitions/model.ValidityStart"
                 }
             }
         },
@@ -383,17 +3028,40 @@ const docTemplate = `{
             "type": "object",
             "required": [
                 "plan",
-                "user_id"
+                "voucher_codes"
             ],
             "properties": {
                 "plan": {
                     "$ref": "#/definitions/model.SubscriptionPlan"
                 },
-                "user_id": {
-                    "type": "string"
-                },
                 "voucher_code": {
+                    "description": "VoucherCode is a single code, kept for clients that predate voucher_codes",
                     "type": "string"
+                },
+                "voucher_codes": {
+                    "description": "VoucherCodes are tried together; at most five per purchase",
+                    "type": "array",
+                    "maxItems": 5,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "purchase.QuoteResponse": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "discounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.DiscountLine"
+                    }
+                },
+                "total": {
+                    "type": "number"
                 }
             }
         },
@@ -405,21 +3073,127 @@ const docTemplate = `{
                 }
             }
         },
+        "response.RuleErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "user.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/model.User"
+                }
+            }
+        },
+        "user.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "country": {
+                    "description": "Country is an ISO 3166-1 alpha-2 code such as GB",
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "Locale is a BCP 47 language tag such as en-GB",
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "user.UpdateRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "$ref": "#/definitions/model.Role"
+                }
+            }
+        },
         "voucher.RedeemVoucherRequest": {
             "type": "object",
             "required": [
-                "code",
-                "user_id"
+                "code"
             ],
             "properties": {
                 "code": {
                     "type": "string"
                 },
                 "user_id": {
+                    "description": "UserId names the customer when a partner redeems with an API key;\nit is ignored for user tokens",
+                    "type": "string"
+                }
+            }
+        },
+        "voucher.ReserveVoucherRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "voucher.RevokeVoucherRequest": {
+            "type": "object",
+            "required": [
+                "reason"
+            ],
+            "properties": {
+                "reason": {
                     "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "APIKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
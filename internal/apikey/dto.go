@@ -0,0 +1,16 @@
+package apikey
+
+import "trinity/internal/model"
+
+// CreateKeyRequest represents the request payload for creating a partner API key
+type CreateKeyRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	CampaignIDs []string `json:"campaign_ids" binding:"required,min=1"`
+	Actions     []string `json:"actions" binding:"required,min=1"`
+}
+
+// CreateKeyResponse carries the plaintext key, which is only shown once
+type CreateKeyResponse struct {
+	Key    string        `json:"key"`
+	APIKey *model.APIKey `json:"api_key"`
+}
@@ -0,0 +1,124 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+	"trinity/internal/auth"
+	"trinity/pkg/logger"
+	"trinity/pkg/reason"
+	"trinity/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles API key management requests
+type Handler struct {
+	service Service
+	logger  logger.Logger
+}
+
+// NewHandler creates a new API key handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger.NewLogger("apiKeyHandler"),
+	}
+}
+
+// RegisterRoutes registers the API key routes with the Gin router
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/", h.CreateKey)
+	rg.GET("/", h.ListKeys)
+	rg.POST("/:id/revoke", h.RevokeKey)
+}
+
+// CreateKey godoc
+// @Summary Create a partner API key
+// @Description Create an API key scoped to campaigns and actions. The key is only returned once.
+// @Tags APIKey
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param request body apikey.CreateKeyRequest true "API key data"
+// @Success 201 {object} apikey.CreateKeyResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /api-keys [post]
+func (h *Handler) CreateKey(c *gin.Context) {
+	var req CreateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	rawKey, key, err := h.service.CreateKey(req.Name, req.CampaignIDs, req.Actions, auth.UserID(c))
+	if err != nil {
+		if errors.Is(err, ErrInvalidAction) {
+			msg := reason.InvalidRequest.Message()
+			h.logger.Errorf("%s: %v", msg, err)
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateKeyResponse{Key: rawKey, APIKey: key})
+}
+
+// ListKeys godoc
+// @Summary List partner API keys
+// @Description Retrieve all partner API keys without their secrets
+// @Tags APIKey
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {array} model.APIKey
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /api-keys [get]
+func (h *Handler) ListKeys(c *gin.Context) {
+	keys, err := h.service.ListKeys()
+	if err != nil {
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeKey godoc
+// @Summary Revoke a partner API key
+// @Description Revoke an API key so it can no longer authenticate
+// @Tags APIKey
+// @Produce  json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /api-keys/{id}/revoke [post]
+func (h *Handler) RevokeKey(c *gin.Context) {
+	if err := h.service.RevokeKey(c.Param("id")); err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.APIKeyNotFound.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+package apikey
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trinity/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupRouter initializes the Gin engine with the API key routes
+func setupRouter(handler *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	handler.RegisterRoutes(r.Group("/api-keys"))
+	return r
+}
+
+func performRequest(r http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reqBody).Encode(body)
+	}
+	req, _ := http.NewRequest(method, path, &reqBody)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_CreateKey_Success(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService))
+
+	key := &model.APIKey{Id: "key123", Name: "Reseller", Hash: "secret-hash"}
+	mockService.On("CreateKey", "Reseller", []string{"campaign123"}, []string{"vouchers:redeem"}, mock.Anything).Return("trk_plain", key, nil)
+
+	w := performRequest(router, "POST", "/api-keys/", CreateKeyRequest{
+		Name:        "Reseller",
+		CampaignIDs: []string{"campaign123"},
+		Actions:     []string{"vouchers:redeem"},
+	})
+
+	assert.Equal(t, http.StatusCreated, w.Code, "Expected status code 201")
+	var response CreateKeyResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	assert.Equal(t, "trk_plain", response.Key, "The plaintext key should be returned once")
+	assert.NotContains(t, w.Body.String(), "secret-hash", "The hash must not be serialized")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateKey_RequiresScope(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService))
+
+	w := performRequest(router, "POST", "/api-keys/", CreateKeyRequest{Name: "Reseller", Actions: []string{"vouchers:redeem"}})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Keys must be scoped to at least one campaign")
+	mockService.AssertNotCalled(t, "CreateKey")
+}
+
+func TestHandler_RevokeKey_NotFound(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService))
+
+	mockService.On("RevokeKey", "missing").Return(ErrKeyNotFound)
+
+	w := performRequest(router, "POST", "/api-keys/missing/revoke", nil)
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "Expected status code 404")
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,110 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"trinity/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrKeyNotFound is returned when no API key matches the lookup
+var ErrKeyNotFound = errors.New("api key not found")
+
+// Repository defines API key data access methods
+type Repository interface {
+	CreateKey(key *model.APIKey) error
+	GetKeyByHash(hash string) (*model.APIKey, error)
+	ListKeys() ([]model.APIKey, error)
+	RevokeKey(id string, revokedAt time.Time) error
+	TouchLastUsed(id string, usedAt time.Time) error
+}
+
+// repository implements Repository interface
+type repository struct {
+	collection *mongo.Collection
+}
+
+// NewRepository creates a new API key repository
+func NewRepository(db *mongo.Database) Repository {
+	return &repository{
+		collection: db.Collection("api_keys"),
+	}
+}
+
+// CreateKey inserts a new API key into the database
+func (r *repository) CreateKey(key *model.APIKey) error {
+	result, err := r.collection.InsertOne(context.Background(), key)
+	if err != nil {
+		return err
+	}
+
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return fmt.Errorf("failed to convert InsertedID to ObjectID")
+	}
+
+	key.Id = oid.Hex()
+	return nil
+}
+
+// GetKeyByHash retrieves an API key by the hash of its secret
+func (r *repository) GetKeyByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.collection.FindOne(context.Background(), bson.M{"hash": hash}).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrKeyNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListKeys retrieves all API keys
+func (r *repository) ListKeys() ([]model.APIKey, error) {
+	cursor, err := r.collection.Find(context.Background(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var keys []model.APIKey
+	if err := cursor.All(context.Background(), &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeKey marks an API key as revoked
+func (r *repository) RevokeKey(id string, revokedAt time.Time) error {
+	return r.setTime(id, "revoked_at", revokedAt)
+}
+
+// TouchLastUsed records when an API key was last used
+func (r *repository) TouchLastUsed(id string, usedAt time.Time) error {
+	return r.setTime(id, "last_used_at", usedAt)
+}
+
+func (r *repository) setTime(id string, field string, value time.Time) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrKeyNotFound
+	}
+
+	result, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": objID}, bson.M{"$set": bson.M{field: value}})
+	if err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrKeyNotFound
+	}
+
+	return nil
+}
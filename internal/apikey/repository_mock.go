@@ -0,0 +1,45 @@
+package apikey
+
+import (
+	"time"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateKey(key *model.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetKeyByHash(hash string) (*model.APIKey, error) {
+	args := m.Called(hash)
+	key := args.Get(0)
+	if key == nil {
+		return nil, args.Error(1)
+	}
+	return key.(*model.APIKey), args.Error(1)
+}
+
+func (m *MockRepository) ListKeys() ([]model.APIKey, error) {
+	args := m.Called()
+	if keys, ok := args.Get(0).([]model.APIKey); ok {
+		return keys, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RevokeKey(id string, revokedAt time.Time) error {
+	args := m.Called(id, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) TouchLastUsed(id string, usedAt time.Time) error {
+	args := m.Called(id, usedAt)
+	return args.Error(0)
+}
@@ -0,0 +1,124 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"trinity/internal/auth"
+	"trinity/internal/model"
+	"trinity/pkg/logger"
+)
+
+// keyPrefix marks partner API keys so they are recognizable in logs and configs
+const keyPrefix = "trk_"
+
+var (
+	// ErrInvalidAction is returned when a key is scoped to an action partners may not perform
+	ErrInvalidAction = errors.New("invalid api key action")
+	// ErrKeyRevoked is returned when a revoked key is used
+	ErrKeyRevoked = errors.New("api key revoked")
+)
+
+// Service defines API key business logic methods
+type Service interface {
+	CreateKey(name string, campaignIDs []string, actions []string, createdBy string) (string, *model.APIKey, error)
+	ListKeys() ([]model.APIKey, error)
+	RevokeKey(id string) error
+	VerifyKey(rawKey string) (*model.APIKey, error)
+}
+
+// service implements Service interface
+type service struct {
+	repo   Repository
+	logger logger.Logger
+}
+
+// NewService creates a new API key service
+func NewService(repo Repository) Service {
+	return &service{
+		repo:   repo,
+		logger: logger.NewLogger("apiKeyService"),
+	}
+}
+
+// CreateKey generates a new partner key and stores only its hash. The
+// plaintext key is returned once and cannot be recovered later.
+func (s *service) CreateKey(name string, campaignIDs []string, actions []string, createdBy string) (string, *model.APIKey, error) {
+	for _, action := range actions {
+		if !isPartnerAction(action) {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidAction, action)
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		s.logger.Errorf("Failed to generate random bytes: %v", err)
+		return "", nil, err
+	}
+	rawKey := keyPrefix + hex.EncodeToString(secret)
+
+	key := &model.APIKey{
+		Name:        name,
+		Prefix:      rawKey[:len(keyPrefix)+8],
+		Hash:        hashKey(rawKey),
+		CampaignIDs: campaignIDs,
+		Actions:     actions,
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.repo.CreateKey(key); err != nil {
+		s.logger.Errorf("Failed to create api key: %v", err)
+		return "", nil, err
+	}
+
+	return rawKey, key, nil
+}
+
+// ListKeys retrieves all API keys
+func (s *service) ListKeys() ([]model.APIKey, error) {
+	return s.repo.ListKeys()
+}
+
+// RevokeKey revokes an API key so it can no longer authenticate
+func (s *service) RevokeKey(id string) error {
+	return s.repo.RevokeKey(id, time.Now())
+}
+
+// VerifyKey resolves a raw key and records its use
+func (s *service) VerifyKey(rawKey string) (*model.APIKey, error) {
+	key, err := s.repo.GetKeyByHash(hashKey(rawKey))
+	if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt != nil {
+		return nil, ErrKeyRevoked
+	}
+
+	now := time.Now()
+	if err := s.repo.TouchLastUsed(key.Id, now); err != nil {
+		s.logger.Errorf("Failed to record api key use: %v", err)
+	}
+	key.LastUsedAt = &now
+
+	return key, nil
+}
+
+// hashKey hashes a raw key; keys are random enough that a fast hash suffices
+func hashKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func isPartnerAction(action string) bool {
+	for _, permission := range auth.PartnerPermissions {
+		if string(permission) == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package apikey
+
+import (
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the Service interface
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) CreateKey(name string, campaignIDs []string, actions []string, createdBy string) (string, *model.APIKey, error) {
+	args := m.Called(name, campaignIDs, actions, createdBy)
+	key := args.Get(1)
+	if key == nil {
+		return args.String(0), nil, args.Error(2)
+	}
+	return args.String(0), key.(*model.APIKey), args.Error(2)
+}
+
+func (m *MockService) ListKeys() ([]model.APIKey, error) {
+	args := m.Called()
+	if keys, ok := args.Get(0).([]model.APIKey); ok {
+		return keys, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockService) RevokeKey(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockService) VerifyKey(rawKey string) (*model.APIKey, error) {
+	args := m.Called(rawKey)
+	key := args.Get(0)
+	if key == nil {
+		return nil, args.Error(1)
+	}
+	return key.(*model.APIKey), args.Error(1)
+}
@@ -0,0 +1,82 @@
+package apikey
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"trinity/internal/auth"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_CreateKey_StoresOnlyHash(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("CreateKey", mock.AnythingOfType("*model.APIKey")).Return(nil)
+
+	rawKey, key, err := service.CreateKey("Reseller", []string{"campaign123"}, []string{string(auth.PermRedeemVouchers)}, "admin1")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.True(t, strings.HasPrefix(rawKey, keyPrefix), "Key should carry the partner prefix")
+	assert.Equal(t, hashKey(rawKey), key.Hash, "Only the hash should be stored")
+	assert.NotContains(t, key.Hash, rawKey, "The raw key must not be stored")
+	assert.True(t, strings.HasPrefix(rawKey, key.Prefix), "Prefix should identify the key")
+	assert.Equal(t, "admin1", key.CreatedBy, "Creator should be recorded")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_CreateKey_InvalidAction(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	_, key, err := service.CreateKey("Reseller", []string{"campaign123"}, []string{string(auth.PermManageUsers)}, "admin1")
+
+	assert.ErrorIs(t, err, ErrInvalidAction, "Partners should not be granted user management")
+	assert.Nil(t, key, "Expected no key to be returned")
+	mockRepo.AssertNotCalled(t, "CreateKey", mock.Anything)
+}
+
+func TestService_VerifyKey_RecordsLastUsed(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	stored := &model.APIKey{Id: "key123", Actions: []string{string(auth.PermRedeemVouchers)}}
+	mockRepo.On("GetKeyByHash", hashKey("trk_secret")).Return(stored, nil)
+	mockRepo.On("TouchLastUsed", "key123", mock.AnythingOfType("time.Time")).Return(nil)
+
+	key, err := service.VerifyKey("trk_secret")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.NotNil(t, key.LastUsedAt, "Last used time should be set")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_VerifyKey_Revoked(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	revokedAt := time.Now().Add(-time.Hour)
+	stored := &model.APIKey{Id: "key123", RevokedAt: &revokedAt}
+	mockRepo.On("GetKeyByHash", hashKey("trk_secret")).Return(stored, nil)
+
+	key, err := service.VerifyKey("trk_secret")
+
+	assert.ErrorIs(t, err, ErrKeyRevoked, "Revoked keys should be rejected")
+	assert.Nil(t, key, "Expected no key to be returned")
+	mockRepo.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything)
+}
+
+func TestService_VerifyKey_Unknown(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetKeyByHash", hashKey("trk_unknown")).Return(nil, ErrKeyNotFound)
+
+	key, err := service.VerifyKey("trk_unknown")
+
+	assert.ErrorIs(t, err, ErrKeyNotFound, "Unknown keys should be rejected")
+	assert.Nil(t, key, "Expected no key to be returned")
+}
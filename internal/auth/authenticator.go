@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+	"trinity/internal/model"
+	"trinity/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidToken is returned when a token fails parsing or verification
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrSigningDisabled is returned when no HS256 secret is configured for issuing tokens
+	ErrSigningDisabled = errors.New("token signing is not configured")
+)
+
+// Claims are the JWT claims understood by the service
+type Claims struct {
+	jwt.RegisteredClaims
+	Role model.Role `json:"role,omitempty"`
+}
+
+// KeyVerifier resolves a raw partner API key to its stored record
+type KeyVerifier interface {
+	VerifyKey(rawKey string) (*model.APIKey, error)
+}
+
+// RoleStore supplies the stored role of a token's subject, so role changes
+// apply to tokens already issued. found is false for subjects without a
+// user record, such as users of an external identity provider.
+type RoleStore interface {
+	CurrentRole(userID string) (role model.Role, found bool, err error)
+}
+
+// Authenticator issues HS256 tokens and verifies HS256 and RS256 tokens
+// and partner API keys
+type Authenticator struct {
+	secret  []byte
+	rsaKeys map[string]*rsa.PublicKey
+	keys    KeyVerifier
+	roles   RoleStore
+	issuer  string
+	ttl     time.Duration
+	logger  logger.Logger
+}
+
+// NewAuthenticator creates a new Authenticator. The secret enables HS256 and
+// the JWKS file, when set, supplies the public keys accepted for RS256.
+func NewAuthenticator(secret, jwksPath, issuer string, ttl time.Duration) (*Authenticator, error) {
+	a := &Authenticator{
+		secret: []byte(secret),
+		issuer: issuer,
+		ttl:    ttl,
+		logger: logger.NewLogger("authenticator"),
+	}
+
+	if jwksPath != "" {
+		keys, err := loadJWKS(jwksPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JWKS: %w", err)
+		}
+		a.rsaKeys = keys
+	}
+
+	if len(a.secret) == 0 && len(a.rsaKeys) == 0 {
+		return nil, errors.New("either a JWT secret or a JWKS file must be configured")
+	}
+
+	return a, nil
+}
+
+// SetKeyVerifier enables authentication with partner API keys
+func (a *Authenticator) SetKeyVerifier(keys KeyVerifier) {
+	a.keys = keys
+}
+
+// SetRoleStore makes the middleware take the caller's role from the user
+// record rather than from the token, so a demotion applies at once
+func (a *Authenticator) SetRoleStore(roles RoleStore) {
+	a.roles = roles
+}
+
+// IssueToken signs an HS256 token for the given user
+func (a *Authenticator) IssueToken(user *model.User) (string, error) {
+	if len(a.secret) == 0 {
+		return "", ErrSigningDisabled
+	}
+
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Id,
+			Issuer:    a.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.ttl)),
+		},
+		Role: user.Role,
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.secret)
+}
+
+// ParseToken verifies the token signature and standard claims
+func (a *Authenticator) ParseToken(tokenString string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if a.issuer != "" {
+		options = append(options, jwt.WithIssuer(a.issuer))
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, a.keyFunc, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	// Tokens without a role claim belong to regular customers
+	if claims.Role == "" {
+		claims.Role = model.RoleCustomer
+	}
+	if !claims.Role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidToken, claims.Role)
+	}
+
+	return &claims, nil
+}
+
+// keyFunc selects the verification key according to the token's algorithm
+func (a *Authenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(a.secret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return a.secret, nil
+	case *jwt.SigningMethodRSA:
+		kid, _ := token.Header["kid"].(string)
+		key, ok := a.rsaKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"trinity/internal/model"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+// writeJWKS stores the public half of key in a temporary JWKS file
+func writeJWKS(t *testing.T, kid string, key *rsa.PrivateKey) string {
+	document := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+	data, err := json.Marshal(document)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// mintRS256 signs a token the way an external identity provider would
+func mintRS256(t *testing.T, kid string, key *rsa.PrivateKey, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func validClaims(subject string) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   subject,
+		Issuer:    "trinity",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func TestAuthenticator_IssueAndParseHS256(t *testing.T) {
+	a, err := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+	require.NoError(t, err)
+
+	token, err := a.IssueToken(&model.User{Id: "user123"})
+	require.NoError(t, err)
+
+	claims, err := a.ParseToken(token)
+	assert.NoError(t, err, "A freshly issued token should verify")
+	assert.Equal(t, "user123", claims.Subject, "Subject should be the user ID")
+}
+
+func TestAuthenticator_RejectsWrongSecret(t *testing.T) {
+	issuer, _ := NewAuthenticator("other-secret", "", "trinity", time.Hour)
+	token, err := issuer.IssueToken(&model.User{Id: "user123"})
+	require.NoError(t, err)
+
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+	_, err = a.ParseToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "Tokens signed with another secret should be rejected")
+}
+
+func TestAuthenticator_RejectsExpiredToken(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", -time.Minute)
+	token, err := a.IssueToken(&model.User{Id: "user123"})
+	require.NoError(t, err)
+
+	_, err = a.ParseToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "Expired tokens should be rejected")
+}
+
+func TestAuthenticator_RejectsUnsignedToken(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims("user123")).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	_, err = a.ParseToken(token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "alg=none tokens should be rejected")
+}
+
+func TestAuthenticator_ParseRS256FromJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	path := writeJWKS(t, "key-1", key)
+
+	a, err := NewAuthenticator("", path, "trinity", time.Hour)
+	require.NoError(t, err)
+
+	claims, err := a.ParseToken(mintRS256(t, "key-1", key, validClaims("user456")))
+	assert.NoError(t, err, "A token signed with a JWKS key should verify")
+	assert.Equal(t, "user456", claims.Subject, "Subject should be the user ID")
+
+	_, err = a.ParseToken(mintRS256(t, "key-2", key, validClaims("user456")))
+	assert.ErrorIs(t, err, ErrInvalidToken, "Tokens with an unknown key ID should be rejected")
+
+	_, err = a.IssueToken(&model.User{Id: "user456"})
+	assert.ErrorIs(t, err, ErrSigningDisabled, "Issuing requires an HS256 secret")
+}
+
+func TestAuthenticator_RejectsRS256SignedByOtherKey(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	a, err := NewAuthenticator(testSecret, writeJWKS(t, "key-1", key), "trinity", time.Hour)
+	require.NoError(t, err)
+
+	_, err = a.ParseToken(mintRS256(t, "key-1", other, validClaims("user456")))
+	assert.ErrorIs(t, err, ErrInvalidToken, "Tokens signed by a key outside the JWKS should be rejected")
+}
+
+func TestNewAuthenticator_RequiresKeyMaterial(t *testing.T) {
+	_, err := NewAuthenticator("", "", "trinity", time.Hour)
+	assert.Error(t, err, "An authenticator without secret or JWKS should not be created")
+}
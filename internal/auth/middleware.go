@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"trinity/internal/model"
+	"trinity/pkg/reason"
+	"trinity/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gin context keys holding the authenticated identity
+const (
+	userIDKey = "auth.user_id"
+	roleKey   = "auth.role"
+	apiKeyKey = "auth.api_key"
+)
+
+// APIKeyHeader carries partner API keys
+const APIKeyHeader = "X-API-Key"
+
+// Middleware rejects requests without a valid bearer token or partner API
+// key and stores the caller's identity in the Gin context
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rawKey := c.GetHeader(APIKeyHeader); rawKey != "" {
+			a.authenticateKey(c, rawKey)
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.Unauthorized.Message()})
+			return
+		}
+
+		claims, err := a.ParseToken(tokenString)
+		if err != nil {
+			a.logger.Warnf("Rejected token: %v", err)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.InvalidToken.Message()})
+			return
+		}
+
+		role, err := a.currentRole(claims)
+		if err != nil {
+			a.logger.Errorf("Failed to load role of %s: %v", claims.Subject, err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, response.ErrorResponse{Error: reason.InternalServerError.Message()})
+			return
+		}
+
+		c.Set(userIDKey, claims.Subject)
+		c.Set(roleKey, role)
+		c.Next()
+	}
+}
+
+// currentRole returns the role stored for the token's subject, falling
+// back to the token's role claim without a role store or a user record
+func (a *Authenticator) currentRole(claims *Claims) (model.Role, error) {
+	if a.roles == nil {
+		return claims.Role, nil
+	}
+
+	role, found, err := a.roles.CurrentRole(claims.Subject)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return claims.Role, nil
+	}
+	// Users stored before roles existed are customers
+	if role == "" {
+		return model.RoleCustomer, nil
+	}
+	return role, nil
+}
+
+// authenticateKey verifies a partner API key and stores it in the context
+func (a *Authenticator) authenticateKey(c *gin.Context, rawKey string) {
+	if a.keys == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.InvalidToken.Message()})
+		return
+	}
+
+	key, err := a.keys.VerifyKey(rawKey)
+	if err != nil {
+		a.logger.Warnf("Rejected API key: %v", err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.InvalidToken.Message()})
+		return
+	}
+
+	c.Set(apiKeyKey, key)
+	c.Next()
+}
+
+// UserID returns the authenticated user ID, or an empty string if the
+// request did not pass through the middleware
+func UserID(c *gin.Context) string {
+	return c.GetString(userIDKey)
+}
+
+// Role returns the authenticated caller's role, or an empty role if the
+// request did not pass through the middleware
+func Role(c *gin.Context) model.Role {
+	role, _ := c.Get(roleKey)
+	r, _ := role.(model.Role)
+	return r
+}
+
+// APIKey returns the partner API key used to authenticate, or nil if the
+// caller used a user token
+func APIKey(c *gin.Context) *model.APIKey {
+	key, _ := c.Get(apiKeyKey)
+	k, _ := key.(*model.APIKey)
+	return k
+}
+
+// Actor identifies the caller in audit records: the user ID for user
+// tokens, or "api_key:<id>" for partner API keys
+func Actor(c *gin.Context) string {
+	if key := APIKey(c); key != nil {
+		return "api_key:" + key.Id
+	}
+	return UserID(c)
+}
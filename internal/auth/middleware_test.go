@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"trinity/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRouter exposes an endpoint echoing the authenticated user ID
+func setupRouter(a *Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/whoami", a.Middleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, UserID(c))
+	})
+	return r
+}
+
+func performRequest(r http.Handler, authorization string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/whoami", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_ValidToken(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+	token, err := a.IssueToken(&model.User{Id: "user123"})
+	require.NoError(t, err)
+
+	w := performRequest(setupRouter(a), "Bearer "+token)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK")
+	assert.Equal(t, "user123", w.Body.String(), "Handler should see the token subject")
+}
+
+func TestMiddleware_MissingToken(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+
+	w := performRequest(setupRouter(a), "")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Expected status 401 Unauthorized")
+}
+
+func TestMiddleware_InvalidToken(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+
+	w := performRequest(setupRouter(a), "Bearer not-a-jwt")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Expected status 401 Unauthorized")
+}
+
+func TestRequirePermission(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/whoami", a.Middleware(), RequirePermission(PermManageCampaigns), func(c *gin.Context) {
+		c.String(http.StatusOK, string(Role(c)))
+	})
+
+	for role, expected := range map[model.Role]int{
+		model.RoleAdmin:    http.StatusOK,
+		model.RoleMarketer: http.StatusOK,
+		model.RoleSupport:  http.StatusForbidden,
+		model.RoleCustomer: http.StatusForbidden,
+	} {
+		token, err := a.IssueToken(&model.User{Id: "user123", Role: role})
+		require.NoError(t, err)
+
+		w := performRequest(r, "Bearer "+token)
+		assert.Equal(t, expected, w.Code, "Unexpected status for role %s", role)
+	}
+}
+
+func TestMiddleware_DefaultsToCustomerRole(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/whoami", a.Middleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, string(Role(c)))
+	})
+
+	token, err := a.IssueToken(&model.User{Id: "user123"})
+	require.NoError(t, err)
+
+	w := performRequest(r, "Bearer "+token)
+	assert.Equal(t, string(model.RoleCustomer), w.Body.String(), "Tokens without a role should act as customers")
+}
+
+// stubRoles holds the stored roles of users
+type stubRoles map[string]model.Role
+
+func (r stubRoles) CurrentRole(userID string) (model.Role, bool, error) {
+	if userID == "broken" {
+		return "", false, errors.New("database error")
+	}
+	role, ok := r[userID]
+	return role, ok, nil
+}
+
+func TestMiddleware_UsesStoredRole(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+	a.SetRoleStore(stubRoles{"demoted": model.RoleCustomer})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/whoami", a.Middleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, string(Role(c)))
+	})
+
+	token, err := a.IssueToken(&model.User{Id: "demoted", Role: model.RoleAdmin})
+	require.NoError(t, err)
+	w := performRequest(r, "Bearer "+token)
+	assert.Equal(t, string(model.RoleCustomer), w.Body.String(), "A demotion should apply to tokens already issued")
+
+	token, err = a.IssueToken(&model.User{Id: "external", Role: model.RoleSupport})
+	require.NoError(t, err)
+	w = performRequest(r, "Bearer "+token)
+	assert.Equal(t, string(model.RoleSupport), w.Body.String(), "Subjects without a user record should keep the token's role")
+
+	token, err = a.IssueToken(&model.User{Id: "broken", Role: model.RoleAdmin})
+	require.NoError(t, err)
+	w = performRequest(r, "Bearer "+token)
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "A failed lookup should not fall back to the token's role")
+}
+
+// stubKeys verifies the raw keys it holds
+type stubKeys map[string]*model.APIKey
+
+func (k stubKeys) VerifyKey(rawKey string) (*model.APIKey, error) {
+	if key, ok := k[rawKey]; ok {
+		return key, nil
+	}
+	return nil, errors.New("unknown key")
+}
+
+func TestActor(t *testing.T) {
+	a, _ := NewAuthenticator(testSecret, "", "trinity", time.Hour)
+	a.SetKeyVerifier(stubKeys{"trk_partner": {Id: "key123"}})
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/whoami", a.Middleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, Actor(c))
+	})
+
+	token, err := a.IssueToken(&model.User{Id: "user123"})
+	require.NoError(t, err)
+	w := performRequest(r, "Bearer "+token)
+	assert.Equal(t, "user123", w.Body.String(), "Users should act under their user ID")
+
+	req, _ := http.NewRequest("GET", "/whoami", nil)
+	req.Header.Set(APIKeyHeader, "trk_partner")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, "api_key:key123", w.Body.String(), "Partners should act under their API key")
+}
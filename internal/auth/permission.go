@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"net/http"
+	"trinity/internal/model"
+	"trinity/pkg/reason"
+	"trinity/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission names an action guarded by role-based access control
+type Permission string
+
+const (
+	PermViewCampaigns    Permission = "campaigns:view"
+	PermManageCampaigns  Permission = "campaigns:manage"
+	PermGenerateVouchers Permission = "vouchers:generate"
+	PermRedeemVouchers   Permission = "vouchers:redeem"
+	PermPurchase         Permission = "purchases:create"
+	PermManageUsers      Permission = "users:manage"
+	PermManageAPIKeys    Permission = "api_keys:manage"
+)
+
+// PartnerPermissions are the permissions that may be granted to API keys
+var PartnerPermissions = []Permission{PermGenerateVouchers, PermRedeemVouchers}
+
+// rolePermissions lists what each role may do; admins may do everything
+var rolePermissions = map[model.Role][]Permission{
+	model.RoleMarketer: {PermViewCampaigns, PermManageCampaigns, PermGenerateVouchers},
+	model.RoleSupport:  {PermViewCampaigns},
+	model.RoleCustomer: {PermRedeemVouchers, PermPurchase},
+}
+
+// HasPermission reports whether the role grants the permission
+func HasPermission(role model.Role, permission Permission) bool {
+	if role == model.RoleAdmin {
+		return true
+	}
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission rejects authenticated callers whose role, or API key
+// scope, lacks the permission
+func RequirePermission(permission Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := HasPermission(Role(c), permission)
+		if key := APIKey(c); key != nil {
+			allowed = key.Allows(string(permission))
+		}
+
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{Error: reason.Forbidden.Message()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireCampaignScope rejects API keys not scoped to the campaign named by
+// the path parameter; user tokens are not campaign-scoped
+func RequireCampaignScope(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !CampaignAllowed(c, c.Param(param)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{Error: reason.Forbidden.Message()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CampaignAllowed reports whether the caller may act on the campaign
+func CampaignAllowed(c *gin.Context, campaignID string) bool {
+	if key := APIKey(c); key != nil {
+		return key.CoversCampaign(campaignID)
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package campaign
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"trinity/internal/model"
+)
+
+// record writes an audit entry for a change that has already been made.
+// A failed write is logged rather than undoing the change.
+func (s *service) record(entry model.AuditEntry) {
+	entry.At = time.Now()
+	if err := s.repo.CreateAuditEntry(&entry); err != nil {
+		s.logger.Errorf("Failed to record %s of campaign %s by %s: %v", entry.Action, entry.CampaignID, entry.Actor, err)
+	}
+}
+
+// recordIssued records vouchers issued to a campaign as a change of its
+// issued count from the count it had before
+func (s *service) recordIssued(campaignID string, actor string, issuedBefore int, count int, note string) {
+	if count == 0 {
+		return
+	}
+	s.record(model.AuditEntry{
+		CampaignID: campaignID,
+		Actor:      actor,
+		Action:     model.AuditGenerateVouchers,
+		Before:     map[string]interface{}{"issued": issuedBefore},
+		After:      map[string]interface{}{"issued": issuedBefore + count},
+		Note:       note,
+	})
+}
+
+// diff returns the values before and after of the campaign fields that
+// differ, keyed by their JSON names. The ID is left out. A nil before
+// stands for a campaign being created: no before values are returned and
+// after holds every field that is set.
+func diff(before, after *model.Campaign) (map[string]interface{}, map[string]interface{}) {
+	created := before == nil
+	if created {
+		before = &model.Campaign{}
+	}
+	b, a := reflect.ValueOf(*before), reflect.ValueOf(*after)
+
+	var old map[string]interface{}
+	if !created {
+		old = map[string]interface{}{}
+	}
+	changed := map[string]interface{}{}
+	for i := 0; i < b.NumField(); i++ {
+		name, _, _ := strings.Cut(b.Type().Field(i).Tag.Get("json"), ",")
+		if name == "id" || reflect.DeepEqual(b.Field(i).Interface(), a.Field(i).Interface()) {
+			continue
+		}
+		if !created {
+			old[name] = b.Field(i).Interface()
+		}
+		changed[name] = a.Field(i).Interface()
+	}
+	return old, changed
+}
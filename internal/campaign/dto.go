@@ -1,16 +1,288 @@
 package campaign
 
+import (
+	"fmt"
+	"mime/multipart"
+	"time"
+	"trinity/internal/model"
+)
+
 // CreateCampaignRequest represents the request payload for creating a campaign
 type CreateCampaignRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Discount    float64 `json:"discount" binding:"required,gt=0"`
-	MaxUsers    int     `json:"max_users" binding:"required,gt=0"`
-	StartDate   string  `json:"start_date" binding:"required"`
-	EndDate     string  `json:"end_date" binding:"required"`
-	Description string  `json:"description" binding:"required"`
+	Name     string  `json:"name" binding:"required"`
+	Discount float64 `json:"discount" binding:"required,gt=0"`
+	MaxUsers int     `json:"max_users" binding:"required,gt=0"`
+	// MaxIssued allows issuing more codes than MaxUsers lets redeem
+	MaxIssued   int               `json:"max_issued,omitempty" binding:"omitempty,gt=0"`
+	StartDate   string            `json:"start_date" binding:"required"`
+	EndDate     string            `json:"end_date" binding:"required"`
+	Description string            `json:"description" binding:"required"`
+	CodeFormat  *model.CodeFormat `json:"code_format,omitempty"`
+	// Validity makes vouchers valid for a number of days from issue or first
+	// view, capped by the end date
+	Validity *model.VoucherValidity `json:"validity,omitempty"`
+	// Eligibility restricts which users and orders the vouchers apply to
+	Eligibility *model.EligibilityRules `json:"eligibility,omitempty"`
+	// Condition is an expression over the purchase that must hold, e.g.
+	// plan == "gold" && user.purchases == 0 && now.weekday in [6, 7]
+	Condition string `json:"condition,omitempty"`
+	// Stacking decides whether the discount combines with other campaigns;
+	// campaigns are exclusive by default
+	Stacking *model.StackingPolicy `json:"stacking,omitempty"`
+	// Automatic applies the discount at checkout without a code
+	Automatic bool `json:"automatic,omitempty"`
+	// Budget caps the total discount given, in the currency of the prices
+	Budget float64 `json:"budget,omitempty" binding:"omitempty,gt=0"`
+	// Tiers replace the discount for the plans or order amounts they name
+	Tiers []model.DiscountTier `json:"tiers,omitempty"`
+	// Variants run an A/B test, each giving its own discount to the share
+	// of users its weight buckets into it
+	Variants []model.CampaignVariant `json:"variants,omitempty"`
+}
+
+// UpdateCampaignRequest represents the request payload for updating a
+// campaign; only the fields that are set are changed
+type UpdateCampaignRequest struct {
+	Name        *string                 `json:"name,omitempty"`
+	Discount    *float64                `json:"discount,omitempty" binding:"omitempty,gt=0"`
+	MaxUsers    *int                    `json:"max_users,omitempty" binding:"omitempty,gt=0"`
+	MaxIssued   *int                    `json:"max_issued,omitempty" binding:"omitempty,gte=0"`
+	StartDate   *string                 `json:"start_date,omitempty"`
+	EndDate     *string                 `json:"end_date,omitempty"`
+	Description *string                 `json:"description,omitempty"`
+	Validity    *model.VoucherValidity  `json:"validity,omitempty"`
+	Eligibility *model.EligibilityRules `json:"eligibility,omitempty"`
+	// Condition replaces the campaign condition; an empty string removes it
+	Condition *string               `json:"condition,omitempty"`
+	Stacking  *model.StackingPolicy `json:"stacking,omitempty"`
+	// Budget replaces the campaign budget; zero removes it
+	Budget *float64 `json:"budget,omitempty" binding:"omitempty,gte=0"`
+	// Tiers replaces the tier table; an empty list removes it
+	Tiers *[]model.DiscountTier `json:"tiers,omitempty"`
+	// Variants replaces the A/B test variants; an empty list ends the test.
+	// Changing weights moves users between variants.
+	Variants *[]model.CampaignVariant `json:"variants,omitempty"`
+}
+
+// apply copies the set fields onto the campaign
+func (r UpdateCampaignRequest) apply(campaign *model.Campaign) error {
+	if r.Name != nil {
+		campaign.Name = *r.Name
+	}
+	if r.Discount != nil {
+		campaign.Discount = *r.Discount
+	}
+	if r.MaxUsers != nil {
+		campaign.MaxUsers = *r.MaxUsers
+	}
+	if r.MaxIssued != nil {
+		campaign.MaxIssued = *r.MaxIssued
+	}
+	if r.StartDate != nil {
+		startDate, err := time.Parse(time.RFC3339, *r.StartDate)
+		if err != nil {
+			return fmt.Errorf("%w: invalid start date: %v", ErrInvalidCampaign, err)
+		}
+		campaign.StartDate = startDate
+	}
+	if r.EndDate != nil {
+		endDate, err := time.Parse(time.RFC3339, *r.EndDate)
+		if err != nil {
+			return fmt.Errorf("%w: invalid end date: %v", ErrInvalidCampaign, err)
+		}
+		campaign.EndDate = endDate
+	}
+	if r.Description != nil {
+		campaign.Description = *r.Description
+	}
+	if r.Validity != nil {
+		campaign.Validity = r.Validity
+	}
+	if r.Eligibility != nil {
+		campaign.Eligibility = r.Eligibility
+	}
+	if r.Condition != nil {
+		campaign.Condition = *r.Condition
+	}
+	if r.Stacking != nil {
+		campaign.Stacking = r.Stacking
+	}
+	if r.Budget != nil {
+		campaign.Budget = *r.Budget
+	}
+	if r.Tiers != nil {
+		campaign.Tiers = *r.Tiers
+	}
+	if r.Variants != nil {
+		campaign.Variants = *r.Variants
+	}
+	return nil
+}
+
+// CreateFromTemplateRequest represents the settings replacing a template's
+// when a campaign is created from it. Besides the fields a campaign update
+// can change, the code format and automatic application can be set, since
+// no vouchers exist yet.
+type CreateFromTemplateRequest struct {
+	UpdateCampaignRequest
+	CodeFormat *model.CodeFormat `json:"code_format,omitempty"`
+	Automatic  *bool             `json:"automatic,omitempty"`
+}
+
+// apply copies the set fields onto the campaign
+func (r CreateFromTemplateRequest) apply(campaign *model.Campaign) error {
+	if err := r.UpdateCampaignRequest.apply(campaign); err != nil {
+		return err
+	}
+	if r.CodeFormat != nil {
+		campaign.CodeFormat = r.CodeFormat
+	}
+	if r.Automatic != nil {
+		campaign.Automatic = *r.Automatic
+	}
+	return nil
+}
+
+// CloneCampaignRequest represents the request payload for cloning a campaign
+type CloneCampaignRequest struct {
+	// Name defaults to the name of the campaign being cloned
+	Name string `json:"name,omitempty"`
+	// StartDate is when the clone starts, RFC 3339; it runs as long as the original
+	StartDate string `json:"start_date" binding:"required"`
+}
+
+// CreateTemplateRequest represents the request payload for saving a campaign template
+type CreateTemplateRequest struct {
+	Name        string                  `json:"name" binding:"required"`
+	Discount    float64                 `json:"discount" binding:"required,gt=0"`
+	Tiers       []model.DiscountTier    `json:"tiers,omitempty"`
+	Stacking    *model.StackingPolicy   `json:"stacking,omitempty"`
+	Automatic   bool                    `json:"automatic,omitempty"`
+	MaxUsers    int                     `json:"max_users,omitempty" binding:"omitempty,gt=0"`
+	MaxIssued   int                     `json:"max_issued,omitempty" binding:"omitempty,gt=0"`
+	Budget      float64                 `json:"budget,omitempty" binding:"omitempty,gt=0"`
+	Description string                  `json:"description"`
+	CodeFormat  *model.CodeFormat       `json:"code_format,omitempty"`
+	Validity    *model.VoucherValidity  `json:"validity,omitempty"`
+	Eligibility *model.EligibilityRules `json:"eligibility,omitempty"`
+	Condition   string                  `json:"condition,omitempty"`
+}
+
+// CampaignResponse represents a campaign with the redemptions it has left
+type CampaignResponse struct {
+	model.Campaign
+	Remaining int `json:"remaining"`
+}
+
+// newCampaignResponse wraps a campaign for a response
+func newCampaignResponse(campaign *model.Campaign) CampaignResponse {
+	return CampaignResponse{Campaign: *campaign, Remaining: campaign.Remaining()}
+}
+
+// VariantResult reports how one variant of a campaign's A/B test performed
+type VariantResult struct {
+	Variant  string  `json:"variant"`
+	Weight   int     `json:"weight"`
+	Discount float64 `json:"discount"`
+	// Users counts the users bucketed into the variant at checkout
+	Users       int64 `json:"users"`
+	Redemptions int64 `json:"redemptions"`
+	Purchases   int64 `json:"purchases"`
+	// Conversion is the share of the variant's users who made a purchase
+	Conversion float64 `json:"conversion"`
+	// Revenue is what the variant's purchases were paid, after discounts
+	Revenue float64 `json:"revenue"`
+	// DiscountGiven is what the variant's discount took off those purchases
+	DiscountGiven float64 `json:"discount_given"`
+}
+
+// ConditionErrorResponse reports where a campaign condition failed to compile
+type ConditionErrorResponse struct {
+	Error   string `json:"error"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
 }
 
 // GenerateVouchersRequest represents the request payload for generating vouchers
 type GenerateVouchersRequest struct {
-	Count int `json:"count" binding:"required,gt=0"`
+	Count int `json:"count" binding:"omitempty,gt=0"`
+	// UserIds assigns one voucher to each user; count defaults to its length
+	UserIds []string `json:"user_ids,omitempty"`
+	// Async generates the vouchers in a background job and returns the job
+	Async bool `json:"async"`
+}
+
+// PartialVouchersResponse reports a voucher generation that failed part
+// way, with the vouchers created before the failure, which stay issued
+type PartialVouchersResponse struct {
+	Error    string          `json:"error"`
+	Vouchers []model.Voucher `json:"vouchers"`
+}
+
+// CreateSharedVoucherRequest represents the request payload for creating a multi-use voucher
+type CreateSharedVoucherRequest struct {
+	Code           string `json:"code" binding:"required"`
+	MaxRedemptions int    `json:"max_redemptions" binding:"required,gt=0"`
+	PerUserLimit   int    `json:"per_user_limit" binding:"gte=0"`
+}
+
+// RevokeVouchersRequest represents the request payload for revoking a
+// campaign's unused vouchers; without codes or dates all of them are revoked
+type RevokeVouchersRequest struct {
+	Reason string   `json:"reason" binding:"required"`
+	Codes  []string `json:"codes"`
+	// IssuedAfter and IssuedBefore are RFC 3339 timestamps bounding when
+	// the vouchers were generated
+	IssuedAfter  string `json:"issued_after"`
+	IssuedBefore string `json:"issued_before"`
+}
+
+// RevokeVouchersResponse reports how many vouchers a bulk revocation voided
+type RevokeVouchersResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// ImportVouchersRequest represents the multipart form for importing voucher codes
+type ImportVouchersRequest struct {
+	File *multipart.FileHeader `form:"file" binding:"required"`
+}
+
+// ImportStatus is the outcome of one row of a voucher import
+type ImportStatus string
+
+const (
+	ImportAccepted     ImportStatus = "accepted"
+	ImportDuplicate    ImportStatus = "duplicate"
+	ImportInvalid      ImportStatus = "invalid"
+	ImportOverCapacity ImportStatus = "over_capacity"
+	ImportSkipped      ImportStatus = "skipped"
+)
+
+// ImportRow reports what happened to one row of an imported code list
+type ImportRow struct {
+	Row    int          `json:"row"`
+	Code   string       `json:"code"`
+	Status ImportStatus `json:"status"`
+	Reason string       `json:"reason,omitempty"`
+	// VoucherCode is the code as stored, with its check character, for
+	// accepted rows
+	VoucherCode string `json:"voucher_code,omitempty"`
+}
+
+// ImportVouchersResponse summarizes a voucher import with a per-row report
+type ImportVouchersResponse struct {
+	Accepted     int         `json:"accepted"`
+	Duplicate    int         `json:"duplicate"`
+	Invalid      int         `json:"invalid"`
+	OverCapacity int         `json:"over_capacity"`
+	Skipped      int         `json:"skipped"`
+	Rows         []ImportRow `json:"rows"`
+}
+
+// PartialImportResponse reports a voucher import that failed part way.
+// Rows accepted before the failure stay issued; the rest are skipped.
+type PartialImportResponse struct {
+	Error  string                  `json:"error"`
+	Report *ImportVouchersResponse `json:"report"`
 }
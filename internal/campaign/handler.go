@@ -1,9 +1,15 @@
 package campaign
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
+	"trinity/internal/auth"
+	"trinity/internal/eligibility"
 	"trinity/internal/model"
+	"trinity/internal/pricing"
+	"trinity/internal/voucher"
 	"trinity/pkg/logger"
 	"trinity/pkg/reason"
 	"trinity/pkg/response"
@@ -17,6 +23,9 @@ type Handler struct {
 	logger  logger.Logger
 }
 
+// maxImportSize bounds uploaded code lists, comfortably above a million codes
+const maxImportSize = 64 << 20
+
 // NewHandler creates a new Campaign handler
 func NewHandler(service Service) *Handler {
 	return &Handler{
@@ -25,22 +34,44 @@ func NewHandler(service Service) *Handler {
 	}
 }
 
-// RegisterRoutes registers the campaign routes with the Gin router
+// RegisterRoutes registers the read-only campaign routes with the Gin router
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
-	rg.POST("/", h.CreateCampaign)
 	rg.GET("/", h.ListCampaigns)
+}
+
+// RegisterAdminRoutes registers the campaign management routes with the Gin router
+func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.POST("/", h.CreateCampaign)
+	rg.PATCH("/:id", h.UpdateCampaign)
+	rg.POST("/:id/clone", h.CloneCampaign)
+	rg.GET("/:id/results", h.GetVariantResults)
+	rg.GET("/:id/history", h.GetHistory)
+	rg.GET("/templates", h.ListTemplates)
+	rg.POST("/templates", h.CreateTemplate)
+	rg.POST("/templates/:name/campaigns", h.CreateFromTemplate)
+	rg.POST("/:id/vouchers/revoke", h.RevokeVouchers)
+}
+
+// RegisterVoucherRoutes registers the voucher generation routes with the Gin router
+func (h *Handler) RegisterVoucherRoutes(rg *gin.RouterGroup) {
 	rg.POST("/:id/vouchers", h.GenerateVouchers)
+	rg.GET("/:id/vouchers/export", h.ExportVouchers)
+	rg.POST("/:id/vouchers/import", h.ImportVouchers)
+	rg.POST("/:id/vouchers/shared", h.CreateSharedVoucher)
 }
 
 // CreateCampaign godoc
 // @Summary Create a new campaign
-// @Description Create a new promotional campaign
+// @Description Create a new promotional campaign. Conditions are compiled before saving and compile errors report their line and column.
 // @Tags Campaign
 // @Accept  json
 // @Produce  json
+// @Security BearerAuth
 // @Param campaign body campaign.CreateCampaignRequest true "Campaign Data"
-// @Success 201 {object} model.Campaign
+// @Success 201 {object} campaign.CampaignResponse
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /campaigns [post]
 func (h *Handler) CreateCampaign(c *gin.Context) {
@@ -73,34 +104,334 @@ func (h *Handler) CreateCampaign(c *gin.Context) {
 		Name:        req.Name,
 		Discount:    req.Discount,
 		MaxUsers:    req.MaxUsers,
-		UsedUsers:   0,
+		MaxIssued:   req.MaxIssued,
+		Issued:      0,
 		StartDate:   startDate,
 		EndDate:     endDate,
 		Description: req.Description,
+		CodeFormat:  req.CodeFormat,
+		Validity:    req.Validity,
+		Eligibility: req.Eligibility,
+		Condition:   req.Condition,
+		Stacking:    req.Stacking,
+		Automatic:   req.Automatic,
+		Budget:      req.Budget,
+		Tiers:       req.Tiers,
+		Variants:    req.Variants,
+		Status:      model.CampaignActive,
 	}
 
-	id, err := h.service.CreateCampaign(&campaign)
+	id, err := h.service.CreateCampaign(&campaign, auth.Actor(c))
 	if err != nil {
+		h.respondCreateError(c, err)
+		return
+	}
+	campaign.Id = id
+	c.JSON(http.StatusCreated, newCampaignResponse(&campaign))
+}
+
+// UpdateCampaign godoc
+// @Summary Update a campaign
+// @Description Change the given campaign settings and leave the others as they are. Conditions are compiled before saving and compile errors report their line and column.
+// @Tags Campaign
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path string true "Campaign ID"
+// @Param campaign body campaign.UpdateCampaignRequest true "Settings to change"
+// @Success 200 {object} campaign.CampaignResponse
+// @Failure 400 {object} campaign.ConditionErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/{id} [patch]
+func (h *Handler) UpdateCampaign(c *gin.Context) {
+	var req UpdateCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	campaign, err := h.service.UpdateCampaign(c.Param("id"), req, auth.Actor(c))
+	if err != nil {
+		if h.respondSettingsError(c, err) {
+			return
+		}
 		msg := reason.InternalServerError.Message()
 		h.logger.Errorf("%s: %v", msg, err)
 		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
 		return
 	}
-	campaign.Id = id
-	c.JSON(http.StatusCreated, campaign)
+
+	c.JSON(http.StatusOK, newCampaignResponse(campaign))
+}
+
+// CloneCampaign godoc
+// @Summary Clone a campaign
+// @Description Create a campaign with the settings of another, moved to a new start date for the same length of time. Vouchers, counters and spend are not copied.
+// @Tags Campaign
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path string true "Campaign ID"
+// @Param request body campaign.CloneCampaignRequest true "New name and start date"
+// @Success 201 {object} campaign.CampaignResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/{id}/clone [post]
+func (h *Handler) CloneCampaign(c *gin.Context) {
+	var req CloneCampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	start, err := time.Parse(time.RFC3339, req.StartDate)
+	if err != nil {
+		msg := "Invalid start date format."
+		h.logger.Errorf("%s: %v", reason.InvalidRequestFormat.Message(), err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	campaign, err := h.service.CloneCampaign(c.Param("id"), req.Name, start, auth.Actor(c))
+	if err != nil {
+		h.respondCreateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newCampaignResponse(campaign))
+}
+
+// CreateTemplate godoc
+// @Summary Save a campaign template
+// @Description Save campaign settings under a name so campaigns can be created from them
+// @Tags Campaign
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param request body campaign.CreateTemplateRequest true "Template settings"
+// @Success 201 {object} model.CampaignTemplate
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/templates [post]
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	var req CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	template := model.CampaignTemplate{
+		Name:        req.Name,
+		Discount:    req.Discount,
+		Tiers:       req.Tiers,
+		Stacking:    req.Stacking,
+		Automatic:   req.Automatic,
+		MaxUsers:    req.MaxUsers,
+		MaxIssued:   req.MaxIssued,
+		Budget:      req.Budget,
+		Description: req.Description,
+		CodeFormat:  req.CodeFormat,
+		Validity:    req.Validity,
+		Eligibility: req.Eligibility,
+		Condition:   req.Condition,
+	}
+
+	if err := h.service.CreateTemplate(&template); err != nil {
+		if errors.Is(err, ErrTemplateExists) {
+			c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.TemplateExists.Message()})
+			return
+		}
+		h.respondCreateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetVariantResults godoc
+// @Summary Report a campaign's A/B test results
+// @Description Report for each variant of the campaign how many users were bucketed into it at checkout, how many redeemed and purchased, the share who purchased, and the revenue and discount of their purchases
+// @Tags Campaign
+// @Produce  json
+// @Security BearerAuth
+// @Param id path string true "Campaign ID"
+// @Success 200 {array} campaign.VariantResult
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/{id}/results [get]
+func (h *Handler) GetVariantResults(c *gin.Context) {
+	results, err := h.service.GetVariantResults(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrNoVariants) {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: reason.NoVariants.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// GetHistory godoc
+// @Summary List a campaign's change history
+// @Description Retrieve the audit entries of a campaign, newest first. Each entry names the actor, the time and the kind of change, with the changed fields before and after.
+// @Tags Campaign
+// @Produce  json
+// @Security BearerAuth
+// @Param id path string true "Campaign ID"
+// @Success 200 {array} model.AuditEntry
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/{id}/history [get]
+func (h *Handler) GetHistory(c *gin.Context) {
+	entries, err := h.service.GetHistory(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrCampaignNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.CampaignNotFound.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// ListTemplates godoc
+// @Summary List campaign templates
+// @Description Retrieve all campaign templates by name
+// @Tags Campaign
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {array} model.CampaignTemplate
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/templates [get]
+func (h *Handler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates()
+	if err != nil {
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateFromTemplate godoc
+// @Summary Create a campaign from a template
+// @Description Create a campaign with a template's settings, replacing only the fields given in the request. Besides the fields a campaign update accepts, the code format and automatic application can be set. Start and end dates are required.
+// @Tags Campaign
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param name path string true "Template name"
+// @Param campaign body campaign.CreateFromTemplateRequest true "Settings replacing the template's"
+// @Success 201 {object} campaign.CampaignResponse
+// @Failure 400 {object} campaign.ConditionErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/templates/{name}/campaigns [post]
+func (h *Handler) CreateFromTemplate(c *gin.Context) {
+	var req CreateFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	campaign, err := h.service.CreateFromTemplate(c.Param("name"), req, auth.Actor(c))
+	if err != nil {
+		if errors.Is(err, ErrTemplateNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.TemplateNotFound.Message()})
+			return
+		}
+		h.respondCreateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newCampaignResponse(campaign))
+}
+
+// respondCreateError maps the errors of creating a campaign or template
+func (h *Handler) respondCreateError(c *gin.Context, err error) {
+	if errors.Is(err, voucher.ErrInvalidCodeFormat) || errors.Is(err, voucher.ErrCodeSpaceTooSmall) {
+		h.respondCodeFormatError(c, err)
+		return
+	}
+	if h.respondSettingsError(c, err) {
+		return
+	}
+	msg := reason.InternalServerError.Message()
+	h.logger.Errorf("%s: %v", msg, err)
+	c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+}
+
+// respondSettingsError answers invalid campaign settings with 400, pointing
+// at the position of condition compile errors, and reports whether it did
+func (h *Handler) respondSettingsError(c *gin.Context, err error) bool {
+	var conditionErr *eligibility.ConditionError
+	if errors.As(err, &conditionErr) {
+		c.JSON(http.StatusBadRequest, ConditionErrorResponse{
+			Error:   reason.InvalidCondition.Message(),
+			Line:    conditionErr.Line,
+			Column:  conditionErr.Column,
+			Message: conditionErr.Message,
+		})
+		return true
+	}
+	if errors.Is(err, ErrInvalidCampaign) || errors.Is(err, ErrInvalidValidity) || errors.Is(err, eligibility.ErrInvalidRules) || errors.Is(err, pricing.ErrInvalidTiers) || errors.Is(err, pricing.ErrInvalidVariants) {
+		msg := reason.InvalidRequest.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return true
+	}
+	return false
 }
 
 // GenerateVouchers godoc
 // @Summary Generate vouchers for a campaign
-// @Description Generate vouchers for the specified campaign
+// @Description Generate vouchers for the specified campaign. With user_ids, one personal voucher is generated per user that only that user can redeem. With async set, the vouchers are generated in a background job whose progress is available at /jobs/{id}. If generation fails part way, the 500 response lists the vouchers created before the failure.
 // @Tags Campaign
 // @Accept  json
 // @Produce  json
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Param id path string true "Campaign ID"
 // @Param request body campaign.GenerateVouchersRequest true "Number of vouchers to generate"
 // @Success 200 {array} model.Voucher
+// @Success 202 {object} model.Job
 // @Failure 400 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} campaign.PartialVouchersResponse
 // @Router /campaigns/{id}/vouchers [post]
 func (h *Handler) GenerateVouchers(c *gin.Context) {
 	campaignID := c.Param("id")
@@ -114,6 +445,10 @@ func (h *Handler) GenerateVouchers(c *gin.Context) {
 		return
 	}
 
+	// Personal vouchers default to one per listed user
+	if req.Count == 0 {
+		req.Count = len(req.UserIds)
+	}
 	if req.Count <= 0 {
 		msg := reason.InvalidRequest.Message()
 		h.logger.Errorf("%s: count must be greater than zero", msg)
@@ -121,15 +456,254 @@ func (h *Handler) GenerateVouchers(c *gin.Context) {
 		return
 	}
 
-	vouchers, err := h.service.GenerateVouchers(campaignID, req.Count)
+	if req.Async {
+		job, err := h.service.StartVoucherJob(campaignID, req.Count, req.UserIds, auth.Actor(c))
+		if err != nil {
+			h.respondGenerateError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	vouchers, err := h.service.GenerateVouchers(campaignID, req.Count, req.UserIds, auth.Actor(c))
+	if err != nil && len(vouchers) > 0 {
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: generated %d of %d vouchers: %v", msg, len(vouchers), req.Count, err)
+		c.JSON(http.StatusInternalServerError, PartialVouchersResponse{Error: msg, Vouchers: vouchers})
+		return
+	}
 	if err != nil {
+		h.respondGenerateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, vouchers)
+}
+
+// respondGenerateError reports a failed voucher generation request
+func (h *Handler) respondGenerateError(c *gin.Context, err error) {
+	if errors.Is(err, voucher.ErrCodeSpaceTooSmall) {
+		h.respondCodeFormatError(c, err)
+		return
+	}
+	if errors.Is(err, ErrInvalidAssignment) {
+		msg := reason.InvalidRequest.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+	if errors.Is(err, ErrNotEnoughVouchers) {
+		msg := reason.NotEnoughVouchers.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+	msg := reason.InternalServerError.Message()
+	h.logger.Errorf("%s: %v", msg, err)
+	c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+}
+
+// ExportVouchers godoc
+// @Summary Export a campaign's vouchers
+// @Description Stream every voucher code of the campaign with its status and expiry date as a CSV or XLSX file
+// @Tags Campaign
+// @Produce  text/csv
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Campaign ID"
+// @Param format query string false "Export format" Enums(csv, xlsx) default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/{id}/vouchers/export [get]
+func (h *Handler) ExportVouchers(c *gin.Context) {
+	campaignID := c.Param("id")
+
+	format, err := voucher.ParseExportFormat(c.DefaultQuery("format", string(voucher.ExportCSV)))
+	if err != nil {
+		msg := reason.InvalidRequest.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="campaign-%s-vouchers.%s"`, campaignID, format))
+
+	if err := h.service.ExportVouchers(campaignID, format, c.Writer); err != nil {
+		// Once rows have been streamed the status can no longer change
+		if c.Writer.Written() {
+			h.logger.Errorf("%s: %v", reason.InternalServerError.Message(), err)
+			return
+		}
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		if errors.Is(err, ErrCampaignNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.CampaignNotFound.Message()})
+			return
+		}
 		msg := reason.InternalServerError.Message()
 		h.logger.Errorf("%s: %v", msg, err)
 		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+	}
+}
+
+// ImportVouchers godoc
+// @Summary Import voucher codes into a campaign
+// @Description Upload a CSV file whose first column holds externally generated codes. A check character is appended to every code, and codes are inserted until the campaign is full and each row is reported as accepted, duplicate, invalid or over_capacity. Codes must match the campaign's code format prefix and alphabet when it has one. Accepted rows carry the voucher_code to hand out. If the import fails part way, the 500 response carries the report, with the rows not yet inserted marked skipped.
+// @Tags Campaign
+// @Accept  multipart/form-data
+// @Produce  json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Campaign ID"
+// @Param file formData file true "CSV file of codes"
+// @Success 200 {object} campaign.ImportVouchersResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} campaign.PartialImportResponse
+// @Router /campaigns/{id}/vouchers/import [post]
+func (h *Handler) ImportVouchers(c *gin.Context) {
+	campaignID := c.Param("id")
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var req ImportVouchersRequest
+	if err := c.ShouldBind(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
 		return
 	}
 
-	c.JSON(http.StatusOK, vouchers)
+	file, err := req.File.Open()
+	if err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+	defer file.Close()
+
+	report, err := h.service.ImportVouchers(campaignID, file, auth.Actor(c))
+	if err != nil && report != nil {
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: imported %d vouchers: %v", msg, report.Accepted, err)
+		c.JSON(http.StatusInternalServerError, PartialImportResponse{Error: msg, Report: report})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidImportFile) {
+			msg := reason.InvalidRequestFormat.Message()
+			h.logger.Errorf("%s: %v", msg, err)
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// CreateSharedVoucher godoc
+// @Summary Create a multi-use voucher
+// @Description Create a shared code that up to max_redemptions users can redeem, each at most per_user_limit times (default 1). A check character is appended to the code, and the response holds the code to hand out.
+// @Tags Campaign
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Campaign ID"
+// @Param request body campaign.CreateSharedVoucherRequest true "Shared voucher data"
+// @Success 201 {object} model.Voucher
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/{id}/vouchers/shared [post]
+func (h *Handler) CreateSharedVoucher(c *gin.Context) {
+	var req CreateSharedVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	shared, err := h.service.CreateSharedVoucher(c.Param("id"), req.Code, req.MaxRedemptions, req.PerUserLimit, auth.Actor(c))
+	if err != nil {
+		if errors.Is(err, voucher.ErrMalformedCode) {
+			h.respondCodeFormatError(c, err)
+			return
+		}
+		if errors.Is(err, ErrCodeTaken) {
+			c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.VoucherCodeTaken.Message()})
+			return
+		}
+		h.respondGenerateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, shared)
+}
+
+// RevokeVouchers godoc
+// @Summary Revoke a campaign's unused vouchers
+// @Description Void every unused voucher of the campaign, or only those matching the listed codes and issue dates, so they can no longer be redeemed
+// @Tags Campaign
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path string true "Campaign ID"
+// @Param request body campaign.RevokeVouchersRequest true "Revocation reason and filter"
+// @Success 200 {object} campaign.RevokeVouchersResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /campaigns/{id}/vouchers/revoke [post]
+func (h *Handler) RevokeVouchers(c *gin.Context) {
+	var req RevokeVouchersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	filter := voucher.RevokeFilter{Codes: req.Codes}
+	var err error
+	if filter.IssuedAfter, err = parseOptionalTime(req.IssuedAfter); err != nil {
+		msg := reason.InvalidRequest.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+	if filter.IssuedBefore, err = parseOptionalTime(req.IssuedBefore); err != nil {
+		msg := reason.InvalidRequest.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	revoked, err := h.service.RevokeVouchers(c.Param("id"), filter, req.Reason, auth.Actor(c))
+	if err != nil {
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, RevokeVouchersResponse{Revoked: revoked})
 }
 
 // ListCampaigns godoc
@@ -138,7 +712,10 @@ func (h *Handler) GenerateVouchers(c *gin.Context) {
 // @Tags Campaign
 // @Accept  json
 // @Produce  json
-// @Success 200 {array} model.Campaign
+// @Security BearerAuth
+// @Success 200 {array} campaign.CampaignResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /campaigns [get]
 func (h *Handler) ListCampaigns(c *gin.Context) {
@@ -150,5 +727,28 @@ func (h *Handler) ListCampaigns(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, campaigns)
+	responses := make([]CampaignResponse, len(campaigns))
+	for i := range campaigns {
+		responses[i] = newCampaignResponse(&campaigns[i])
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// respondCodeFormatError reports a rejected voucher code format
+func (h *Handler) respondCodeFormatError(c *gin.Context, err error) {
+	msg := reason.InvalidCodeFormat.Message()
+	h.logger.Errorf("%s: %v", msg, err)
+	c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+}
+
+// parseOptionalTime parses an RFC 3339 timestamp, treating an empty string as unset
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
 }
@@ -3,11 +3,18 @@ package campaign
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+	"trinity/internal/apikey"
+	"trinity/internal/auth"
+	"trinity/internal/eligibility"
 	"trinity/internal/model"
+	"trinity/internal/voucher"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -51,7 +58,7 @@ func TestHandler_CreateCampaign_Success(t *testing.T) {
 	campaignID := "campaign123"
 
 	// Set expectations
-	mockService.On("CreateCampaign", mock.AnythingOfType("*model.Campaign")).Return(campaignID, nil)
+	mockService.On("CreateCampaign", mock.AnythingOfType("*model.Campaign"), "").Return(campaignID, nil)
 
 	w := performRequest(router, "POST", "/campaigns", requestBody)
 
@@ -67,3 +74,389 @@ func TestHandler_CreateCampaign_Success(t *testing.T) {
 
 	mockService.AssertExpectations(t)
 }
+
+func TestHandler_GenerateVouchers_Async(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers", handler.GenerateVouchers)
+
+	job := &model.Job{Id: "job123", CampaignID: "campaign123", Status: model.JobPending, Total: 50000}
+	mockService.On("StartVoucherJob", "campaign123", 50000, []string(nil), "").Return(job, nil)
+
+	w := performRequest(router, "POST", "/campaigns/campaign123/vouchers", GenerateVouchersRequest{Count: 50000, Async: true})
+
+	assert.Equal(t, http.StatusAccepted, w.Code, "Expected status code 202")
+	var response model.Job
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err, "Expected no error unmarshaling response")
+	assert.Equal(t, "job123", response.Id, "Expected the job ID to be returned")
+	mockService.AssertNotCalled(t, "GenerateVouchers", mock.Anything, mock.Anything, mock.Anything)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GenerateVouchers_ActorFromAPIKey(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	keys := new(apikey.MockService)
+	keys.On("VerifyKey", "trk_partner").Return(&model.APIKey{Id: "key123"}, nil)
+	authenticator, _ := auth.NewAuthenticator("test-secret", "", "trinity", time.Hour)
+	authenticator.SetKeyVerifier(keys)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers", authenticator.Middleware(), handler.GenerateVouchers)
+
+	job := &model.Job{Id: "job123", CampaignID: "campaign123", Status: model.JobPending, Total: 50000}
+	mockService.On("StartVoucherJob", "campaign123", 50000, []string(nil), "api_key:key123").Return(job, nil)
+
+	body, _ := json.Marshal(GenerateVouchersRequest{Count: 50000, Async: true})
+	req, _ := http.NewRequest("POST", "/campaigns/campaign123/vouchers", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(auth.APIKeyHeader, "trk_partner")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code, "Expected status code 202")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ExportVouchers_CSV(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.GET("/campaigns/:id/vouchers/export", handler.ExportVouchers)
+
+	mockService.On("ExportVouchers", "campaign123", voucher.ExportCSV, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(2).(io.Writer).Write([]byte("code,status,expiry_date\n"))
+	}).Return(nil)
+
+	w := performRequest(router, "GET", "/campaigns/campaign123/vouchers/export?format=csv", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status code 200")
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"), "Expected a CSV response")
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "campaign-campaign123-vouchers.csv", "Expected a download file name")
+	assert.Equal(t, "code,status,expiry_date\n", w.Body.String())
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ExportVouchers_UnsupportedFormat(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.GET("/campaigns/:id/vouchers/export", handler.ExportVouchers)
+
+	w := performRequest(router, "GET", "/campaigns/campaign123/vouchers/export?format=pdf", nil)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected status code 400")
+	mockService.AssertNotCalled(t, "ExportVouchers", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_ExportVouchers_CampaignNotFound(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.GET("/campaigns/:id/vouchers/export", handler.ExportVouchers)
+
+	mockService.On("ExportVouchers", "missing", voucher.ExportXLSX, mock.Anything).Return(ErrCampaignNotFound)
+
+	w := performRequest(router, "GET", "/campaigns/missing/vouchers/export?format=xlsx", nil)
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "Expected status code 404")
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json", "Errors should be reported as JSON")
+	assert.Empty(t, w.Header().Get("Content-Disposition"), "Errors should not be offered as a download")
+}
+
+func TestHandler_ImportVouchers_Success(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers/import", handler.ImportVouchers)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "codes.csv")
+	part.Write([]byte("PARTNER1\n"))
+	form.Close()
+
+	report := &ImportVouchersResponse{Accepted: 1, Rows: []ImportRow{{Row: 1, Code: "PARTNER1", Status: ImportAccepted}}}
+	mockService.On("ImportVouchers", "campaign123", mock.Anything, "").Return(report, nil)
+
+	req, _ := http.NewRequest("POST", "/campaigns/campaign123/vouchers/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status code 200")
+	var response ImportVouchersResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	assert.Equal(t, 1, response.Accepted, "Expected the report to be returned")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ImportVouchers_PartialFailure(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers/import", handler.ImportVouchers)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "codes.csv")
+	part.Write([]byte("PARTNER1\nPARTNER2\n"))
+	form.Close()
+
+	report := &ImportVouchersResponse{Accepted: 1, Skipped: 1, Rows: []ImportRow{
+		{Row: 1, Code: "PARTNER1", Status: ImportAccepted},
+		{Row: 2, Code: "PARTNER2", Status: ImportSkipped},
+	}}
+	mockService.On("ImportVouchers", "campaign123", mock.Anything, "").Return(report, errors.New("database error"))
+
+	req, _ := http.NewRequest("POST", "/campaigns/campaign123/vouchers/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "Expected status code 500")
+	var response PartialImportResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	if assert.NotNil(t, response.Report, "Expected the partial report to be returned") {
+		assert.Equal(t, 1, response.Report.Accepted, "Expected the imported rows to be reported")
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_ImportVouchers_MissingFile(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers/import", handler.ImportVouchers)
+
+	w := performRequest(router, "POST", "/campaigns/campaign123/vouchers/import", nil)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected status code 400")
+	mockService.AssertNotCalled(t, "ImportVouchers", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_CreateSharedVoucher_Success(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers/shared", handler.CreateSharedVoucher)
+
+	shared := &model.Voucher{Id: "voucher123", Code: voucher.WithCheckCharacter("WELCOME2026"), Mode: model.VoucherMultiUse, MaxRedemptions: 500, PerUserLimit: 1}
+	mockService.On("CreateSharedVoucher", "campaign123", "WELCOME2026", 500, 0, "").Return(shared, nil)
+
+	w := performRequest(router, "POST", "/campaigns/campaign123/vouchers/shared", CreateSharedVoucherRequest{Code: "WELCOME2026", MaxRedemptions: 500})
+
+	assert.Equal(t, http.StatusCreated, w.Code, "Expected status code 201")
+	var response model.Voucher
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	assert.Equal(t, shared.Code, response.Code, "Expected the code to hand out to be returned")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateSharedVoucher_CodeTaken(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers/shared", handler.CreateSharedVoucher)
+
+	mockService.On("CreateSharedVoucher", "campaign123", "WELCOME2026", 500, 1, "").Return(nil, ErrCodeTaken)
+
+	w := performRequest(router, "POST", "/campaigns/campaign123/vouchers/shared", CreateSharedVoucherRequest{Code: "WELCOME2026", MaxRedemptions: 500, PerUserLimit: 1})
+
+	assert.Equal(t, http.StatusConflict, w.Code, "Expected status code 409")
+}
+
+func TestHandler_GenerateVouchers_ForUsers(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers", handler.GenerateVouchers)
+
+	userIDs := []string{"user1", "user2"}
+	vouchers := []model.Voucher{{Code: "CODE1", AssignedUserId: "user1"}, {Code: "CODE2", AssignedUserId: "user2"}}
+	mockService.On("GenerateVouchers", "campaign123", 2, userIDs, "").Return(vouchers, nil)
+
+	w := performRequest(router, "POST", "/campaigns/campaign123/vouchers", GenerateVouchersRequest{UserIds: userIDs})
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected the count to default to the number of users")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_RevokeVouchers_Filtered(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers/revoke", handler.RevokeVouchers)
+
+	after := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	filter := voucher.RevokeFilter{Codes: []string{"CODE1"}, IssuedAfter: &after}
+	mockService.On("RevokeVouchers", "campaign123", filter, "batch leaked", "").Return(int64(1), nil)
+
+	w := performRequest(router, "POST", "/campaigns/campaign123/vouchers/revoke", RevokeVouchersRequest{
+		Reason:      "batch leaked",
+		Codes:       []string{"CODE1"},
+		IssuedAfter: "2026-05-01T00:00:00Z",
+	})
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status code 200")
+	var response RevokeVouchersResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	assert.Equal(t, int64(1), response.Revoked)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_RevokeVouchers_InvalidDate(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/vouchers/revoke", handler.RevokeVouchers)
+
+	w := performRequest(router, "POST", "/campaigns/campaign123/vouchers/revoke", RevokeVouchersRequest{Reason: "batch leaked", IssuedBefore: "yesterday"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected status code 400")
+	mockService.AssertNotCalled(t, "RevokeVouchers", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandler_UpdateCampaign_ConditionError(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.PATCH("/campaigns/:id", handler.UpdateCampaign)
+
+	condition := "plan == "
+	compileErr := &eligibility.ConditionError{Line: 1, Column: 9, Message: "unexpected token EOF"}
+	mockService.On("UpdateCampaign", "campaign123", UpdateCampaignRequest{Condition: &condition}, "").Return(nil, compileErr)
+
+	w := performRequest(router, "PATCH", "/campaigns/campaign123", UpdateCampaignRequest{Condition: &condition})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected status code 400")
+	var response ConditionErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	assert.Equal(t, 1, response.Line)
+	assert.Equal(t, 9, response.Column)
+}
+
+func TestHandler_ListCampaigns_ShowsCounters(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.GET("/campaigns", handler.ListCampaigns)
+
+	mockService.On("ListCampaigns").Return([]model.Campaign{{Id: "campaign123", MaxUsers: 100, MaxIssued: 300, Issued: 250, Redeemed: 40}}, nil)
+
+	w := performRequest(router, "GET", "/campaigns", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status code 200")
+	var response []map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	assert.EqualValues(t, 250, response[0]["issued"])
+	assert.EqualValues(t, 40, response[0]["redeemed"])
+	assert.EqualValues(t, 60, response[0]["remaining"])
+}
+
+func TestHandler_CloneCampaign_Success(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/:id/clone", handler.CloneCampaign)
+
+	start := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	mockService.On("CloneCampaign", "campaign123", "November", start, "").Return(&model.Campaign{Id: "campaign456", Name: "November", StartDate: start}, nil)
+
+	w := performRequest(router, "POST", "/campaigns/campaign123/clone", CloneCampaignRequest{Name: "November", StartDate: start.Format(time.RFC3339)})
+
+	assert.Equal(t, http.StatusCreated, w.Code, "Expected status code 201")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_CreateFromTemplate_NotFound(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/templates/:name/campaigns", handler.CreateFromTemplate)
+
+	mockService.On("CreateFromTemplate", "missing", CreateFromTemplateRequest{}, "").Return(nil, ErrTemplateNotFound)
+
+	w := performRequest(router, "POST", "/campaigns/templates/missing/campaigns", UpdateCampaignRequest{})
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "Expected status code 404")
+}
+
+func TestHandler_CreateTemplate_NameTaken(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.POST("/campaigns/templates", handler.CreateTemplate)
+
+	mockService.On("CreateTemplate", mock.AnythingOfType("*model.CampaignTemplate")).Return(ErrTemplateExists)
+
+	w := performRequest(router, "POST", "/campaigns/templates", CreateTemplateRequest{Name: "monthly", Discount: 15})
+
+	assert.Equal(t, http.StatusConflict, w.Code, "Expected status code 409")
+}
+
+func TestHandler_GetVariantResults_NoVariants(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.GET("/campaigns/:id/results", handler.GetVariantResults)
+
+	mockService.On("GetVariantResults", "campaign123").Return(nil, ErrNoVariants)
+
+	w := performRequest(router, "GET", "/campaigns/campaign123/results", nil)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected status code 400")
+}
+
+func TestHandler_GetHistory_Success(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.GET("/campaigns/:id/history", handler.GetHistory)
+
+	mockService.On("GetHistory", "campaign123").Return([]model.AuditEntry{
+		{CampaignID: "campaign123", Actor: "admin123", Action: model.AuditUpdate,
+			Before: map[string]interface{}{"discount": 30.0}, After: map[string]interface{}{"discount": 25.0}},
+	}, nil)
+
+	w := performRequest(router, "GET", "/campaigns/campaign123/history", nil)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status code 200")
+	assert.Contains(t, w.Body.String(), `"before":{"discount":30}`)
+}
+
+func TestHandler_GetHistory_CampaignNotFound(t *testing.T) {
+	mockService := new(MockService)
+	handler := SetupHandler(mockService)
+
+	router := gin.Default()
+	router.GET("/campaigns/:id/history", handler.GetHistory)
+
+	mockService.On("GetHistory", "missing").Return(nil, ErrCampaignNotFound)
+
+	w := performRequest(router, "GET", "/campaigns/missing/history", nil)
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "Expected status code 404")
+}
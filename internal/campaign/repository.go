@@ -2,32 +2,71 @@ package campaign
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"trinity/internal/model"
+	"trinity/internal/voucher"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrCampaignNotFound is returned when no campaign matches the lookup
+var ErrCampaignNotFound = errors.New("campaign not found")
+
 // Repository defines campaign data access methods
 type Repository interface {
 	CreateCampaign(campaign *model.Campaign) (string, error)
 	GetCampaignByID(id string) (*model.Campaign, error)
-	IncrementUsedUsers(id string, count int) error
+	UpdateCampaign(campaign *model.Campaign) error
+	ClaimIssued(id string, count int) error
+	ReleaseIssued(id string, count int) error
+	ClaimRedemption(id string) error
+	ReleaseRedemption(id string) error
+	AddSpend(id string, amount float64) (*model.Campaign, error)
+	RefundSpend(id string, amount float64) error
+	SetStatus(id string, status model.CampaignStatus) error
+	ReactivateCampaign(id string) (bool, error)
 	ListCampaigns() ([]model.Campaign, error)
+	ListAutomaticCampaigns(now time.Time) ([]model.Campaign, error)
+	CreateTemplate(template *model.CampaignTemplate) error
+	GetTemplateByName(name string) (*model.CampaignTemplate, error)
+	ListTemplates() ([]model.CampaignTemplate, error)
+	RecordExposure(campaignID string, variant string, userID string) error
+	CountExposures(campaignID string) (map[string]int64, error)
+	SumPurchasesByVariant(campaignID string) (map[string]VariantSales, error)
+	CreateAuditEntry(entry *model.AuditEntry) error
+	ListAuditEntries(campaignID string) ([]model.AuditEntry, error)
+}
+
+// VariantSales sums up the purchases discounted by one campaign variant
+type VariantSales struct {
+	Purchases int64   `bson:"purchases"`
+	Revenue   float64 `bson:"revenue"`
+	Discount  float64 `bson:"discount"`
 }
 
 // repository implements Repository interface
 type repository struct {
 	collection *mongo.Collection
+	templates  *mongo.Collection
+	exposures  *mongo.Collection
+	purchases  *mongo.Collection
+	history    *mongo.Collection
 }
 
 // NewRepository creates a new Campaign repository
 func NewRepository(db *mongo.Database) Repository {
 	return &repository{
 		collection: db.Collection("campaigns"),
+		templates:  db.Collection("campaign_templates"),
+		exposures:  db.Collection("campaign_exposures"),
+		purchases:  db.Collection("purchases"),
+		history:    db.Collection("campaign_history"),
 	}
 }
 
@@ -51,14 +90,14 @@ func (r *repository) CreateCampaign(campaign *model.Campaign) (string, error) {
 func (r *repository) GetCampaignByID(campaignID string) (*model.Campaign, error) {
 	objID, err := primitive.ObjectIDFromHex(campaignID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid campaign ID format")
+		return nil, ErrCampaignNotFound
 	}
 
 	var campaign model.Campaign
 	err = r.collection.FindOne(context.Background(), bson.M{"_id": objID}).Decode(&campaign)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("no documents in result")
+			return nil, ErrCampaignNotFound
 		}
 		return nil, err
 	}
@@ -66,26 +105,227 @@ func (r *repository) GetCampaignByID(campaignID string) (*model.Campaign, error)
 	return &campaign, nil
 }
 
-// IncrementUsedUsers increments the used_users field of a campaign by a specified count
-func (r *repository) IncrementUsedUsers(id string, count int) error {
+// ClaimIssued counts count vouchers against the campaign's issue limit in
+// a single conditional update, so concurrent generation and imports cannot
+// issue more than the campaign takes. Vouchers are claimed before they are
+// inserted; those that end up not inserted are given back with ReleaseIssued.
+func (r *repository) ClaimIssued(id string, count int) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	// The issue limit is max_issued when set and max_users otherwise
+	limit := bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$max_issued", 0}}, 0}},
+		"$max_issued",
+		"$max_users",
+	}}
+	filter := bson.M{
+		"_id":   objID,
+		"$expr": bson.M{"$lte": bson.A{bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$used_users", 0}}, count}}, limit}},
+	}
+	update := bson.M{"$inc": bson.M{"used_users": count}}
+
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to claim issued vouchers: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotEnoughVouchers
+	}
+
+	return nil
+}
+
+// ReleaseIssued gives back vouchers claimed with ClaimIssued that were not
+// inserted after all
+func (r *repository) ReleaseIssued(id string, count int) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	filter := bson.M{"_id": objID, "used_users": bson.M{"$gte": count}}
+	update := bson.M{"$inc": bson.M{"used_users": -count}}
+
+	if _, err := r.collection.UpdateOne(context.Background(), filter, update); err != nil {
+		return fmt.Errorf("failed to release issued vouchers: %w", err)
+	}
+	return nil
+}
+
+// ClaimRedemption counts one redemption against the campaign's MaxUsers in
+// a single conditional update, so concurrent redemptions cannot overfill it
+func (r *repository) ClaimRedemption(id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	filter := bson.M{
+		"_id":   objID,
+		"$expr": bson.M{"$lt": bson.A{"$redeemed", "$max_users"}},
+	}
+	update := bson.M{"$inc": bson.M{"redeemed": 1}}
+
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to claim campaign redemption: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return voucher.ErrCampaignFull
+	}
+
+	return nil
+}
+
+// ReleaseRedemption gives back a redemption claimed for a voucher that
+// could not be redeemed after all
+func (r *repository) ReleaseRedemption(id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	filter := bson.M{"_id": objID, "redeemed": bson.M{"$gt": 0}}
+	update := bson.M{"$inc": bson.M{"redeemed": -1}}
+
+	if _, err := r.collection.UpdateOne(context.Background(), filter, update); err != nil {
+		return fmt.Errorf("failed to release campaign redemption: %w", err)
+	}
+	return nil
+}
+
+// AddSpend adds a discount to the campaign's spend and returns the updated
+// campaign. The budget is checked in the same conditional update, so
+// concurrent purchases cannot overspend it; campaigns without a budget only
+// keep count.
+func (r *repository) AddSpend(id string, amount float64) (*model.Campaign, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	filter := bson.M{
+		"_id":    objID,
+		"status": bson.M{"$ne": model.CampaignExhausted},
+		"$or": bson.A{
+			bson.M{"budget": bson.M{"$in": bson.A{nil, 0}}},
+			bson.M{"$expr": bson.M{"$lte": bson.A{bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$spent", 0}}, amount}}, "$budget"}}},
+		},
+	}
+	update := bson.M{"$inc": bson.M{"spent": amount}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var campaign model.Campaign
+	err = r.collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&campaign)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrBudgetExhausted
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to add campaign spend: %w", err)
+	}
+	return &campaign, nil
+}
+
+// RefundSpend takes back a discount added by AddSpend for a purchase that
+// did not go through
+func (r *repository) RefundSpend(id string, amount float64) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("invalid campaign ID: %w", err)
 	}
 
+	update := bson.M{"$inc": bson.M{"spent": -amount}}
+	if _, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": objID}, update); err != nil {
+		return fmt.Errorf("failed to refund campaign spend: %w", err)
+	}
+	return nil
+}
+
+// SetStatus changes the status of a campaign
+func (r *repository) SetStatus(id string, status model.CampaignStatus) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	result, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": objID}, bson.M{"$set": bson.M{"status": status}})
+	if err != nil {
+		return fmt.Errorf("failed to set campaign status: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no campaign found with ID %s", id)
+	}
+
+	return nil
+}
+
+// ReactivateCampaign brings an exhausted campaign back to active if its
+// stored budget is unlimited or above its stored spend, and reports whether
+// it did. Checking the stored values keeps a purchase exhausting the
+// campaign at the same time from being undone.
+func (r *repository) ReactivateCampaign(id string) (bool, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
+	filter := bson.M{
+		"_id":    objID,
+		"status": model.CampaignExhausted,
+		"$or": bson.A{
+			bson.M{"budget": bson.M{"$in": bson.A{nil, 0}}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$spent", 0}}, "$budget"}}},
+		},
+	}
+	result, err := r.collection.UpdateOne(context.Background(), filter, bson.M{"$set": bson.M{"status": model.CampaignActive}})
+	if err != nil {
+		return false, fmt.Errorf("failed to reactivate campaign: %w", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// UpdateCampaign saves the editable settings of a campaign. Counters such
+// as used_users, redeemed and spent are left alone so concurrent generation,
+// redemptions and purchases are not undone, and so is the status, which
+// purchases exhaust through SetStatus.
+func (r *repository) UpdateCampaign(campaign *model.Campaign) error {
+	objID, err := primitive.ObjectIDFromHex(campaign.Id)
+	if err != nil {
+		return fmt.Errorf("invalid campaign ID: %w", err)
+	}
+
 	update := bson.M{
-		"$inc": bson.M{
-			"used_users": count,
+		"$set": bson.M{
+			"name":        campaign.Name,
+			"discount":    campaign.Discount,
+			"max_users":   campaign.MaxUsers,
+			"max_issued":  campaign.MaxIssued,
+			"budget":      campaign.Budget,
+			"tiers":       campaign.Tiers,
+			"start_date":  campaign.StartDate,
+			"end_date":    campaign.EndDate,
+			"description": campaign.Description,
+			"validity":    campaign.Validity,
+			"eligibility": campaign.Eligibility,
+			"condition":   campaign.Condition,
+			"stacking":    campaign.Stacking,
+			"variants":    campaign.Variants,
 		},
 	}
 
 	result, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": objID}, update)
 	if err != nil {
-		return fmt.Errorf("failed to increment used users: %w", err)
+		return fmt.Errorf("failed to update campaign: %w", err)
 	}
 
 	if result.MatchedCount == 0 {
-		return fmt.Errorf("no campaign found with ID %s", id)
+		return fmt.Errorf("no campaign found with ID %s", campaign.Id)
 	}
 
 	return nil
@@ -114,3 +354,188 @@ func (r *repository) ListCampaigns() ([]model.Campaign, error) {
 
 	return campaigns, nil
 }
+
+// ListAutomaticCampaigns retrieves the automatic campaigns running at the
+// given time that still have places left
+func (r *repository) ListAutomaticCampaigns(now time.Time) ([]model.Campaign, error) {
+	filter := bson.M{
+		"automatic":  true,
+		"status":     bson.M{"$ne": model.CampaignExhausted},
+		"start_date": bson.M{"$lte": now},
+		"end_date":   bson.M{"$gte": now},
+		"$expr":      bson.M{"$lt": bson.A{"$redeemed", "$max_users"}},
+	}
+
+	cursor, err := r.collection.Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var campaigns []model.Campaign
+	if err := cursor.All(context.Background(), &campaigns); err != nil {
+		return nil, err
+	}
+	return campaigns, nil
+}
+
+// CreateTemplate inserts a new campaign template; names are unique
+func (r *repository) CreateTemplate(template *model.CampaignTemplate) error {
+	result, err := r.templates.InsertOne(context.Background(), template)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrTemplateExists
+		}
+		return err
+	}
+
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return fmt.Errorf("failed to convert InsertedID to ObjectID")
+	}
+
+	template.Id = oid.Hex()
+	return nil
+}
+
+// GetTemplateByName retrieves a campaign template by its name
+func (r *repository) GetTemplateByName(name string) (*model.CampaignTemplate, error) {
+	var template model.CampaignTemplate
+	err := r.templates.FindOne(context.Background(), bson.M{"name": name}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListTemplates retrieves all campaign templates by name
+func (r *repository) ListTemplates() ([]model.CampaignTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.templates.Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	templates := []model.CampaignTemplate{}
+	if err := cursor.All(context.Background(), &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// RecordExposure records the variant a user was bucketed into. Only the
+// first exposure of a user to a campaign is kept.
+func (r *repository) RecordExposure(campaignID string, variant string, userID string) error {
+	filter := bson.M{"campaign_id": campaignID, "user_id": userID}
+	update := bson.M{"$setOnInsert": bson.M{"variant": variant, "exposed_at": time.Now()}}
+	opts := options.Update().SetUpsert(true)
+
+	if _, err := r.exposures.UpdateOne(context.Background(), filter, update, opts); err != nil {
+		// A concurrent first exposure of the same user already won
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to record exposure: %w", err)
+	}
+	return nil
+}
+
+// CountExposures counts the users exposed to each variant of a campaign
+func (r *repository) CountExposures(campaignID string) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"campaign_id": campaignID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$variant", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.exposures.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var rows []struct {
+		Variant string `bson:"_id"`
+		Count   int64  `bson:"count"`
+	}
+	if err := cursor.All(context.Background(), &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Variant] = row.Count
+	}
+	return counts, nil
+}
+
+// SumPurchasesByVariant sums up the purchases each variant of a campaign
+// discounted, by the total paid and the campaign's discount line
+func (r *repository) SumPurchasesByVariant(campaignID string) (map[string]VariantSales, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"discounts.campaign_id": campaignID}}},
+		{{Key: "$unwind", Value: "$discounts"}},
+		{{Key: "$match", Value: bson.M{"discounts.campaign_id": campaignID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$discounts.variant",
+			"purchases": bson.M{"$sum": 1},
+			"revenue":   bson.M{"$sum": "$total"},
+			"discount":  bson.M{"$sum": "$discounts.amount"},
+		}}},
+	}
+
+	cursor, err := r.purchases.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var rows []struct {
+		Variant      string `bson:"_id"`
+		VariantSales `bson:",inline"`
+	}
+	if err := cursor.All(context.Background(), &rows); err != nil {
+		return nil, err
+	}
+
+	sales := make(map[string]VariantSales, len(rows))
+	for _, row := range rows {
+		sales[row.Variant] = row.VariantSales
+	}
+	return sales, nil
+}
+
+// CreateAuditEntry appends an entry to a campaign's history
+func (r *repository) CreateAuditEntry(entry *model.AuditEntry) error {
+	result, err := r.history.InsertOne(context.Background(), entry)
+	if err != nil {
+		return err
+	}
+
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return fmt.Errorf("failed to convert InsertedID to ObjectID")
+	}
+
+	entry.Id = oid.Hex()
+	return nil
+}
+
+// ListAuditEntries retrieves a campaign's history, newest first
+func (r *repository) ListAuditEntries(campaignID string) ([]model.AuditEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}})
+	cursor, err := r.history.Find(context.Background(), bson.M{"campaign_id": campaignID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	entries := []model.AuditEntry{}
+	if err := cursor.All(context.Background(), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -1,12 +1,13 @@
 package campaign
 
 import (
+	"time"
 	"trinity/internal/model"
 
 	"github.com/stretchr/testify/mock"
 )
 
-// MockRepository is a mock implementation of the Repository interface// MockRepository is a mock implementation of the Repository interface
+// MockRepository is a mock implementation of the Repository interface
 type MockRepository struct {
 	mock.Mock
 }
@@ -24,11 +25,63 @@ func (m *MockRepository) GetCampaignByID(id string) (*model.Campaign, error) {
 	return nil, args.Error(1)
 }
 
-func (m *MockRepository) IncrementUsedUsers(id string) error {
+func (m *MockRepository) UpdateCampaign(campaign *model.Campaign) error {
+	args := m.Called(campaign)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimIssued(id string, count int) error {
+	args := m.Called(id, count)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReleaseIssued(id string, count int) error {
+	args := m.Called(id, count)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimRedemption(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReleaseRedemption(id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockRepository) AddSpend(id string, amount float64) (*model.Campaign, error) {
+	args := m.Called(id, amount)
+	campaign := args.Get(0)
+	if campaign == nil {
+		return nil, args.Error(1)
+	}
+	return campaign.(*model.Campaign), args.Error(1)
+}
+
+func (m *MockRepository) RefundSpend(id string, amount float64) error {
+	args := m.Called(id, amount)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetStatus(id string, status model.CampaignStatus) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReactivateCampaign(id string) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ListAutomaticCampaigns(now time.Time) ([]model.Campaign, error) {
+	args := m.Called(now)
+	if campaigns, ok := args.Get(0).([]model.Campaign); ok {
+		return campaigns, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockRepository) ListCampaigns() ([]model.Campaign, error) {
 	args := m.Called()
 	if campaigns, ok := args.Get(0).([]model.Campaign); ok {
@@ -36,3 +89,59 @@ func (m *MockRepository) ListCampaigns() ([]model.Campaign, error) {
 	}
 	return nil, args.Error(1)
 }
+
+func (m *MockRepository) CreateTemplate(template *model.CampaignTemplate) error {
+	args := m.Called(template)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetTemplateByName(name string) (*model.CampaignTemplate, error) {
+	args := m.Called(name)
+	template := args.Get(0)
+	if template == nil {
+		return nil, args.Error(1)
+	}
+	return template.(*model.CampaignTemplate), args.Error(1)
+}
+
+func (m *MockRepository) ListTemplates() ([]model.CampaignTemplate, error) {
+	args := m.Called()
+	if templates, ok := args.Get(0).([]model.CampaignTemplate); ok {
+		return templates, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RecordExposure(campaignID string, variant string, userID string) error {
+	args := m.Called(campaignID, variant, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CountExposures(campaignID string) (map[string]int64, error) {
+	args := m.Called(campaignID)
+	if counts, ok := args.Get(0).(map[string]int64); ok {
+		return counts, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) SumPurchasesByVariant(campaignID string) (map[string]VariantSales, error) {
+	args := m.Called(campaignID)
+	if sales, ok := args.Get(0).(map[string]VariantSales); ok {
+		return sales, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) CreateAuditEntry(entry *model.AuditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListAuditEntries(campaignID string) ([]model.AuditEntry, error) {
+	args := m.Called(campaignID)
+	if entries, ok := args.Get(0).([]model.AuditEntry); ok {
+		return entries, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
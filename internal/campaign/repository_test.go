@@ -50,7 +50,7 @@ func TestRepository_CreateCampaign(t *testing.T) {
 		Name:        "Integration Test Campaign",
 		Discount:    15,
 		MaxUsers:    50,
-		UsedUsers:   0,
+		Issued:      0,
 		StartDate:   time.Now(),
 		EndDate:     time.Now().Add(72 * time.Hour),
 		Description: "A campaign for integration testing",
@@ -85,7 +85,7 @@ func TestRepository_GetCampaignByID(t *testing.T) {
 		Name:        "Retrieve Test Campaign",
 		Discount:    20,
 		MaxUsers:    100,
-		UsedUsers:   10,
+		Issued:      10,
 		StartDate:   time.Now(),
 		EndDate:     time.Now().Add(48 * time.Hour),
 		Description: "A campaign for retrieval testing",
@@ -103,10 +103,10 @@ func TestRepository_GetCampaignByID(t *testing.T) {
 
 	// Test retrieving a non-existent campaign
 	_, err = repo.GetCampaignByID("nonexistentid123")
-	assert.Error(t, err, "GetCampaignByID should return an error for invalid ID")
+	assert.ErrorIs(t, err, ErrCampaignNotFound, "GetCampaignByID should report an invalid ID as not found")
 }
 
-// func TestRepository_IncrementUsedUsers(t *testing.T) {
+// func TestRepository_IncrementIssued(t *testing.T) {
 // 	db := getTestDB(t)
 // 	repoInterface := NewRepository(db)
 
@@ -119,7 +119,7 @@ func TestRepository_GetCampaignByID(t *testing.T) {
 // 		Name:        "Increment Test Campaign",
 // 		Discount:    10,
 // 		MaxUsers:    100,
-// 		UsedUsers:   25,
+// 		Issued:   25,
 // 		StartDate:   time.Now(),
 // 		EndDate:     time.Now().Add(24 * time.Hour),
 // 		Description: "A campaign for increment testing",
@@ -129,19 +129,47 @@ func TestRepository_GetCampaignByID(t *testing.T) {
 // 	assert.NoError(t, err, "CreateCampaign should not return an error")
 
 // 	// Increment used_users
-// 	err = repo.IncrementUsedUsers(id)
-// 	assert.NoError(t, err, "IncrementUsedUsers should not return an error")
+// 	err = repo.IncrementIssued(id)
+// 	assert.NoError(t, err, "IncrementIssued should not return an error")
 
 // 	// Verify that used_users has been incremented
 // 	updatedCampaign, err := repo.GetCampaignByID(id)
 // 	assert.NoError(t, err, "GetCampaignByID should not return an error")
-// 	assert.Equal(t, campaign.UsedUsers+1, updatedCampaign.UsedUsers, "UsedUsers should be incremented by 1")
+// 	assert.Equal(t, campaign.Issued+1, updatedCampaign.Issued, "Issued should be incremented by 1")
 
 // 	// Test incrementing a non-existent campaign
-// 	err = repo.IncrementUsedUsers("nonexistentid123")
-// 	assert.Error(t, err, "IncrementUsedUsers should return an error for invalid ID")
+// 	err = repo.IncrementIssued("nonexistentid123")
+// 	assert.Error(t, err, "IncrementIssued should return an error for invalid ID")
 // }
 
+func TestRepository_ClaimIssued(t *testing.T) {
+	db := getTestDB(t)
+	repo := NewRepository(db)
+
+	id, err := repo.CreateCampaign(&model.Campaign{
+		Name:      "Claim Test Campaign",
+		Discount:  10,
+		MaxUsers:  100,
+		Issued:    90,
+		StartDate: time.Now(),
+		EndDate:   time.Now().Add(24 * time.Hour),
+	})
+	assert.NoError(t, err, "CreateCampaign should not return an error")
+
+	err = repo.ClaimIssued(id, 11)
+	assert.ErrorIs(t, err, ErrNotEnoughVouchers, "Claims past the issue limit should be rejected")
+
+	err = repo.ClaimIssued(id, 10)
+	assert.NoError(t, err, "Claims up to the issue limit should succeed")
+
+	err = repo.ReleaseIssued(id, 4)
+	assert.NoError(t, err, "ReleaseIssued should not return an error")
+
+	updated, err := repo.GetCampaignByID(id)
+	assert.NoError(t, err, "GetCampaignByID should not return an error")
+	assert.Equal(t, 96, updated.Issued, "Expected the claim minus the release to be counted")
+}
+
 func TestRepository_ListCampaigns(t *testing.T) {
 	db := getTestDB(t)
 	repoInterface := NewRepository(db)
@@ -156,7 +184,7 @@ func TestRepository_ListCampaigns(t *testing.T) {
 			Name:        "List Test Campaign 1",
 			Discount:    5,
 			MaxUsers:    50,
-			UsedUsers:   10,
+			Issued:      10,
 			StartDate:   time.Now(),
 			EndDate:     time.Now().Add(24 * time.Hour),
 			Description: "First campaign for listing",
@@ -165,7 +193,7 @@ func TestRepository_ListCampaigns(t *testing.T) {
 			Name:        "List Test Campaign 2",
 			Discount:    10,
 			MaxUsers:    100,
-			UsedUsers:   20,
+			Issued:      20,
 			StartDate:   time.Now(),
 			EndDate:     time.Now().Add(48 * time.Hour),
 			Description: "Second campaign for listing",
@@ -190,10 +218,75 @@ func TestRepository_ListCampaigns(t *testing.T) {
 				found = true
 				assert.Equal(t, inserted.Discount, retrieved.Discount, "Discount should match")
 				assert.Equal(t, inserted.MaxUsers, retrieved.MaxUsers, "MaxUsers should match")
-				assert.Equal(t, inserted.UsedUsers, retrieved.UsedUsers, "UsedUsers should match")
+				assert.Equal(t, inserted.Issued, retrieved.Issued, "Issued should match")
 				break
 			}
 		}
 		assert.True(t, found, "Inserted campaign should be found in retrieved campaigns")
 	}
 }
+
+func TestRepository_UpdateCampaign_SavesVariants(t *testing.T) {
+	db := getTestDB(t)
+	repo := NewRepository(db)
+
+	campaign := &model.Campaign{
+		Name:      "AB Test Campaign",
+		Discount:  20,
+		MaxUsers:  50,
+		StartDate: time.Now(),
+		EndDate:   time.Now().Add(72 * time.Hour),
+	}
+	_, err := repo.CreateCampaign(campaign)
+	assert.NoError(t, err, "CreateCampaign should not return an error")
+
+	campaign.Variants = []model.CampaignVariant{
+		{Name: "control", Weight: 1, Discount: 15},
+		{Name: "treatment", Weight: 1, Discount: 25},
+	}
+	err = repo.UpdateCampaign(campaign)
+	assert.NoError(t, err, "UpdateCampaign should not return an error")
+
+	updated, err := repo.GetCampaignByID(campaign.Id)
+	assert.NoError(t, err, "GetCampaignByID should not return an error")
+	assert.Equal(t, campaign.Variants, updated.Variants, "Variants should be saved")
+}
+
+func TestRepository_UpdateCampaign_KeepsStatus(t *testing.T) {
+	db := getTestDB(t)
+	repo := NewRepository(db)
+
+	campaign := &model.Campaign{
+		Name:      "Budget Campaign",
+		Discount:  20,
+		MaxUsers:  50,
+		Budget:    1000,
+		StartDate: time.Now(),
+		EndDate:   time.Now().Add(72 * time.Hour),
+	}
+	_, err := repo.CreateCampaign(campaign)
+	assert.NoError(t, err, "CreateCampaign should not return an error")
+
+	// A purchase uses up the budget while an update holds the older copy
+	_, err = repo.AddSpend(campaign.Id, 1000)
+	assert.NoError(t, err, "AddSpend should not return an error")
+	assert.NoError(t, repo.SetStatus(campaign.Id, model.CampaignExhausted))
+
+	campaign.Name = "Renamed"
+	err = repo.UpdateCampaign(campaign)
+	assert.NoError(t, err, "UpdateCampaign should not return an error")
+	stored, _ := repo.GetCampaignByID(campaign.Id)
+	assert.Equal(t, model.CampaignExhausted, stored.Status, "An update should not undo the exhaustion")
+
+	reactivated, err := repo.ReactivateCampaign(campaign.Id)
+	assert.NoError(t, err, "ReactivateCampaign should not return an error")
+	assert.False(t, reactivated, "A campaign whose budget is spent should stay exhausted")
+
+	campaign.Budget = 2000
+	assert.NoError(t, repo.UpdateCampaign(campaign))
+	reactivated, err = repo.ReactivateCampaign(campaign.Id)
+	assert.NoError(t, err, "ReactivateCampaign should not return an error")
+	assert.True(t, reactivated, "A raised budget should bring the campaign back")
+	stored, _ = repo.GetCampaignByID(campaign.Id)
+	assert.Equal(t, model.CampaignActive, stored.Status)
+}
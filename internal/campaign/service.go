@@ -1,44 +1,121 @@
 package campaign
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
+	"time"
+	"trinity/internal/eligibility"
+	"trinity/internal/job"
 	"trinity/internal/model"
+	"trinity/internal/pricing"
 	"trinity/internal/voucher"
 	"trinity/pkg/logger"
 )
 
+const (
+	// voucherBatchSize is the number of vouchers inserted per database round trip
+	voucherBatchSize = 1000
+	// maxCollisionRetries bounds how often colliding codes are regenerated
+	maxCollisionRetries = 5
+	// jobLease is how long a worker holds a voucher job without recording
+	// progress before another worker may take it over
+	jobLease = 2 * time.Minute
+)
+
+var (
+	// ErrNotEnoughVouchers is returned when a campaign cannot take the requested number of vouchers
+	ErrNotEnoughVouchers = errors.New("not enough vouchers remaining")
+	// ErrCodeTaken is returned when a chosen voucher code already exists
+	ErrCodeTaken = errors.New("voucher code already exists")
+	// ErrInvalidAssignment is returned when personal vouchers do not name one user each
+	ErrInvalidAssignment = errors.New("invalid voucher assignment")
+	// ErrBudgetExhausted is returned when a campaign's budget cannot cover a discount
+	ErrBudgetExhausted = errors.New("campaign budget exhausted")
+)
+
+// ErrInvalidCampaign is returned for campaign settings that contradict each other
+var ErrInvalidCampaign = errors.New("invalid campaign")
+
+// ErrInvalidValidity is returned for unusable relative voucher validity settings
+var ErrInvalidValidity = errors.New("invalid voucher validity")
+
+// ErrInvalidImportFile is returned when an uploaded code list is not valid CSV
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+var (
+	// ErrTemplateNotFound is returned when no campaign template has the name
+	ErrTemplateNotFound = errors.New("campaign template not found")
+	// ErrTemplateExists is returned when a campaign template name is taken
+	ErrTemplateExists = errors.New("campaign template already exists")
+)
+
+// ErrNoVariants is returned for A/B test results of a campaign without variants
+var ErrNoVariants = errors.New("campaign has no variants")
+
+// ErrTooManyCollisions is returned when generated codes keep colliding with
+// existing ones, which means the campaign's code space is nearly exhausted
+var ErrTooManyCollisions = errors.New("too many voucher code collisions")
+
 // Service defines campaign business logic methods
 type Service interface {
-	CreateCampaign(campaign *model.Campaign) (string, error)
-	GenerateVouchers(campaignID string, count int) ([]model.Voucher, error)
+	CreateCampaign(campaign *model.Campaign, actor string) (string, error)
+	UpdateCampaign(campaignID string, req UpdateCampaignRequest, actor string) (*model.Campaign, error)
+	GenerateVouchers(campaignID string, count int, userIDs []string, actor string) ([]model.Voucher, error)
+	StartVoucherJob(campaignID string, count int, userIDs []string, createdBy string) (*model.Job, error)
+	ResumeVoucherJobs() error
+	ExportVouchers(campaignID string, format voucher.ExportFormat, w io.Writer) error
+	ImportVouchers(campaignID string, r io.Reader, actor string) (*ImportVouchersResponse, error)
+	CreateSharedVoucher(campaignID string, code string, maxRedemptions int, perUserLimit int, actor string) (*model.Voucher, error)
+	RevokeVouchers(campaignID string, filter voucher.RevokeFilter, reason string, actor string) (int64, error)
 	ListCampaigns() ([]model.Campaign, error)
+	CloneCampaign(campaignID string, name string, start time.Time, actor string) (*model.Campaign, error)
+	CreateTemplate(template *model.CampaignTemplate) error
+	ListTemplates() ([]model.CampaignTemplate, error)
+	CreateFromTemplate(name string, req CreateFromTemplateRequest, actor string) (*model.Campaign, error)
+	GetVariantResults(campaignID string) ([]VariantResult, error)
+	GetHistory(campaignID string) ([]model.AuditEntry, error)
 }
 
 // service implements Service interface
 type service struct {
 	repo        Repository
 	voucherRepo voucher.Repository
-	logger      logger.Logger
+	jobRepo     job.Repository
+	// owner identifies this process when it claims background jobs
+	owner  string
+	logger logger.Logger
 }
 
 // NewService creates a new Campaign service
-func NewService(repo Repository, voucherRepo voucher.Repository) Service {
+func NewService(repo Repository, voucherRepo voucher.Repository, jobRepo job.Repository) Service {
 	return &service{
 		repo:        repo,
 		voucherRepo: voucherRepo,
+		jobRepo:     jobRepo,
+		owner:       workerID(),
 		logger:      logger.NewLogger("campaignService"),
 	}
 }
 
-// CreateCampaign creates a new campaign
-func (s *service) CreateCampaign(campaign *model.Campaign) (string, error) {
-	// Validate campaign dates
-	if campaign.StartDate.After(campaign.EndDate) {
-		return "", errors.New("start date must be before end date")
+// CreateCampaign creates a new campaign and records it in the campaign's
+// history under the actor
+func (s *service) CreateCampaign(campaign *model.Campaign, actor string) (string, error) {
+	if err := validateSettings(campaign); err != nil {
+		return "", err
+	}
+
+	// Validate the voucher code format against the campaign size
+	if campaign.CodeFormat != nil {
+		if err := voucher.ValidateFormat(*campaign.CodeFormat); err != nil {
+			return "", err
+		}
+		if err := voucher.CheckCapacity(*campaign.CodeFormat, campaign.IssueLimit()); err != nil {
+			return "", err
+		}
 	}
 
 	// Create campaign
@@ -48,61 +125,884 @@ func (s *service) CreateCampaign(campaign *model.Campaign) (string, error) {
 		return "", err
 	}
 
+	_, after := diff(nil, campaign)
+	s.record(model.AuditEntry{CampaignID: id, Actor: actor, Action: model.AuditCreate, After: after})
 	return id, nil
 }
 
-// GenerateVouchers generates vouchers for a campaign
-func (s *service) GenerateVouchers(campaignID string, count int) ([]model.Voucher, error) {
+// UpdateCampaign changes the settings given in the request and leaves the
+// others as they are. The code format cannot change once codes exist, and
+// the caps cannot drop below the vouchers already issued or redeemed or the
+// discount already spent.
+func (s *service) UpdateCampaign(campaignID string, req UpdateCampaignRequest, actor string) (*model.Campaign, error) {
 	campaign, err := s.repo.GetCampaignByID(campaignID)
 	if err != nil {
 		s.logger.Errorf("Failed to get campaign: %v", err)
 		return nil, err
 	}
+	previous := *campaign
 
-	remainingVouchers := campaign.MaxUsers - campaign.UsedUsers
-	if count > remainingVouchers {
-		return nil, fmt.Errorf("not enough vouchers remaining: requested %d, available %d", count, remainingVouchers)
+	if err := req.apply(campaign); err != nil {
+		return nil, err
+	}
+	if err := validateSettings(campaign); err != nil {
+		return nil, err
+	}
+	if campaign.IssueLimit() < campaign.Issued {
+		return nil, fmt.Errorf("%w: the issue limit cannot be below the %d vouchers already issued", ErrInvalidCampaign, campaign.Issued)
 	}
+	if campaign.MaxUsers < campaign.Redeemed {
+		return nil, fmt.Errorf("%w: max users cannot be below the %d redemptions so far", ErrInvalidCampaign, campaign.Redeemed)
+	}
+	if campaign.Budget > 0 && campaign.Budget < campaign.Spent {
+		return nil, fmt.Errorf("%w: budget cannot be below the %.2f already spent", ErrInvalidCampaign, campaign.Spent)
+	}
+
+	if err := s.repo.UpdateCampaign(campaign); err != nil {
+		s.logger.Errorf("Failed to update campaign: %v", err)
+		return nil, err
+	}
+
+	if before, after := diff(&previous, campaign); len(after) > 0 {
+		s.record(model.AuditEntry{CampaignID: campaignID, Actor: actor, Action: model.AuditUpdate, Before: before, After: after})
+	}
+
+	// A raised or removed budget brings an exhausted campaign back. The
+	// stored status is checked rather than the one read above, since a
+	// purchase may have exhausted the campaign in the meantime.
+	if req.Budget != nil {
+		reactivated, err := s.repo.ReactivateCampaign(campaignID)
+		if err != nil {
+			s.logger.Errorf("Failed to reactivate campaign: %v", err)
+			return nil, err
+		}
+		if reactivated {
+			campaign.Status = model.CampaignActive
+			s.record(model.AuditEntry{
+				CampaignID: campaignID,
+				Actor:      actor,
+				Action:     model.AuditStatusChange,
+				Before:     map[string]interface{}{"status": model.CampaignExhausted},
+				After:      map[string]interface{}{"status": model.CampaignActive},
+				Note:       "budget raised",
+			})
+		}
+	}
+	return campaign, nil
+}
 
-	var generatedVouchers []model.Voucher
+// CloneCampaign creates a campaign with the settings of another, renamed if
+// a name is given and moved to start at start for the same length of time
+func (s *service) CloneCampaign(campaignID string, name string, start time.Time, actor string) (*model.Campaign, error) {
+	source, err := s.repo.GetCampaignByID(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to get campaign: %v", err)
+		return nil, err
+	}
+
+	clone := source.Clone(start)
+	if name != "" {
+		clone.Name = name
+	}
+	if clone.Id, err = s.CreateCampaign(clone, actor); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
 
-	for i := 0; i < count; i++ {
-		code := s.generateVoucherCode()
-		if code == "" {
+// CreateTemplate saves campaign settings under a name. The settings are
+// validated as they would be for a campaign.
+func (s *service) CreateTemplate(template *model.CampaignTemplate) error {
+	if err := validateSettings(template.NewCampaign()); err != nil {
+		return err
+	}
+	if template.CodeFormat != nil {
+		if err := voucher.ValidateFormat(*template.CodeFormat); err != nil {
+			return err
+		}
+	}
+
+	template.CreatedAt = time.Now()
+	if err := s.repo.CreateTemplate(template); err != nil {
+		if !errors.Is(err, ErrTemplateExists) {
+			s.logger.Errorf("Failed to create template: %v", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// ListTemplates retrieves all campaign templates
+func (s *service) ListTemplates() ([]model.CampaignTemplate, error) {
+	templates, err := s.repo.ListTemplates()
+	if err != nil {
+		s.logger.Errorf("Failed to list templates: %v", err)
+		return nil, err
+	}
+	return templates, nil
+}
+
+// CreateFromTemplate creates a campaign from a template, replacing only the
+// settings given in the request. Templates have no dates, so the request
+// must give both.
+func (s *service) CreateFromTemplate(name string, req CreateFromTemplateRequest, actor string) (*model.Campaign, error) {
+	template, err := s.repo.GetTemplateByName(name)
+	if err != nil {
+		if !errors.Is(err, ErrTemplateNotFound) {
+			s.logger.Errorf("Failed to get template %s: %v", name, err)
+		}
+		return nil, err
+	}
+
+	if req.StartDate == nil || req.EndDate == nil {
+		return nil, fmt.Errorf("%w: start and end dates are required", ErrInvalidCampaign)
+	}
+	campaign := template.NewCampaign()
+	if err := req.apply(campaign); err != nil {
+		return nil, err
+	}
+	if campaign.MaxUsers <= 0 {
+		return nil, fmt.Errorf("%w: max users is required", ErrInvalidCampaign)
+	}
+
+	if campaign.Id, err = s.CreateCampaign(campaign, actor); err != nil {
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// validateSettings checks the campaign settings shared by create and update
+func validateSettings(campaign *model.Campaign) error {
+	if campaign.StartDate.After(campaign.EndDate) {
+		return fmt.Errorf("%w: start date must be before end date", ErrInvalidCampaign)
+	}
+
+	if campaign.Discount < 0 || campaign.Discount > 100 {
+		return fmt.Errorf("%w: discount must be between 0 and 100", ErrInvalidCampaign)
+	}
+
+	if campaign.MaxIssued < 0 {
+		return fmt.Errorf("%w: max issued must not be negative", ErrInvalidCampaign)
+	}
+
+	if campaign.Budget < 0 {
+		return fmt.Errorf("%w: budget must not be negative", ErrInvalidCampaign)
+	}
+
+	if campaign.Validity != nil {
+		if err := validateValidity(*campaign.Validity); err != nil {
+			return err
+		}
+	}
+
+	if campaign.Eligibility != nil {
+		if err := eligibility.Validate(*campaign.Eligibility); err != nil {
+			return err
+		}
+	}
+
+	if err := pricing.ValidateTiers(campaign.Tiers); err != nil {
+		return err
+	}
+
+	if err := pricing.ValidateVariants(campaign.Variants); err != nil {
+		return err
+	}
+	if len(campaign.Variants) > 0 && len(campaign.Tiers) > 0 {
+		return fmt.Errorf("%w: variants replace the discount and cannot be combined with tiers", pricing.ErrInvalidVariants)
+	}
+
+	if campaign.Stacking != nil && !campaign.Stacking.Mode.IsValid() {
+		return fmt.Errorf("%w: unknown stacking mode %q", ErrInvalidCampaign, campaign.Stacking.Mode)
+	}
+
+	// Conditions are compiled up front so mistakes surface to the marketer
+	// rather than at checkout
+	if campaign.Condition != "" {
+		if _, err := eligibility.CompileCondition(campaign.Condition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateVouchers generates vouchers for a campaign. Codes are inserted in
+// batches and any that collide with an existing code are regenerated, so the
+// campaign receives exactly count vouchers or an error. When userIDs is
+// given, it holds one user per voucher and each voucher can only be
+// redeemed by its user. The vouchers issued are recorded in the campaign's
+// history under the actor. When generation fails partway, the vouchers
+// created so far are returned with the error.
+func (s *service) GenerateVouchers(campaignID string, count int, userIDs []string, actor string) ([]model.Voucher, error) {
+	if err := checkAssignments(count, userIDs); err != nil {
+		return nil, err
+	}
+
+	campaign, err := s.repo.GetCampaignByID(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to get campaign: %v", err)
+		return nil, err
+	}
+
+	if err := checkRemaining(campaign, count); err != nil {
+		return nil, err
+	}
+
+	generatedVouchers := make([]model.Voucher, 0, count)
+	err = s.generateInBatches(campaignID, "", campaign, count, userIDs, func(batch []model.Voucher) error {
+		generatedVouchers = append(generatedVouchers, batch...)
+		return nil
+	})
+	s.recordIssued(campaignID, actor, campaign.Issued, len(generatedVouchers), fmt.Sprintf("generated %d vouchers", len(generatedVouchers)))
+	if err != nil {
+		return generatedVouchers, err
+	}
+
+	return generatedVouchers, nil
+}
+
+// StartVoucherJob validates the request and generates the vouchers in a
+// background job, returning the job so its progress can be followed
+func (s *service) StartVoucherJob(campaignID string, count int, userIDs []string, createdBy string) (*model.Job, error) {
+	if err := checkAssignments(count, userIDs); err != nil {
+		return nil, err
+	}
+
+	campaign, err := s.repo.GetCampaignByID(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to get campaign: %v", err)
+		return nil, err
+	}
+
+	if err := checkRemaining(campaign, count); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &model.Job{
+		Type:       model.JobGenerateVouchers,
+		Status:     model.JobPending,
+		CampaignID: campaignID,
+		Total:      count,
+		UserIDs:    userIDs,
+		Errors:     []string{},
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.jobRepo.CreateJob(job); err != nil {
+		s.logger.Errorf("Failed to create job: %v", err)
+		return nil, err
+	}
+
+	go s.runVoucherJob(*job)
+
+	return job, nil
+}
+
+// ResumeVoucherJobs restarts voucher generation jobs interrupted by a
+// shutdown. Each job continues from the vouchers it has already inserted,
+// or fails if the campaign can no longer take the remaining ones. Jobs
+// another worker still holds are retried once its lease runs out.
+func (s *service) ResumeVoucherJobs() error {
+	jobs, err := s.jobRepo.ListUnfinishedJobs(model.JobGenerateVouchers)
+	if err != nil {
+		s.logger.Errorf("Failed to list unfinished jobs: %v", err)
+		return err
+	}
+
+	for _, job := range jobs {
+		if job.LeaseUntil != nil && job.LeaseUntil.After(time.Now()) {
+			s.logger.Infof("Job %s is held by %s until %s", job.Id, job.Owner, job.LeaseUntil.Format(time.RFC3339))
+			time.AfterFunc(time.Until(*job.LeaseUntil), func() { s.runVoucherJob(job) })
 			continue
 		}
-		voucher := model.Voucher{
-			Code:       code,
+		s.logger.Infof("Resuming job %s at %d of %d vouchers", job.Id, job.Generated, job.Total)
+		go s.runVoucherJob(job)
+	}
+	return nil
+}
+
+// runVoucherJob claims the job and generates the vouchers it has not
+// produced yet. A job held by another worker is left to it.
+func (s *service) runVoucherJob(job model.Job) {
+	if err := s.jobRepo.ClaimJob(job.Id, s.owner, time.Now().Add(jobLease)); err != nil {
+		s.logger.Warnf("Job %s not claimed: %v", job.Id, err)
+		return
+	}
+
+	status, jobErr := model.JobCompleted, ""
+	if err := s.generateJobVouchers(job); err != nil {
+		s.logger.Errorf("Job %s failed: %v", job.Id, err)
+		status, jobErr = model.JobFailed, err.Error()
+	}
+	if err := s.jobRepo.FinishJob(job.Id, s.owner, status, jobErr); err != nil {
+		s.logger.Errorf("Failed to finish job %s: %v", job.Id, err)
+	}
+}
+
+// generateJobVouchers generates the vouchers missing from a job, recording
+// progress and renewing the lease after every batch. Progress is recounted
+// from the vouchers tagged with the job, so a batch inserted just before a
+// crash is not generated again. The vouchers issued are recorded in the
+// campaign's history under the job's creator.
+func (s *service) generateJobVouchers(job model.Job) error {
+	generated, userIDs, err := s.jobProgress(job)
+	if err != nil {
+		s.logger.Errorf("Failed to count vouchers of job %s: %v", job.Id, err)
+		return err
+	}
+
+	campaign, err := s.repo.GetCampaignByID(job.CampaignID)
+	if err != nil {
+		return err
+	}
+
+	remaining := job.Total - generated
+	if err := checkRemaining(campaign, remaining); err != nil {
+		return err
+	}
+	if err := s.jobRepo.SetProgress(job.Id, s.owner, generated, time.Now().Add(jobLease)); err != nil {
+		return err
+	}
+
+	issued := 0
+	err = s.generateInBatches(job.CampaignID, job.Id, campaign, remaining, userIDs, func(batch []model.Voucher) error {
+		issued += len(batch)
+		return s.jobRepo.SetProgress(job.Id, s.owner, generated+issued, time.Now().Add(jobLease))
+	})
+	s.recordIssued(job.CampaignID, job.CreatedBy, campaign.Issued, issued, fmt.Sprintf("generated %d vouchers in job %s", issued, job.Id))
+	return err
+}
+
+// workerID identifies this process as the owner of the jobs it runs
+func workerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// jobProgress counts the vouchers a job has inserted and returns the users
+// still waiting for theirs
+func (s *service) jobProgress(job model.Job) (int, []string, error) {
+	if len(job.UserIDs) == 0 {
+		generated, err := s.voucherRepo.CountVouchersByJob(job.Id)
+		return generated, nil, err
+	}
+
+	assigned, err := s.voucherRepo.ListAssignedUsersByJob(job.Id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	served := make(map[string]int, len(assigned))
+	for _, userID := range assigned {
+		served[userID]++
+	}
+	waiting := make([]string, 0, len(job.UserIDs))
+	for _, userID := range job.UserIDs {
+		if served[userID] > 0 {
+			served[userID]--
+			continue
+		}
+		waiting = append(waiting, userID)
+	}
+	return len(assigned), waiting, nil
+}
+
+// ExportVouchers streams every voucher of the campaign to w in the format
+func (s *service) ExportVouchers(campaignID string, format voucher.ExportFormat, w io.Writer) error {
+	if _, err := s.repo.GetCampaignByID(campaignID); err != nil {
+		s.logger.Errorf("Failed to get campaign: %v", err)
+		return err
+	}
+
+	writer, err := voucher.NewExportWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := s.voucherRepo.StreamVouchersByCampaign(campaignID, writer.Write); err != nil {
+		s.logger.Errorf("Failed to export vouchers: %v", err)
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// CreateSharedVoucher creates a multi-use code, such as WELCOME2026, that up
+// to maxRedemptions users can redeem, each at most perUserLimit times. The
+// chosen code is stored with a check character appended, so typos are
+// caught as for generated codes, and maxRedemptions vouchers are counted as
+// issued.
+func (s *service) CreateSharedVoucher(campaignID string, code string, maxRedemptions int, perUserLimit int, actor string) (*model.Voucher, error) {
+	if err := voucher.ValidateCodeSyntax(code); err != nil {
+		return nil, err
+	}
+	if perUserLimit <= 0 {
+		perUserLimit = 1
+	}
+
+	campaign, err := s.repo.GetCampaignByID(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to get campaign: %v", err)
+		return nil, err
+	}
+
+	if remaining := campaign.IssueLimit() - campaign.Issued; maxRedemptions > remaining {
+		return nil, fmt.Errorf("%w: requested %d, available %d", ErrNotEnoughVouchers, maxRedemptions, remaining)
+	}
+
+	shared := []model.Voucher{{
+		Code:           voucher.WithCheckCharacter(code),
+		CampaignID:     campaignID,
+		Used:           false,
+		Mode:           model.VoucherMultiUse,
+		MaxRedemptions: maxRedemptions,
+		PerUserLimit:   perUserLimit,
+	}}
+	applyValidity(&shared[0], campaign, time.Now())
+	if err := s.claimIssued(campaignID, maxRedemptions); err != nil {
+		return nil, err
+	}
+	duplicates, err := s.voucherRepo.CreateVouchers(shared)
+	if err != nil {
+		s.logger.Errorf("Failed to create shared voucher: %v", err)
+		s.releaseIssued(campaignID, maxRedemptions)
+		return nil, err
+	}
+	if len(duplicates) > 0 {
+		s.releaseIssued(campaignID, maxRedemptions)
+		return nil, ErrCodeTaken
+	}
+
+	s.recordIssued(campaignID, actor, campaign.Issued, maxRedemptions, fmt.Sprintf("created shared voucher %s", shared[0].Code))
+	return &shared[0], nil
+}
+
+// RevokeVouchers voids the campaign's unused vouchers that match the filter
+// and returns how many were revoked. Used and already revoked vouchers are
+// left untouched. Revocations are recorded in the campaign's history under
+// the actor.
+func (s *service) RevokeVouchers(campaignID string, filter voucher.RevokeFilter, reason string, actor string) (int64, error) {
+	if _, err := s.repo.GetCampaignByID(campaignID); err != nil {
+		s.logger.Errorf("Failed to get campaign: %v", err)
+		return 0, err
+	}
+
+	revoked, err := s.voucherRepo.RevokeVouchers(campaignID, filter, reason, time.Now())
+	if err != nil {
+		s.logger.Errorf("Failed to revoke vouchers: %v", err)
+		return 0, err
+	}
+
+	if revoked > 0 {
+		s.record(model.AuditEntry{
 			CampaignID: campaignID,
-			Used:       false,
-			ExpiryDate: campaign.EndDate,
+			Actor:      actor,
+			Action:     model.AuditRevokeVouchers,
+			Note:       fmt.Sprintf("revoked %d vouchers: %s", revoked, reason),
+		})
+	}
+	return revoked, nil
+}
+
+// ImportVouchers adds externally generated codes from a CSV file whose first
+// column holds the code; a leading "code" header row is skipped. Codes are
+// validated, de-duplicated and inserted in batches until the campaign is
+// full, and every row is reported. Codes are stored with a check character
+// appended, which the report returns for every accepted row. If an insert
+// fails, the report so far is returned with the error. The codes accepted
+// are recorded in the campaign's history under the actor.
+func (s *service) ImportVouchers(campaignID string, r io.Reader, actor string) (*ImportVouchersResponse, error) {
+	campaign, err := s.repo.GetCampaignByID(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to get campaign: %v", err)
+		return nil, err
+	}
+
+	report, pending, err := parseImport(r, campaign.CodeFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := campaign.IssueLimit() - campaign.Issued
+	for len(pending) > 0 && report.Accepted < remaining {
+		size := min(voucherBatchSize, remaining-report.Accepted, len(pending))
+		batch := make([]model.Voucher, size)
+		issuedAt := time.Now()
+		for i, row := range pending[:size] {
+			batch[i] = model.Voucher{
+				Code:       voucher.WithCheckCharacter(report.Rows[row].Code),
+				CampaignID: campaignID,
+				Used:       false,
+			}
+			applyValidity(&batch[i], campaign, issuedAt)
+		}
+
+		// Another request may have taken the capacity since it was read
+		if err := s.claimIssued(campaignID, size); err != nil {
+			if errors.Is(err, ErrNotEnoughVouchers) {
+				break
+			}
+			return s.stopImport(report, pending, campaign, actor, err)
 		}
-		err := s.voucherRepo.CreateVoucher(&voucher)
+		duplicates, err := s.voucherRepo.CreateVouchers(batch)
 		if err != nil {
-			s.logger.Errorf("Failed to create voucher: %v", err)
+			s.logger.Errorf("Failed to import vouchers: %v", err)
+			s.releaseIssued(campaignID, size)
+			return s.stopImport(report, pending, campaign, actor, err)
+		}
+		s.releaseIssued(campaignID, len(duplicates))
+
+		rejected := make(map[int]bool, len(duplicates))
+		for _, i := range duplicates {
+			rejected[i] = true
+			report.setStatus(pending[i], ImportDuplicate, "code already exists")
+		}
+		for i, row := range pending[:size] {
+			if !rejected[i] {
+				report.setStatus(row, ImportAccepted, "")
+				report.Rows[row].VoucherCode = batch[i].Code
+			}
+		}
+
+		pending = pending[size:]
+	}
+
+	for _, row := range pending {
+		report.setStatus(row, ImportOverCapacity, "campaign has no remaining capacity")
+	}
+
+	s.logger.Infof("Imported %d vouchers into campaign %s", report.Accepted, campaignID)
+	s.recordIssued(campaignID, actor, campaign.Issued, report.Accepted, fmt.Sprintf("imported %d vouchers", report.Accepted))
+	return report, nil
+}
+
+// stopImport ends an import that failed part way. The rows not yet
+// inserted are skipped, and the report of what was imported before the
+// failure is returned with the error.
+func (s *service) stopImport(report *ImportVouchersResponse, pending []int, campaign *model.Campaign, actor string, err error) (*ImportVouchersResponse, error) {
+	for _, row := range pending {
+		report.setStatus(row, ImportSkipped, "import stopped by an error")
+	}
+	s.recordIssued(campaign.Id, actor, campaign.Issued, report.Accepted, fmt.Sprintf("imported %d vouchers", report.Accepted))
+	return report, err
+}
+
+// parseImport reads the code list, reporting invalid and repeated rows
+// straight away. Codes must match the campaign's format when it has one.
+// It returns the indexes of the rows still to be inserted.
+func parseImport(r io.Reader, format *model.CodeFormat) (*ImportVouchersResponse, []int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	report := &ImportVouchersResponse{Rows: []ImportRow{}}
+	var pending []int
+	seen := make(map[string]bool)
+
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+
+		code := strings.TrimSpace(record[0])
+		if first && strings.EqualFold(code, "code") {
+			continue
+		}
+		if code == "" && len(record) == 1 {
 			continue
 		}
-		generatedVouchers = append(generatedVouchers, voucher)
+
+		line, _ := reader.FieldPos(0)
+		report.Rows = append(report.Rows, ImportRow{Row: line, Code: code})
+		index := len(report.Rows) - 1
+
+		if err := voucher.ValidateImportedCode(code, format); err != nil {
+			report.setStatus(index, ImportInvalid, err.Error())
+			continue
+		}
+		if seen[code] {
+			report.setStatus(index, ImportDuplicate, "code repeated in file")
+			continue
+		}
+		seen[code] = true
+		pending = append(pending, index)
+	}
+
+	return report, pending, nil
+}
+
+// setStatus records the outcome of a row and counts it
+func (r *ImportVouchersResponse) setStatus(index int, status ImportStatus, reason string) {
+	r.Rows[index].Status = status
+	r.Rows[index].Reason = reason
+	switch status {
+	case ImportAccepted:
+		r.Accepted++
+	case ImportDuplicate:
+		r.Duplicate++
+	case ImportInvalid:
+		r.Invalid++
+	case ImportOverCapacity:
+		r.OverCapacity++
+	case ImportSkipped:
+		r.Skipped++
+	}
+}
+
+// checkAssignments verifies that personal vouchers name one user each
+func checkAssignments(count int, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	if len(userIDs) != count {
+		return fmt.Errorf("%w: %d users for %d vouchers", ErrInvalidAssignment, len(userIDs), count)
+	}
+	for _, id := range userIDs {
+		if id == "" {
+			return fmt.Errorf("%w: empty user ID", ErrInvalidAssignment)
+		}
+	}
+	return nil
+}
+
+// checkRemaining verifies the campaign can take count more vouchers in its
+// code format
+func checkRemaining(campaign *model.Campaign, count int) error {
+	remainingVouchers := campaign.IssueLimit() - campaign.Issued
+	if count > remainingVouchers {
+		return fmt.Errorf("%w: requested %d, available %d", ErrNotEnoughVouchers, count, remainingVouchers)
+	}
+
+	return voucher.CheckCapacity(voucher.FormatOrDefault(campaign.CodeFormat), campaign.Issued+count)
+}
+
+// generateInBatches inserts count vouchers batch by batch, assigning them
+// to userIDs in order if given and tagging them with the generating job, if
+// any. Each batch is claimed against the campaign's issue limit before it is
+// inserted and the part not inserted is given back. The vouchers inserted
+// are passed to onBatch even when their batch fails part way through.
+func (s *service) generateInBatches(campaignID string, jobID string, campaign *model.Campaign, count int, userIDs []string, onBatch func([]model.Voucher) error) error {
+	format := voucher.FormatOrDefault(campaign.CodeFormat)
+
+	for done := 0; done < count; {
+		size := min(voucherBatchSize, count-done)
+		pending, err := newVouchers(campaignID, campaign, format, size)
+		if err != nil {
+			return err
+		}
+		for i := range pending {
+			pending[i].JobID = jobID
+			if len(userIDs) > 0 {
+				pending[i].AssignedUserId = userIDs[done+i]
+			}
+		}
+
+		if err := s.claimIssued(campaignID, size); err != nil {
+			return err
+		}
+		batch, err := s.insertVoucherBatch(pending, format)
+		s.releaseIssued(campaignID, size-len(batch))
+		if len(batch) > 0 {
+			if cbErr := onBatch(batch); cbErr != nil {
+				return cbErr
+			}
+			done += len(batch)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertVoucherBatch inserts the pending vouchers, giving new codes to those
+// that collide with existing ones. It returns the vouchers inserted so far
+// even when it fails.
+func (s *service) insertVoucherBatch(pending []model.Voucher, format model.CodeFormat) ([]model.Voucher, error) {
+	inserted := make([]model.Voucher, 0, len(pending))
+	for attempt := 0; ; attempt++ {
+		duplicates, err := s.voucherRepo.CreateVouchers(pending)
+		if err != nil {
+			s.logger.Errorf("Failed to create vouchers: %v", err)
+			return inserted, err
+		}
+
+		rejected := make(map[int]bool, len(duplicates))
+		for _, i := range duplicates {
+			rejected[i] = true
+		}
+		for i := range pending {
+			if !rejected[i] {
+				inserted = append(inserted, pending[i])
+			}
+		}
+
+		if len(duplicates) == 0 {
+			return inserted, nil
+		}
+		if attempt == maxCollisionRetries {
+			return inserted, ErrTooManyCollisions
+		}
+
+		// Colliding vouchers keep their other fields, such as the assigned user
+		s.logger.Infof("Regenerating %d colliding voucher codes", len(duplicates))
+		retry := make([]model.Voucher, len(duplicates))
+		for j, i := range duplicates {
+			retry[j] = pending[i]
+			if retry[j].Code, err = voucher.GenerateCode(format); err != nil {
+				return inserted, err
+			}
+		}
+		pending = retry
+	}
+}
+
+// claimIssued counts count vouchers against the campaign's issue limit
+// before they are inserted
+func (s *service) claimIssued(campaignID string, count int) error {
+	if err := s.repo.ClaimIssued(campaignID, count); err != nil {
+		if !errors.Is(err, ErrNotEnoughVouchers) {
+			s.logger.Errorf("Failed to count issued vouchers: %v", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// releaseIssued gives back claimed vouchers that were not inserted. A
+// failure is logged; it leaves the campaign able to issue fewer vouchers,
+// never more.
+func (s *service) releaseIssued(campaignID string, count int) {
+	if count <= 0 {
+		return
+	}
+	if err := s.repo.ReleaseIssued(campaignID, count); err != nil {
+		s.logger.Errorf("Failed to release %d issued vouchers of campaign %s: %v", count, campaignID, err)
+	}
+}
+
+// newVouchers builds n unsaved vouchers with freshly generated codes
+func newVouchers(campaignID string, campaign *model.Campaign, format model.CodeFormat, n int) ([]model.Voucher, error) {
+	vouchers := make([]model.Voucher, n)
+	issuedAt := time.Now()
+	for i := range vouchers {
+		code, err := voucher.GenerateCode(format)
+		if err != nil {
+			return nil, err
+		}
+		vouchers[i] = model.Voucher{
+			Code:       code,
+			CampaignID: campaignID,
+			Used:       false,
+		}
+		applyValidity(&vouchers[i], campaign, issuedAt)
+	}
+	return vouchers, nil
+}
+
+// validateValidity checks relative voucher validity settings
+func validateValidity(validity model.VoucherValidity) error {
+	if validity.Days <= 0 {
+		return fmt.Errorf("%w: days must be positive", ErrInvalidValidity)
+	}
+	if validity.From != model.ValidFromIssue && validity.From != model.ValidFromFirstView {
+		return fmt.Errorf("%w: from must be %q or %q", ErrInvalidValidity, model.ValidFromIssue, model.ValidFromFirstView)
+	}
+	return nil
+}
+
+// applyValidity sets the expiry of a voucher issued at the given time.
+// Vouchers expire at the campaign end unless the campaign has a relative
+// validity, which never extends past the end. Vouchers whose validity starts
+// at first view keep the campaign end until then.
+func applyValidity(v *model.Voucher, campaign *model.Campaign, issuedAt time.Time) {
+	v.ExpiryDate = campaign.EndDate
+	if campaign.Validity == nil {
+		return
+	}
+
+	switch campaign.Validity.From {
+	case model.ValidFromIssue:
+		if expiry := issuedAt.AddDate(0, 0, campaign.Validity.Days); expiry.Before(campaign.EndDate) {
+			v.ExpiryDate = expiry
+		}
+	case model.ValidFromFirstView:
+		v.ValidityDays = campaign.Validity.Days
 	}
+}
 
-	// Increment used users
-	err = s.repo.IncrementUsedUsers(campaignID, len(generatedVouchers))
+// GetVariantResults reports how each variant of a campaign's A/B test has
+// performed. Users are counted when checkout first buckets them, so
+// conversion is the share of them who went on to buy with the discount.
+func (s *service) GetVariantResults(campaignID string) ([]VariantResult, error) {
+	campaign, err := s.repo.GetCampaignByID(campaignID)
 	if err != nil {
-		s.logger.Errorf("Failed to increment used users: %v", err)
+		s.logger.Errorf("Failed to get campaign: %v", err)
 		return nil, err
 	}
+	if len(campaign.Variants) == 0 {
+		return nil, ErrNoVariants
+	}
 
-	return generatedVouchers, nil
+	users, err := s.repo.CountExposures(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to count exposures of campaign %s: %v", campaignID, err)
+		return nil, err
+	}
+	redemptions, err := s.voucherRepo.CountRedemptionsByVariant(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to count redemptions of campaign %s: %v", campaignID, err)
+		return nil, err
+	}
+	sales, err := s.repo.SumPurchasesByVariant(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to sum purchases of campaign %s: %v", campaignID, err)
+		return nil, err
+	}
+
+	results := make([]VariantResult, len(campaign.Variants))
+	for i, variant := range campaign.Variants {
+		result := VariantResult{
+			Variant:       variant.Name,
+			Weight:        variant.Weight,
+			Discount:      variant.Discount,
+			Users:         users[variant.Name],
+			Redemptions:   redemptions[variant.Name],
+			Purchases:     sales[variant.Name].Purchases,
+			Revenue:       sales[variant.Name].Revenue,
+			DiscountGiven: sales[variant.Name].Discount,
+		}
+		if result.Users > 0 {
+			result.Conversion = float64(result.Purchases) / float64(result.Users)
+		}
+		results[i] = result
+	}
+	return results, nil
 }
 
-// generateVoucherCode generates a unique voucher code
-func (s *service) generateVoucherCode() string {
-	bytes := make([]byte, 5) // 10 characters when hex-encoded
-	if _, err := rand.Read(bytes); err != nil {
-		s.logger.Errorf("Failed to generate random bytes: %v", err)
-		return ""
+// GetHistory retrieves the audit entries of a campaign, newest first
+func (s *service) GetHistory(campaignID string) ([]model.AuditEntry, error) {
+	if _, err := s.repo.GetCampaignByID(campaignID); err != nil {
+		s.logger.Errorf("Failed to get campaign: %v", err)
+		return nil, err
+	}
+
+	entries, err := s.repo.ListAuditEntries(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to list history of campaign %s: %v", campaignID, err)
+		return nil, err
 	}
-	return strings.ToUpper(hex.EncodeToString(bytes))
+	return entries, nil
 }
 
 // ListCampaigns retrieves all campaigns
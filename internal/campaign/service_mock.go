@@ -1,7 +1,10 @@
 package campaign
 
 import (
+	"io"
+	"time"
 	"trinity/internal/model"
+	"trinity/internal/voucher"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -11,13 +14,13 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) CreateCampaign(campaign *model.Campaign) (string, error) {
-	args := m.Called(campaign)
+func (m *MockService) CreateCampaign(campaign *model.Campaign, actor string) (string, error) {
+	args := m.Called(campaign, actor)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockService) GenerateVouchers(campaignID string, count int) ([]model.Voucher, error) {
-	args := m.Called(campaignID, count)
+func (m *MockService) GenerateVouchers(campaignID string, count int, userIDs []string, actor string) ([]model.Voucher, error) {
+	args := m.Called(campaignID, count, userIDs, actor)
 	return args.Get(0).([]model.Voucher), args.Error(1)
 }
 
@@ -25,3 +28,101 @@ func (m *MockService) ListCampaigns() ([]model.Campaign, error) {
 	args := m.Called()
 	return args.Get(0).([]model.Campaign), args.Error(1)
 }
+
+func (m *MockService) StartVoucherJob(campaignID string, count int, userIDs []string, createdBy string) (*model.Job, error) {
+	args := m.Called(campaignID, count, userIDs, createdBy)
+	job := args.Get(0)
+	if job == nil {
+		return nil, args.Error(1)
+	}
+	return job.(*model.Job), args.Error(1)
+}
+
+func (m *MockService) ResumeVoucherJobs() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockService) ExportVouchers(campaignID string, format voucher.ExportFormat, w io.Writer) error {
+	args := m.Called(campaignID, format, w)
+	return args.Error(0)
+}
+
+func (m *MockService) ImportVouchers(campaignID string, r io.Reader, actor string) (*ImportVouchersResponse, error) {
+	args := m.Called(campaignID, r, actor)
+	report := args.Get(0)
+	if report == nil {
+		return nil, args.Error(1)
+	}
+	return report.(*ImportVouchersResponse), args.Error(1)
+}
+
+func (m *MockService) CreateSharedVoucher(campaignID string, code string, maxRedemptions int, perUserLimit int, actor string) (*model.Voucher, error) {
+	args := m.Called(campaignID, code, maxRedemptions, perUserLimit, actor)
+	voucher := args.Get(0)
+	if voucher == nil {
+		return nil, args.Error(1)
+	}
+	return voucher.(*model.Voucher), args.Error(1)
+}
+
+func (m *MockService) RevokeVouchers(campaignID string, filter voucher.RevokeFilter, reason string, actor string) (int64, error) {
+	args := m.Called(campaignID, filter, reason, actor)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockService) UpdateCampaign(campaignID string, req UpdateCampaignRequest, actor string) (*model.Campaign, error) {
+	args := m.Called(campaignID, req, actor)
+	campaign := args.Get(0)
+	if campaign == nil {
+		return nil, args.Error(1)
+	}
+	return campaign.(*model.Campaign), args.Error(1)
+}
+
+func (m *MockService) CloneCampaign(campaignID string, name string, start time.Time, actor string) (*model.Campaign, error) {
+	args := m.Called(campaignID, name, start, actor)
+	campaign := args.Get(0)
+	if campaign == nil {
+		return nil, args.Error(1)
+	}
+	return campaign.(*model.Campaign), args.Error(1)
+}
+
+func (m *MockService) CreateTemplate(template *model.CampaignTemplate) error {
+	args := m.Called(template)
+	return args.Error(0)
+}
+
+func (m *MockService) ListTemplates() ([]model.CampaignTemplate, error) {
+	args := m.Called()
+	if templates, ok := args.Get(0).([]model.CampaignTemplate); ok {
+		return templates, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockService) CreateFromTemplate(name string, req CreateFromTemplateRequest, actor string) (*model.Campaign, error) {
+	args := m.Called(name, req, actor)
+	campaign := args.Get(0)
+	if campaign == nil {
+		return nil, args.Error(1)
+	}
+	return campaign.(*model.Campaign), args.Error(1)
+}
+
+func (m *MockService) GetVariantResults(campaignID string) ([]VariantResult, error) {
+	args := m.Called(campaignID)
+	if results, ok := args.Get(0).([]VariantResult); ok {
+		return results, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockService) GetHistory(campaignID string) ([]model.AuditEntry, error) {
+	args := m.Called(campaignID)
+	if entries, ok := args.Get(0).([]model.AuditEntry); ok {
+		return entries, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -2,10 +2,15 @@ package campaign
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
+	"trinity/internal/eligibility"
+	"trinity/internal/job"
 	"trinity/internal/model"
+	"trinity/internal/pricing"
 	"trinity/internal/voucher"
+	"trinity/pkg/logger"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -13,9 +18,11 @@ import (
 
 // Initialize the service with mocked dependencies
 func setupService(mockRepo *MockRepository, mockVoucherRepo *voucher.MockRepository) *service {
+	mockRepo.On("CreateAuditEntry", mock.AnythingOfType("*model.AuditEntry")).Return(nil).Maybe()
 	return &service{
 		repo:        mockRepo,
 		voucherRepo: mockVoucherRepo,
+		logger:      logger.NewLogger("campaignService"),
 	}
 }
 
@@ -29,13 +36,13 @@ func TestService_CreateCampaign_Success(t *testing.T) {
 		StartDate:   time.Now(),
 		EndDate:     time.Now().Add(48 * time.Hour),
 		MaxUsers:    100,
-		UsedUsers:   0,
+		Issued:      0,
 		Description: "A test campaign",
 	}
 
 	mockRepo.On("CreateCampaign", campaign).Return("campaign123", nil)
 
-	id, err := service.CreateCampaign(campaign)
+	id, err := service.CreateCampaign(campaign, "admin123")
 
 	assert.NoError(t, err, "Expected no error")
 	assert.Equal(t, "campaign123", id, "Expected campaign ID to match")
@@ -52,11 +59,11 @@ func TestService_CreateCampaign_InvalidDates(t *testing.T) {
 		StartDate:   time.Now().Add(48 * time.Hour),
 		EndDate:     time.Now(),
 		MaxUsers:    100,
-		UsedUsers:   0,
+		Issued:      0,
 		Description: "Campaign with invalid dates",
 	}
 
-	id, err := service.CreateCampaign(campaign)
+	id, err := service.CreateCampaign(campaign, "admin123")
 
 	assert.Error(t, err, "Expected an error due to invalid dates")
 	assert.Equal(t, "", id, "Expected no campaign ID to be returned")
@@ -78,20 +85,20 @@ func TestService_GenerateVouchers_Success(t *testing.T) {
 		StartDate:   time.Now(),
 		EndDate:     time.Now().Add(72 * time.Hour),
 		MaxUsers:    10,
-		UsedUsers:   3,
+		Issued:      3,
 		Description: "Campaign for generating vouchers",
 	}
 
 	// Expect GetCampaignByID to be called and return the campaign
 	mockRepo.On("GetCampaignByID", campaignID).Return(campaign, nil)
 
-	// Expect IncrementUsedUsers to be called once
-	mockRepo.On("IncrementUsedUsers", campaignID).Return(nil)
+	// Expect ClaimIssued to be called once
+	mockRepo.On("ClaimIssued", campaignID, count).Return(nil)
 
-	// Expect CreateVoucher to be called 'count' times with any Voucher
-	mockVoucherRepo.On("CreateVoucher", mock.AnythingOfType("*model.Voucher")).Return(nil).Times(count)
+	// Expect the vouchers to be inserted in a single batch without collisions
+	mockVoucherRepo.On("CreateVouchers", mock.AnythingOfType("[]model.Voucher")).Return(nil, nil).Once()
 
-	vouchers, err := service.GenerateVouchers(campaignID, count)
+	vouchers, err := service.GenerateVouchers(campaignID, count, nil, "admin123")
 
 	assert.NoError(t, err, "Expected no error")
 	assert.Len(t, vouchers, count, "Expected number of generated vouchers to match")
@@ -114,21 +121,38 @@ func TestService_GenerateVouchers_NotEnoughVouchers(t *testing.T) {
 		StartDate:   time.Now(),
 		EndDate:     time.Now().Add(72 * time.Hour),
 		MaxUsers:    10,
-		UsedUsers:   5, // Only 5 vouchers left
+		Issued:      5, // Only 5 vouchers left
 		Description: "Campaign with limited vouchers",
 	}
 
 	// Expect GetCampaignByID to be called and return the campaign
 	mockRepo.On("GetCampaignByID", campaignID).Return(campaign, nil)
 
-	vouchers, err := service.GenerateVouchers(campaignID, count)
+	vouchers, err := service.GenerateVouchers(campaignID, count, nil, "admin123")
 
 	assert.Error(t, err, "Expected an error due to insufficient vouchers")
 	assert.Nil(t, vouchers, "Expected no vouchers to be returned")
 
 	mockRepo.AssertExpectations(t)
-	mockVoucherRepo.AssertNotCalled(t, "CreateVoucher", mock.Anything)
-	mockRepo.AssertNotCalled(t, "IncrementUsedUsers", campaignID)
+	mockVoucherRepo.AssertNotCalled(t, "CreateVouchers", mock.Anything)
+	mockRepo.AssertNotCalled(t, "ClaimIssued", campaignID, mock.Anything)
+}
+
+func TestService_GenerateVouchers_OverIssue(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	// Ten redemptions are allowed but fifty codes may go out
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 10, MaxIssued: 50, Issued: 10, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("ClaimIssued", "campaign123", 20).Return(nil)
+	mockVoucherRepo.On("CreateVouchers", mock.AnythingOfType("[]model.Voucher")).Return(nil, nil)
+
+	vouchers, err := service.GenerateVouchers("campaign123", 20, nil, "admin123")
+
+	assert.NoError(t, err, "Expected codes beyond MaxUsers up to MaxIssued")
+	assert.Len(t, vouchers, 20)
 }
 
 func TestService_ListCampaigns_Success(t *testing.T) {
@@ -143,7 +167,7 @@ func TestService_ListCampaigns_Success(t *testing.T) {
 			StartDate:   time.Now(),
 			EndDate:     time.Now().Add(48 * time.Hour),
 			MaxUsers:    100,
-			UsedUsers:   20,
+			Issued:      20,
 			Description: "First campaign",
 		},
 		{
@@ -152,7 +176,7 @@ func TestService_ListCampaigns_Success(t *testing.T) {
 			StartDate:   time.Now(),
 			EndDate:     time.Now().Add(72 * time.Hour),
 			MaxUsers:    200,
-			UsedUsers:   50,
+			Issued:      50,
 			Description: "Second campaign",
 		},
 	}
@@ -180,3 +204,1170 @@ func TestService_ListCampaigns_RepositoryError(t *testing.T) {
 	assert.Nil(t, result, "Expected no campaigns to be returned")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestService_CreateCampaign_CodeFormatTooSmall(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{
+		Name:       "Tiny Codes",
+		StartDate:  time.Now(),
+		EndDate:    time.Now().Add(48 * time.Hour),
+		MaxUsers:   10000,
+		CodeFormat: &model.CodeFormat{Length: 3, Alphabet: voucher.SafeAlphabet},
+	}
+
+	id, err := service.CreateCampaign(campaign, "admin123")
+
+	assert.ErrorIs(t, err, voucher.ErrCodeSpaceTooSmall, "Expected the format to be refused")
+	assert.Equal(t, "", id, "Expected no campaign ID to be returned")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+func TestService_GenerateVouchers_UsesCampaignCodeFormat(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaignID := "campaign123"
+	count := 3
+	campaign := &model.Campaign{
+		Id:         campaignID,
+		EndDate:    time.Now().Add(72 * time.Hour),
+		MaxUsers:   10,
+		CodeFormat: &model.CodeFormat{Prefix: "SUMMER", Length: 8, Alphabet: voucher.SafeAlphabet, GroupSize: 4, Separator: "-"},
+	}
+
+	mockRepo.On("GetCampaignByID", campaignID).Return(campaign, nil)
+	mockRepo.On("ClaimIssued", campaignID, count).Return(nil)
+	mockVoucherRepo.On("CreateVouchers", mock.AnythingOfType("[]model.Voucher")).Return(nil, nil).Once()
+
+	vouchers, err := service.GenerateVouchers(campaignID, count, nil, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	for _, v := range vouchers {
+		assert.Regexp(t, `^SUMMER-[A-Z2-9]{4}-[A-Z2-9]{4}$`, v.Code, "Codes should follow the campaign format")
+	}
+}
+
+func TestService_GenerateVouchers_CodeSpaceTooSmall(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaignID := "campaign123"
+	campaign := &model.Campaign{
+		Id:         campaignID,
+		EndDate:    time.Now().Add(72 * time.Hour),
+		MaxUsers:   1000,
+		CodeFormat: &model.CodeFormat{Length: 2, Alphabet: voucher.SafeAlphabet},
+	}
+
+	mockRepo.On("GetCampaignByID", campaignID).Return(campaign, nil)
+
+	vouchers, err := service.GenerateVouchers(campaignID, 500, nil, "admin123")
+
+	assert.ErrorIs(t, err, voucher.ErrCodeSpaceTooSmall, "Expected the format to be refused")
+	assert.Nil(t, vouchers, "Expected no vouchers to be returned")
+	mockVoucherRepo.AssertNotCalled(t, "CreateVouchers", mock.Anything)
+}
+
+func TestService_GenerateVouchers_RetriesCollisions(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaignID := "campaign123"
+	count := 5
+	campaign := &model.Campaign{
+		Id:       campaignID,
+		EndDate:  time.Now().Add(72 * time.Hour),
+		MaxUsers: 10,
+	}
+
+	mockRepo.On("GetCampaignByID", campaignID).Return(campaign, nil)
+	mockRepo.On("ClaimIssued", campaignID, count).Return(nil)
+
+	// Two codes collide on the first insert and are regenerated
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return len(v) == count })).
+		Return([]int{1, 3}, nil).Once()
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return len(v) == 2 })).
+		Return(nil, nil).Once()
+
+	vouchers, err := service.GenerateVouchers(campaignID, count, nil, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Len(t, vouchers, count, "Expected exactly the requested number of vouchers")
+	mockRepo.AssertExpectations(t)
+	mockVoucherRepo.AssertExpectations(t)
+}
+
+func TestService_GenerateVouchers_TooManyCollisions(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaignID := "campaign123"
+	campaign := &model.Campaign{
+		Id:       campaignID,
+		EndDate:  time.Now().Add(72 * time.Hour),
+		MaxUsers: 10,
+	}
+
+	mockRepo.On("GetCampaignByID", campaignID).Return(campaign, nil)
+	// The first voucher is stored, the second collides every time
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return len(v) == 2 })).
+		Return([]int{1}, nil).Once()
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return len(v) == 1 })).
+		Return([]int{0}, nil)
+	mockRepo.On("ClaimIssued", campaignID, 2).Return(nil).Once()
+	mockRepo.On("ReleaseIssued", campaignID, 1).Return(nil).Once()
+
+	vouchers, err := service.GenerateVouchers(campaignID, 2, nil, "admin123")
+
+	assert.ErrorIs(t, err, ErrTooManyCollisions, "Expected generation to give up")
+	assert.Len(t, vouchers, 1, "Expected the voucher created before the failure to be returned")
+	mockRepo.AssertExpectations(t)
+	if entries := auditEntries(mockRepo); assert.Len(t, entries, 1, "Expected the issued voucher to be recorded") {
+		assert.Equal(t, map[string]interface{}{"issued": 1}, entries[0].After)
+	}
+}
+
+func TestService_GenerateVouchers_ClaimsBeforeInserting(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	// The campaign had room when read, but a concurrent request took it
+	campaign := &model.Campaign{Id: "campaign123", EndDate: time.Now().Add(72 * time.Hour), MaxUsers: 10}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("ClaimIssued", "campaign123", 5).Return(ErrNotEnoughVouchers)
+
+	vouchers, err := service.GenerateVouchers("campaign123", 5, nil, "admin123")
+
+	assert.ErrorIs(t, err, ErrNotEnoughVouchers, "Expected the claim to be refused")
+	assert.Empty(t, vouchers)
+	mockVoucherRepo.AssertNotCalled(t, "CreateVouchers", mock.Anything)
+}
+
+// memoryVoucherRepository stores voucher codes in memory and reports
+// collisions like the unique index does, for benchmarking generation
+type memoryVoucherRepository struct {
+	voucher.MockRepository
+	codes map[string]struct{}
+}
+
+func (r *memoryVoucherRepository) CreateVouchers(vouchers []model.Voucher) ([]int, error) {
+	var duplicates []int
+	for i, v := range vouchers {
+		if _, ok := r.codes[v.Code]; ok {
+			duplicates = append(duplicates, i)
+			continue
+		}
+		r.codes[v.Code] = struct{}{}
+	}
+	return duplicates, nil
+}
+
+func BenchmarkService_GenerateVouchers(b *testing.B) {
+	const count = 100000
+
+	for i := 0; i < b.N; i++ {
+		mockRepo := new(MockRepository)
+		campaign := &model.Campaign{
+			Id:       "campaign123",
+			EndDate:  time.Now().Add(72 * time.Hour),
+			MaxUsers: count,
+		}
+		mockRepo.On("GetCampaignByID", campaign.Id).Return(campaign, nil)
+		mockRepo.On("ClaimIssued", campaign.Id, mock.Anything).Return(nil)
+		mockRepo.On("CreateAuditEntry", mock.Anything).Return(nil)
+
+		service := &service{
+			repo:        mockRepo,
+			voucherRepo: &memoryVoucherRepository{codes: make(map[string]struct{}, count)},
+			logger:      logger.NewLogger("campaignService"),
+		}
+
+		vouchers, err := service.GenerateVouchers(campaign.Id, count, nil, "admin123")
+		if err != nil || len(vouchers) != count {
+			b.Fatalf("generated %d vouchers: %v", len(vouchers), err)
+		}
+	}
+}
+
+func TestService_StartVoucherJob_CompletesInBackground(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	mockJobRepo := new(job.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+	service.jobRepo = mockJobRepo
+
+	campaignID := "campaign123"
+	count := voucherBatchSize + 500
+	campaign := &model.Campaign{
+		Id:       campaignID,
+		EndDate:  time.Now().Add(72 * time.Hour),
+		MaxUsers: 5000,
+	}
+
+	done := make(chan struct{})
+	mockRepo.On("GetCampaignByID", campaignID).Return(campaign, nil)
+	mockRepo.On("ClaimIssued", campaignID, mock.Anything).Return(nil)
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return v[0].JobID == "job123" })).Return(nil, nil)
+	mockVoucherRepo.On("CountVouchersByJob", "job123").Return(0, nil)
+	mockJobRepo.On("CreateJob", mock.AnythingOfType("*model.Job")).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.Job).Id = "job123"
+	}).Return(nil)
+	mockJobRepo.On("ClaimJob", "job123", mock.Anything, mock.Anything).Return(nil)
+	mockJobRepo.On("SetProgress", "job123", mock.Anything, 0, mock.Anything).Return(nil).Once()
+	mockJobRepo.On("SetProgress", "job123", mock.Anything, voucherBatchSize, mock.Anything).Return(nil).Once()
+	mockJobRepo.On("SetProgress", "job123", mock.Anything, count, mock.Anything).Return(nil).Once()
+	mockJobRepo.On("FinishJob", "job123", mock.Anything, model.JobCompleted, "").Run(func(mock.Arguments) {
+		close(done)
+	}).Return(nil)
+
+	started, err := service.StartVoucherJob(campaignID, count, nil, "user123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, "job123", started.Id, "Expected the job to be returned")
+	assert.Equal(t, model.JobPending, started.Status, "Expected the job to start pending")
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not finish")
+	}
+	mockJobRepo.AssertExpectations(t)
+	mockRepo.AssertCalled(t, "ClaimIssued", campaignID, voucherBatchSize)
+	mockRepo.AssertCalled(t, "ClaimIssued", campaignID, 500)
+}
+
+func TestService_StartVoucherJob_NotEnoughVouchers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockJobRepo := new(job.MockRepository)
+	service := setupService(mockRepo, new(voucher.MockRepository))
+	service.jobRepo = mockJobRepo
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 10, Issued: 8}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+
+	started, err := service.StartVoucherJob("campaign123", 5, nil, "user123")
+
+	assert.Error(t, err, "Expected the request to be refused up front")
+	assert.Nil(t, started, "Expected no job to be created")
+	mockJobRepo.AssertNotCalled(t, "CreateJob", mock.Anything)
+}
+
+func TestService_ResumeVoucherJobs_FailsWhenCampaignIsFull(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockJobRepo := new(job.MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+	service.jobRepo = mockJobRepo
+
+	interrupted := model.Job{Id: "job123", CampaignID: "campaign123", Status: model.JobRunning, Total: 100, Generated: 40}
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 100, Issued: 90}
+
+	done := make(chan struct{})
+	mockJobRepo.On("ListUnfinishedJobs", model.JobGenerateVouchers).Return([]model.Job{interrupted}, nil)
+	mockJobRepo.On("ClaimJob", "job123", mock.Anything, mock.Anything).Return(nil)
+	mockVoucherRepo.On("CountVouchersByJob", "job123").Return(40, nil)
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockJobRepo.On("FinishJob", "job123", mock.Anything, model.JobFailed, mock.AnythingOfType("string")).Run(func(mock.Arguments) {
+		close(done)
+	}).Return(nil)
+
+	err := service.ResumeVoucherJobs()
+	assert.NoError(t, err, "Expected no error")
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not failed")
+	}
+	mockJobRepo.AssertExpectations(t)
+}
+
+func TestService_RunVoucherJob_SkipsBatchInsertedBeforeCrash(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockJobRepo := new(job.MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+	service.jobRepo = mockJobRepo
+
+	// The first batch was inserted but its progress was never recorded
+	interrupted := model.Job{Id: "job123", CampaignID: "campaign123", Status: model.JobRunning, Total: voucherBatchSize + 500}
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 5000, Issued: voucherBatchSize, EndDate: time.Now().Add(72 * time.Hour)}
+
+	mockJobRepo.On("ClaimJob", "job123", mock.Anything, mock.Anything).Return(nil)
+	mockVoucherRepo.On("CountVouchersByJob", "job123").Return(voucherBatchSize, nil)
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockJobRepo.On("SetProgress", "job123", mock.Anything, voucherBatchSize, mock.Anything).Return(nil).Once()
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return len(v) == 500 })).Return(nil, nil).Once()
+	mockRepo.On("ClaimIssued", "campaign123", 500).Return(nil).Once()
+	mockJobRepo.On("SetProgress", "job123", mock.Anything, voucherBatchSize+500, mock.Anything).Return(nil).Once()
+	mockJobRepo.On("FinishJob", "job123", mock.Anything, model.JobCompleted, "").Return(nil)
+
+	service.runVoucherJob(interrupted)
+
+	mockJobRepo.AssertExpectations(t)
+	mockVoucherRepo.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_RunVoucherJob_AssignsWaitingUsers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockJobRepo := new(job.MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+	service.jobRepo = mockJobRepo
+
+	interrupted := model.Job{Id: "job123", CampaignID: "campaign123", Status: model.JobRunning, Total: 3, UserIDs: []string{"user1", "user2", "user3"}}
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 10, Issued: 1, EndDate: time.Now().Add(72 * time.Hour)}
+
+	mockJobRepo.On("ClaimJob", "job123", mock.Anything, mock.Anything).Return(nil)
+	mockVoucherRepo.On("ListAssignedUsersByJob", "job123").Return([]string{"user2"}, nil)
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockJobRepo.On("SetProgress", "job123", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool {
+		return len(v) == 2 && v[0].AssignedUserId == "user1" && v[1].AssignedUserId == "user3"
+	})).Return(nil, nil).Once()
+	mockRepo.On("ClaimIssued", "campaign123", 2).Return(nil).Once()
+	mockJobRepo.On("FinishJob", "job123", mock.Anything, model.JobCompleted, "").Return(nil)
+
+	service.runVoucherJob(interrupted)
+
+	mockVoucherRepo.AssertExpectations(t)
+	mockJobRepo.AssertCalled(t, "SetProgress", "job123", mock.Anything, 3, mock.Anything)
+}
+
+func TestService_RunVoucherJob_HeldByAnotherWorker(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockJobRepo := new(job.MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+	service.jobRepo = mockJobRepo
+
+	mockJobRepo.On("ClaimJob", "job123", mock.Anything, mock.Anything).Return(job.ErrJobClaimed)
+
+	service.runVoucherJob(model.Job{Id: "job123", CampaignID: "campaign123", Status: model.JobRunning, Total: 100})
+
+	mockVoucherRepo.AssertNotCalled(t, "CreateVouchers", mock.Anything)
+	mockJobRepo.AssertNotCalled(t, "FinishJob", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestService_ExportVouchers_CSV(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	expiry := time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123"}, nil)
+	mockVoucherRepo.On("StreamVouchersByCampaign", "campaign123", mock.Anything).Return([]model.Voucher{
+		{Code: "CODE1", ExpiryDate: expiry},
+		{Code: "CODE2", Used: true, ExpiryDate: expiry},
+	}, nil)
+
+	var out strings.Builder
+	err := service.ExportVouchers("campaign123", voucher.ExportCSV, &out)
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, "code,status,expiry_date\nCODE1,active,2030-06-01T00:00:00Z\nCODE2,used,2030-06-01T00:00:00Z\n", out.String())
+}
+
+func TestService_ExportVouchers_CampaignNotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	mockRepo.On("GetCampaignByID", "missing").Return(nil, ErrCampaignNotFound)
+
+	var out strings.Builder
+	err := service.ExportVouchers("missing", voucher.ExportCSV, &out)
+
+	assert.ErrorIs(t, err, ErrCampaignNotFound, "Expected the lookup error")
+	assert.Empty(t, out.String(), "Nothing should be written for a missing campaign")
+	mockVoucherRepo.AssertNotCalled(t, "StreamVouchersByCampaign", mock.Anything, mock.Anything)
+}
+
+func TestService_ImportVouchers_Report(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	taken := "PARTNERTAKEN"
+	valid := []string{"PARTNER1", "PARTNER2", "PARTNER3"}
+	csvFile := "code\n" +
+		valid[0] + "\n" +
+		"partner lower\n" +
+		"PARTNER 4\n" +
+		valid[0] + "\n" +
+		taken + "\n" +
+		valid[1] + "\n" +
+		valid[2] + "\n"
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 10, Issued: 7, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	// Three slots remain: the taken code is rejected by the unique index,
+	// and the next batch fills the last slot
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return len(v) == 3 })).Return([]int{1}, nil).Once()
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return len(v) == 1 && v[0].Code == voucher.WithCheckCharacter(valid[2]) })).Return(nil, nil).Once()
+	mockRepo.On("ClaimIssued", "campaign123", 3).Return(nil).Once()
+	mockRepo.On("ReleaseIssued", "campaign123", 1).Return(nil).Once()
+	mockRepo.On("ClaimIssued", "campaign123", 1).Return(nil).Once()
+
+	report, err := service.ImportVouchers("campaign123", strings.NewReader(csvFile), "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, 3, report.Accepted, "Expected the campaign to be filled")
+	assert.Equal(t, 2, report.Duplicate, "Expected the repeated and taken codes to be duplicates")
+	assert.Equal(t, 2, report.Invalid, "Expected the malformed codes to be invalid")
+	assert.Equal(t, 0, report.OverCapacity)
+
+	statuses := make([]ImportStatus, len(report.Rows))
+	for i, row := range report.Rows {
+		statuses[i] = row.Status
+	}
+	assert.Equal(t, []ImportStatus{ImportAccepted, ImportInvalid, ImportInvalid, ImportDuplicate, ImportDuplicate, ImportAccepted, ImportAccepted}, statuses)
+	assert.Equal(t, 2, report.Rows[0].Row, "Rows should be numbered by file line")
+	mockRepo.AssertExpectations(t)
+	mockVoucherRepo.AssertExpectations(t)
+}
+
+func TestService_ImportVouchers_OverCapacity(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 5, Issued: 4}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool {
+		return len(v) == 1 && v[0].Code == voucher.WithCheckCharacter("PARTNER1")
+	})).Return(nil, nil).Once()
+	mockRepo.On("ClaimIssued", "campaign123", 1).Return(nil).Once()
+
+	report, err := service.ImportVouchers("campaign123", strings.NewReader("PARTNER1\nPARTNER2\nPARTNER3\n"), "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, 1, report.Accepted, "Expected only the remaining slot to be filled")
+	assert.Equal(t, 2, report.OverCapacity, "Expected the rest to be refused")
+	assert.Equal(t, "PARTNER1", report.Rows[0].Code, "The report should show the code as supplied")
+	assert.Equal(t, voucher.WithCheckCharacter("PARTNER1"), report.Rows[0].VoucherCode, "The report should show the code to hand out")
+	assert.NoError(t, voucher.ValidateCode(report.Rows[0].VoucherCode), "Imported codes should carry a check character")
+	mockVoucherRepo.AssertExpectations(t)
+}
+
+func TestService_ImportVouchers_CodeFormat(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	format := &model.CodeFormat{Prefix: "SUMMER", Length: 8, Alphabet: voucher.SafeAlphabet, GroupSize: 4, Separator: "-"}
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 10, CodeFormat: format}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("ClaimIssued", "campaign123", 1).Return(nil).Once()
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool {
+		return len(v) == 1 && v[0].Code == voucher.WithCheckCharacter("SUMMER-7KQ2-MX9P")
+	})).Return(nil, nil).Once()
+
+	report, err := service.ImportVouchers("campaign123", strings.NewReader("SUMMER-7KQ2-MX9P\nWINTER-7KQ2-MX9P\nSUMMER-7KQ0-MX9P\n"), "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, 1, report.Accepted, "Expected the matching code to be imported")
+	assert.Equal(t, 2, report.Invalid, "Expected the wrong prefix and alphabet to be invalid")
+	mockVoucherRepo.AssertExpectations(t)
+}
+
+func TestService_ImportVouchers_InsertFails(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 10, Issued: 8}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("ClaimIssued", "campaign123", 2).Return(nil).Once()
+	mockRepo.On("ReleaseIssued", "campaign123", 2).Return(nil).Once()
+	mockVoucherRepo.On("CreateVouchers", mock.Anything).Return(nil, errors.New("database error")).Once()
+
+	report, err := service.ImportVouchers("campaign123", strings.NewReader("PARTNER1\nPARTNER1\nPARTNER2\nPARTNER3\n"), "admin123")
+
+	assert.Error(t, err, "Expected the insert error")
+	if assert.NotNil(t, report, "Expected the report so far to be returned") {
+		assert.Equal(t, 0, report.Accepted)
+		assert.Equal(t, 1, report.Duplicate, "Rows checked before the failure should keep their status")
+		assert.Equal(t, 3, report.Skipped, "Expected the rows not inserted to be skipped")
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_ImportVouchers_InvalidFile(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	mockRepo.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123", MaxUsers: 5}, nil)
+
+	_, err := service.ImportVouchers("campaign123", strings.NewReader("\"PARTNER1\nPARTNER2\n"), "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidImportFile, "Expected the CSV error to be reported")
+	mockVoucherRepo.AssertNotCalled(t, "CreateVouchers", mock.Anything)
+}
+
+func TestService_CreateSharedVoucher_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 1000, Issued: 100, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool {
+		return len(v) == 1 && v[0].Mode == model.VoucherMultiUse && v[0].MaxRedemptions == 500 && v[0].PerUserLimit == 1
+	})).Return(nil, nil)
+	mockRepo.On("ClaimIssued", "campaign123", 500).Return(nil)
+
+	shared, err := service.CreateSharedVoucher("campaign123", "WELCOME2026", 500, 0, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, voucher.WithCheckCharacter("WELCOME2026"), shared.Code, "Expected the code to carry a check character")
+	mockRepo.AssertExpectations(t)
+	mockVoucherRepo.AssertExpectations(t)
+}
+
+func TestService_CreateSharedVoucher_CodeTaken(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	mockRepo.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123", MaxUsers: 1000}, nil)
+	mockRepo.On("ClaimIssued", "campaign123", 500).Return(nil).Once()
+	mockRepo.On("ReleaseIssued", "campaign123", 500).Return(nil).Once()
+	mockVoucherRepo.On("CreateVouchers", mock.Anything).Return([]int{0}, nil)
+
+	_, err := service.CreateSharedVoucher("campaign123", "WELCOME2026", 500, 1, "admin123")
+
+	assert.ErrorIs(t, err, ErrCodeTaken, "Expected the duplicate code to be reported")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_CreateSharedVoucher_NotEnoughVouchers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	mockRepo.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123", MaxUsers: 100, Issued: 50}, nil)
+
+	_, err := service.CreateSharedVoucher("campaign123", "WELCOME2026", 500, 1, "admin123")
+
+	assert.ErrorIs(t, err, ErrNotEnoughVouchers, "Redemptions should count against the campaign size")
+	mockVoucherRepo.AssertNotCalled(t, "CreateVouchers", mock.Anything)
+}
+
+func TestService_GenerateVouchers_AssignsUsers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaignID := "campaign123"
+	userIDs := []string{"user1", "user2", "user3"}
+	campaign := &model.Campaign{Id: campaignID, EndDate: time.Now().Add(72 * time.Hour), MaxUsers: 10}
+
+	mockRepo.On("GetCampaignByID", campaignID).Return(campaign, nil)
+	mockRepo.On("ClaimIssued", campaignID, 3).Return(nil)
+	// The second voucher collides and must keep its user when it gets a new code
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool { return len(v) == 3 })).Return([]int{1}, nil).Once()
+	mockVoucherRepo.On("CreateVouchers", mock.MatchedBy(func(v []model.Voucher) bool {
+		return len(v) == 1 && v[0].AssignedUserId == "user2"
+	})).Return(nil, nil).Once()
+
+	vouchers, err := service.GenerateVouchers(campaignID, 3, userIDs, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assigned := make([]string, 0, len(vouchers))
+	for _, v := range vouchers {
+		assigned = append(assigned, v.AssignedUserId)
+	}
+	assert.ElementsMatch(t, userIDs, assigned, "Expected one voucher per user")
+	mockVoucherRepo.AssertExpectations(t)
+}
+
+func TestService_GenerateVouchers_AssignmentMismatch(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	vouchers, err := service.GenerateVouchers("campaign123", 5, []string{"user1", "user2"}, "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidAssignment, "Expected the count to match the users")
+	assert.Nil(t, vouchers)
+	mockRepo.AssertNotCalled(t, "GetCampaignByID", mock.Anything)
+}
+
+func TestService_RevokeVouchers_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	filter := voucher.RevokeFilter{Codes: []string{"CODE1", "CODE2"}}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123"}, nil)
+	mockVoucherRepo.On("RevokeVouchers", "campaign123", filter, "batch leaked", mock.AnythingOfType("time.Time")).Return(int64(2), nil)
+
+	revoked, err := service.RevokeVouchers("campaign123", filter, "batch leaked", "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, int64(2), revoked)
+	mockVoucherRepo.AssertExpectations(t)
+}
+
+func TestService_RevokeVouchers_CampaignNotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	mockRepo.On("GetCampaignByID", "missing").Return(nil, ErrCampaignNotFound)
+
+	_, err := service.RevokeVouchers("missing", voucher.RevokeFilter{}, "batch leaked", "admin123")
+
+	assert.Error(t, err, "Expected the lookup error")
+	mockVoucherRepo.AssertNotCalled(t, "RevokeVouchers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestService_CreateCampaign_DiscountOverFull(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Name: "Too generous", Discount: 150, MaxUsers: 100, StartDate: time.Now(), EndDate: time.Now().Add(24 * time.Hour)}
+
+	_, err := service.CreateCampaign(campaign, "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidCampaign, "A discount above 100 percent should be rejected")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+func TestService_CreateCampaign_InvalidValidity(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{
+		Name:      "Relative Campaign",
+		StartDate: time.Now(),
+		EndDate:   time.Now().Add(72 * time.Hour),
+		MaxUsers:  100,
+		Validity:  &model.VoucherValidity{Days: 7, From: "assignment"},
+	}
+
+	_, err := service.CreateCampaign(campaign, "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidValidity, "Expected the unknown start to be refused")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+func TestService_GenerateVouchers_RelativeValidity(t *testing.T) {
+	endDate := time.Now().Add(30 * 24 * time.Hour)
+	tests := []struct {
+		name         string
+		validity     *model.VoucherValidity
+		expiry       time.Time
+		validityDays int
+	}{
+		{"campaign end", nil, endDate, 0},
+		{"days from issue", &model.VoucherValidity{Days: 7, From: model.ValidFromIssue}, time.Now().AddDate(0, 0, 7), 0},
+		{"capped by campaign end", &model.VoucherValidity{Days: 90, From: model.ValidFromIssue}, endDate, 0},
+		{"days from first view", &model.VoucherValidity{Days: 7, From: model.ValidFromFirstView}, endDate, 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockVoucherRepo := new(voucher.MockRepository)
+			service := setupService(mockRepo, mockVoucherRepo)
+
+			campaign := &model.Campaign{Id: "campaign123", EndDate: endDate, MaxUsers: 10, Validity: tt.validity}
+			mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+			mockRepo.On("ClaimIssued", "campaign123", 2).Return(nil)
+			mockVoucherRepo.On("CreateVouchers", mock.AnythingOfType("[]model.Voucher")).Return(nil, nil)
+
+			vouchers, err := service.GenerateVouchers("campaign123", 2, nil, "admin123")
+
+			assert.NoError(t, err, "Expected no error")
+			for _, v := range vouchers {
+				assert.WithinDuration(t, tt.expiry, v.ExpiryDate, time.Minute, "Unexpected expiry")
+				assert.Equal(t, tt.validityDays, v.ValidityDays, "Unexpected pending validity")
+			}
+		})
+	}
+}
+
+func TestService_CreateCampaign_InvalidEligibility(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{
+		Name:        "Platinum Campaign",
+		StartDate:   time.Now(),
+		EndDate:     time.Now().Add(72 * time.Hour),
+		MaxUsers:    100,
+		Eligibility: &model.EligibilityRules{Plans: []model.SubscriptionPlan{"platinum"}},
+	}
+
+	_, err := service.CreateCampaign(campaign, "admin123")
+
+	assert.ErrorIs(t, err, eligibility.ErrInvalidRules, "Expected the unknown plan to be refused")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+func TestService_CreateCampaign_InvalidCondition(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{
+		Name:      "Weekend Campaign",
+		StartDate: time.Now(),
+		EndDate:   time.Now().Add(72 * time.Hour),
+		MaxUsers:  100,
+		Condition: `plan == "gold" && now.weekday in [6, 7`,
+	}
+
+	_, err := service.CreateCampaign(campaign, "admin123")
+
+	var conditionErr *eligibility.ConditionError
+	assert.ErrorAs(t, err, &conditionErr, "Expected the compile error with its position")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+func TestService_UpdateCampaign_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", Name: "Summer", Discount: 30, MaxUsers: 100, Issued: 40, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("UpdateCampaign", mock.AnythingOfType("*model.Campaign")).Return(nil)
+
+	discount, condition := 25.0, `plan == "gold"`
+	updated, err := service.UpdateCampaign("campaign123", UpdateCampaignRequest{Discount: &discount, Condition: &condition}, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, 25.0, updated.Discount, "Expected the discount to change")
+	assert.Equal(t, condition, updated.Condition, "Expected the condition to change")
+	assert.Equal(t, "Summer", updated.Name, "Expected unset fields to stay")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_UpdateCampaign_MaxUsersBelowIssued(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 100, Issued: 40, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+
+	maxUsers := 10
+	_, err := service.UpdateCampaign("campaign123", UpdateCampaignRequest{MaxUsers: &maxUsers}, "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidCampaign, "Expected MaxUsers below the issued vouchers to be refused")
+	mockRepo.AssertNotCalled(t, "UpdateCampaign", mock.Anything)
+}
+
+func TestService_CreateCampaign_UnknownStackingMode(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{
+		Name:      "Combo",
+		StartDate: time.Now(),
+		EndDate:   time.Now().Add(72 * time.Hour),
+		MaxUsers:  100,
+		Stacking:  &model.StackingPolicy{Mode: "sometimes"},
+	}
+
+	_, err := service.CreateCampaign(campaign, "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidCampaign, "Expected unknown stacking modes to be refused")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+func TestService_UpdateCampaign_MaxUsersBelowRedeemed(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 100, MaxIssued: 500, Issued: 300, Redeemed: 60, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+
+	maxUsers := 50
+	_, err := service.UpdateCampaign("campaign123", UpdateCampaignRequest{MaxUsers: &maxUsers}, "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidCampaign, "Expected MaxUsers below the redemptions so far to be refused")
+	mockRepo.AssertNotCalled(t, "UpdateCampaign", mock.Anything)
+}
+
+func TestService_UpdateCampaign_RaisedBudgetReactivates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 100, Budget: 1000, Spent: 1000, Status: model.CampaignExhausted, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("UpdateCampaign", mock.MatchedBy(func(c *model.Campaign) bool { return c.Budget == 5000 })).Return(nil)
+	mockRepo.On("ReactivateCampaign", "campaign123").Return(true, nil)
+
+	budget := 5000.0
+	updated, err := service.UpdateCampaign("campaign123", UpdateCampaignRequest{Budget: &budget}, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, model.CampaignActive, updated.Status, "Expected the campaign to give discounts again")
+	entries := auditEntries(mockRepo)
+	if assert.Len(t, entries, 2, "Expected the update and the status change to be recorded") {
+		assert.Equal(t, model.AuditStatusChange, entries[1].Action)
+		assert.Equal(t, "admin123", entries[1].Actor)
+		assert.Equal(t, map[string]interface{}{"status": model.CampaignActive}, entries[1].After)
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_UpdateCampaign_BudgetStillSpent(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	// The campaign was read as active, but a purchase exhausts it before the
+	// budget is saved and the stored spend still exceeds the new budget
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 100, Budget: 1000, Spent: 900, Status: model.CampaignActive, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("UpdateCampaign", mock.AnythingOfType("*model.Campaign")).Return(nil)
+	mockRepo.On("ReactivateCampaign", "campaign123").Return(false, nil)
+
+	budget := 1200.0
+	updated, err := service.UpdateCampaign("campaign123", UpdateCampaignRequest{Budget: &budget}, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, model.CampaignActive, updated.Status)
+	mockRepo.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything)
+	for _, entry := range auditEntries(mockRepo) {
+		assert.NotEqual(t, model.AuditStatusChange, entry.Action, "No status change should be recorded")
+	}
+}
+
+func TestService_UpdateCampaign_BudgetBelowSpent(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 100, Budget: 1000, Spent: 700, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+
+	budget := 500.0
+	_, err := service.UpdateCampaign("campaign123", UpdateCampaignRequest{Budget: &budget}, "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidCampaign, "Expected a budget below the spend to be refused")
+	mockRepo.AssertNotCalled(t, "UpdateCampaign", mock.Anything)
+}
+
+func TestService_CreateCampaign_OverlappingTiers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{
+		Name:      "Tiered",
+		Discount:  10,
+		StartDate: time.Now(),
+		EndDate:   time.Now().Add(72 * time.Hour),
+		MaxUsers:  100,
+		Tiers: []model.DiscountTier{
+			{MinAmount: 0, MaxAmount: 150, Discount: 10},
+			{MinAmount: 100, Discount: 25},
+		},
+	}
+
+	_, err := service.CreateCampaign(campaign, "admin123")
+
+	assert.ErrorIs(t, err, pricing.ErrInvalidTiers, "Expected overlapping tiers to be refused")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+func TestService_CloneCampaign_ShiftsDates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	source := &model.Campaign{
+		Id: "campaign123", Name: "September", Discount: 20, MaxUsers: 100,
+		Issued: 80, Redeemed: 60, Spent: 1200, Status: model.CampaignExhausted,
+		StartDate: start, EndDate: start.AddDate(0, 0, 30),
+	}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(source, nil)
+	mockRepo.On("CreateCampaign", mock.AnythingOfType("*model.Campaign")).Return("campaign456", nil)
+
+	october := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	clone, err := service.CloneCampaign("campaign123", "October", october, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, "campaign456", clone.Id)
+	assert.Equal(t, "October", clone.Name)
+	assert.Equal(t, 20.0, clone.Discount, "Expected the settings to be copied")
+	assert.Equal(t, october, clone.StartDate)
+	assert.Equal(t, october.AddDate(0, 0, 30), clone.EndDate, "Expected the clone to run as long as the original")
+	assert.Zero(t, clone.Issued, "Expected the counters to start over")
+	assert.Zero(t, clone.Redeemed)
+	assert.Zero(t, clone.Spent)
+	assert.Equal(t, model.CampaignActive, clone.Status)
+}
+
+func TestService_CreateFromTemplate_OverridesGivenFields(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	template := &model.CampaignTemplate{
+		Name: "monthly", Discount: 15, MaxUsers: 500, Description: "Monthly promotion",
+		CodeFormat: &model.CodeFormat{Prefix: "MONTH", Length: 8, Alphabet: voucher.SafeAlphabet},
+	}
+	mockRepo.On("GetTemplateByName", "monthly").Return(template, nil)
+	mockRepo.On("CreateCampaign", mock.AnythingOfType("*model.Campaign")).Return("campaign789", nil)
+
+	name, discount := "November", 25.0
+	start, end := "2026-11-01T00:00:00Z", "2026-11-30T23:59:59Z"
+	campaign, err := service.CreateFromTemplate("monthly", CreateFromTemplateRequest{
+		UpdateCampaignRequest: UpdateCampaignRequest{Name: &name, Discount: &discount, StartDate: &start, EndDate: &end},
+	}, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, "November", campaign.Name)
+	assert.Equal(t, 25.0, campaign.Discount, "Expected the given discount to win")
+	assert.Equal(t, 500, campaign.MaxUsers, "Expected the template's max users")
+	assert.Equal(t, "Monthly promotion", campaign.Description, "Expected the template's description")
+	assert.Equal(t, "MONTH", campaign.CodeFormat.Prefix, "Expected the template's code format")
+}
+
+func TestService_CreateFromTemplate_OverridesCodeFormatAndAutomatic(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	template := &model.CampaignTemplate{
+		Name: "monthly", Discount: 15, MaxUsers: 500, Description: "Monthly promotion",
+		CodeFormat: &model.CodeFormat{Prefix: "MONTH", Length: 8, Alphabet: voucher.SafeAlphabet},
+	}
+	mockRepo.On("GetTemplateByName", "monthly").Return(template, nil)
+	mockRepo.On("CreateCampaign", mock.AnythingOfType("*model.Campaign")).Return("campaign789", nil)
+
+	start, end, automatic := "2026-11-01T00:00:00Z", "2026-11-30T23:59:59Z", true
+	campaign, err := service.CreateFromTemplate("monthly", CreateFromTemplateRequest{
+		UpdateCampaignRequest: UpdateCampaignRequest{StartDate: &start, EndDate: &end},
+		CodeFormat:            &model.CodeFormat{Prefix: "NOV", Length: 8, Alphabet: voucher.SafeAlphabet},
+		Automatic:             &automatic,
+	}, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, "NOV", campaign.CodeFormat.Prefix, "Expected the given code format to win")
+	assert.True(t, campaign.Automatic, "Expected the campaign to apply automatically")
+	assert.Equal(t, "MONTH", template.CodeFormat.Prefix, "The template should be left untouched")
+}
+
+func TestService_CreateFromTemplate_RequiresDates(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	mockRepo.On("GetTemplateByName", "monthly").Return(&model.CampaignTemplate{Name: "monthly", Discount: 15, MaxUsers: 500}, nil)
+
+	_, err := service.CreateFromTemplate("monthly", CreateFromTemplateRequest{}, "admin123")
+
+	assert.ErrorIs(t, err, ErrInvalidCampaign, "Expected a campaign without dates to be refused")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+func TestService_CreateTemplate_InvalidCondition(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	err := service.CreateTemplate(&model.CampaignTemplate{Name: "broken", Discount: 10, Condition: "plan =="})
+
+	var conditionErr *eligibility.ConditionError
+	assert.ErrorAs(t, err, &conditionErr, "Expected templates to be validated like campaigns")
+	mockRepo.AssertNotCalled(t, "CreateTemplate", mock.Anything)
+}
+
+func TestService_GetVariantResults(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	mockRepo.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123", Variants: []model.CampaignVariant{
+		{Name: "control", Weight: 1, Discount: 15},
+		{Name: "treatment", Weight: 1, Discount: 25},
+	}}, nil)
+	mockRepo.On("CountExposures", "campaign123").Return(map[string]int64{"control": 200, "treatment": 100}, nil)
+	mockVoucherRepo.On("CountRedemptionsByVariant", "campaign123").Return(map[string]int64{"control": 30}, nil)
+	mockRepo.On("SumPurchasesByVariant", "campaign123").Return(map[string]VariantSales{
+		"control": {Purchases: 20, Revenue: 3400, Discount: 600},
+	}, nil)
+
+	results, err := service.GetVariantResults("campaign123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Len(t, results, 2, "Expected a result per variant")
+	assert.Equal(t, "control", results[0].Variant)
+	assert.Equal(t, int64(200), results[0].Users)
+	assert.Equal(t, int64(30), results[0].Redemptions)
+	assert.InDelta(t, 0.1, results[0].Conversion, 0.0001, "20 purchases from 200 users is a 10% conversion")
+	assert.Equal(t, 3400.0, results[0].Revenue)
+	assert.Equal(t, 600.0, results[0].DiscountGiven)
+	assert.Equal(t, "treatment", results[1].Variant, "Variants without purchases are reported too")
+	assert.Zero(t, results[1].Purchases)
+	assert.Zero(t, results[1].Conversion)
+}
+
+func TestService_GetVariantResults_NoVariants(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	mockRepo.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123", Discount: 20}, nil)
+
+	_, err := service.GetVariantResults("campaign123")
+
+	assert.ErrorIs(t, err, ErrNoVariants)
+	mockRepo.AssertNotCalled(t, "CountExposures", mock.Anything)
+}
+
+func TestService_CreateCampaign_VariantsWithTiers(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{
+		Name: "AB test", Discount: 20, MaxUsers: 100,
+		StartDate: time.Now(), EndDate: time.Now().Add(24 * time.Hour),
+		Tiers: []model.DiscountTier{{Plan: model.PlanGold, Discount: 30}},
+		Variants: []model.CampaignVariant{
+			{Name: "control", Weight: 1, Discount: 15},
+			{Name: "treatment", Weight: 1, Discount: 25},
+		},
+	}
+
+	_, err := service.CreateCampaign(campaign, "admin123")
+
+	assert.ErrorIs(t, err, pricing.ErrInvalidVariants, "Variants and tiers both replace the discount")
+	mockRepo.AssertNotCalled(t, "CreateCampaign", mock.Anything)
+}
+
+// auditEntries returns the audit entries the service wrote to the mock
+func auditEntries(mockRepo *MockRepository) []*model.AuditEntry {
+	var entries []*model.AuditEntry
+	for _, call := range mockRepo.Calls {
+		if call.Method == "CreateAuditEntry" {
+			entries = append(entries, call.Arguments.Get(0).(*model.AuditEntry))
+		}
+	}
+	return entries
+}
+
+func TestService_UpdateCampaign_RecordsDiff(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", Name: "Summer", Discount: 30, MaxUsers: 100, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("UpdateCampaign", mock.AnythingOfType("*model.Campaign")).Return(nil)
+
+	discount, maxUsers, name := 25.0, 200, "Summer"
+	_, err := service.UpdateCampaign("campaign123", UpdateCampaignRequest{Discount: &discount, MaxUsers: &maxUsers, Name: &name}, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	entries := auditEntries(mockRepo)
+	if assert.Len(t, entries, 1, "Expected one audit entry") {
+		entry := entries[0]
+		assert.Equal(t, "campaign123", entry.CampaignID)
+		assert.Equal(t, "admin123", entry.Actor)
+		assert.Equal(t, model.AuditUpdate, entry.Action)
+		assert.False(t, entry.At.IsZero(), "Expected the entry to be timestamped")
+		assert.Equal(t, map[string]interface{}{"discount": 30.0, "max_users": 100}, entry.Before, "Expected only the changed fields")
+		assert.Equal(t, map[string]interface{}{"discount": 25.0, "max_users": 200}, entry.After)
+	}
+}
+
+func TestService_UpdateCampaign_NothingChanged(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", Discount: 30, MaxUsers: 100, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("UpdateCampaign", mock.AnythingOfType("*model.Campaign")).Return(nil)
+
+	discount := 30.0
+	_, err := service.UpdateCampaign("campaign123", UpdateCampaignRequest{Discount: &discount}, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Empty(t, auditEntries(mockRepo), "Expected no entry for an update that changes nothing")
+}
+
+func TestService_CreateCampaign_RecordsCreation(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Name: "Summer", Discount: 30, MaxUsers: 100, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("CreateCampaign", campaign).Return("campaign123", nil)
+
+	_, err := service.CreateCampaign(campaign, "admin123")
+
+	assert.NoError(t, err, "Expected no error")
+	entries := auditEntries(mockRepo)
+	if assert.Len(t, entries, 1, "Expected one audit entry") {
+		assert.Equal(t, model.AuditCreate, entries[0].Action)
+		assert.Equal(t, "campaign123", entries[0].CampaignID)
+		assert.Nil(t, entries[0].Before, "Expected nothing before a creation")
+		assert.Equal(t, "Summer", entries[0].After["name"])
+		assert.NotContains(t, entries[0].After, "budget", "Expected unset fields to be left out")
+	}
+}
+
+func TestService_GenerateVouchers_RecordsIssued(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	campaign := &model.Campaign{Id: "campaign123", MaxUsers: 100, Issued: 40, EndDate: time.Now().Add(72 * time.Hour)}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(campaign, nil)
+	mockRepo.On("ClaimIssued", "campaign123", 10).Return(nil)
+	mockVoucherRepo.On("CreateVouchers", mock.AnythingOfType("[]model.Voucher")).Return(nil, nil)
+
+	_, err := service.GenerateVouchers("campaign123", 10, nil, "marketer1")
+
+	assert.NoError(t, err, "Expected no error")
+	entries := auditEntries(mockRepo)
+	if assert.Len(t, entries, 1, "Expected one audit entry") {
+		assert.Equal(t, model.AuditGenerateVouchers, entries[0].Action)
+		assert.Equal(t, "marketer1", entries[0].Actor)
+		assert.Equal(t, map[string]interface{}{"issued": 40}, entries[0].Before)
+		assert.Equal(t, map[string]interface{}{"issued": 50}, entries[0].After)
+	}
+}
+
+func TestService_GetHistory(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockVoucherRepo := new(voucher.MockRepository)
+	service := setupService(mockRepo, mockVoucherRepo)
+
+	history := []model.AuditEntry{{CampaignID: "campaign123", Actor: "admin123", Action: model.AuditUpdate}}
+	mockRepo.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123"}, nil)
+	mockRepo.On("ListAuditEntries", "campaign123").Return(history, nil)
+
+	entries, err := service.GetHistory("campaign123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, history, entries)
+}
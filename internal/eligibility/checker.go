@@ -0,0 +1,87 @@
+package eligibility
+
+import (
+	"time"
+	"trinity/internal/model"
+	"trinity/pkg/logger"
+)
+
+// CampaignSource looks up the campaign whose rules apply
+type CampaignSource interface {
+	GetCampaignByID(id string) (*model.Campaign, error)
+}
+
+// PurchaseHistory counts a user's completed purchases
+type PurchaseHistory interface {
+	CountPurchasesByUser(userID string) (int64, error)
+}
+
+// Checker evaluates a campaign's eligibility rules for a user and order
+type Checker interface {
+	Check(campaignID string, user *model.User, order *Order) error
+}
+
+// checker implements Checker interface
+type checker struct {
+	campaigns CampaignSource
+	purchases PurchaseHistory
+	logger    logger.Logger
+}
+
+// NewChecker creates a new eligibility checker
+func NewChecker(campaigns CampaignSource, purchases PurchaseHistory) Checker {
+	return &checker{
+		campaigns: campaigns,
+		purchases: purchases,
+		logger:    logger.NewLogger("eligibilityChecker"),
+	}
+}
+
+// Check returns a *RuleError if the user or order fails one of the
+// campaign's rules or its condition. Conditions describe the purchase, so
+// they are only evaluated when there is an order. The purchase history is
+// only read when a rule or condition needs it.
+func (c *checker) Check(campaignID string, user *model.User, order *Order) error {
+	campaign, err := c.campaigns.GetCampaignByID(campaignID)
+	if err != nil {
+		c.logger.Errorf("Failed to get campaign %s: %v", campaignID, err)
+		return err
+	}
+
+	hasCondition := campaign.Condition != "" && order != nil
+	if campaign.Eligibility == nil && !hasCondition {
+		return nil
+	}
+
+	facts := Facts{User: user, Order: order}
+	if hasCondition || campaign.Eligibility.FirstPurchaseOnly {
+		if facts.PurchaseCount, err = c.purchases.CountPurchasesByUser(user.Id); err != nil {
+			c.logger.Errorf("Failed to count purchases of %s: %v", user.Id, err)
+			return err
+		}
+	}
+
+	if campaign.Eligibility != nil {
+		if err := Evaluate(*campaign.Eligibility, facts); err != nil {
+			return err
+		}
+	}
+	if hasCondition {
+		return c.checkCondition(campaign, facts)
+	}
+	return nil
+}
+
+// checkCondition evaluates the campaign's condition. Conditions that fail
+// to run are treated as not met, so a faulty condition never grants a discount.
+func (c *checker) checkCondition(campaign *model.Campaign, facts Facts) error {
+	met, err := EvaluateCondition(campaign.Condition, facts, time.Now())
+	if err != nil {
+		c.logger.Errorf("Failed to evaluate condition of campaign %s: %v", campaign.Id, err)
+		return &RuleError{Rule: RuleCondition, Reason: "condition could not be evaluated"}
+	}
+	if !met {
+		return &RuleError{Rule: RuleCondition, Reason: "campaign condition not met"}
+	}
+	return nil
+}
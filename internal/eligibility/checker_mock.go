@@ -0,0 +1,17 @@
+package eligibility
+
+import (
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockChecker is a mock implementation of the Checker interface
+type MockChecker struct {
+	mock.Mock
+}
+
+func (m *MockChecker) Check(campaignID string, user *model.User, order *Order) error {
+	args := m.Called(campaignID, user, order)
+	return args.Error(0)
+}
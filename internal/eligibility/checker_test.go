@@ -0,0 +1,71 @@
+package eligibility
+
+import (
+	"testing"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockCampaigns struct {
+	mock.Mock
+}
+
+func (m *mockCampaigns) GetCampaignByID(id string) (*model.Campaign, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Campaign), args.Error(1)
+}
+
+type mockPurchases struct {
+	mock.Mock
+}
+
+func (m *mockPurchases) CountPurchasesByUser(userID string) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestChecker_NoRules(t *testing.T) {
+	campaigns, purchases := new(mockCampaigns), new(mockPurchases)
+	checker := NewChecker(campaigns, purchases)
+
+	campaigns.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123"}, nil)
+
+	assert.NoError(t, checker.Check("campaign123", &model.User{Id: "user1"}, nil))
+	purchases.AssertNotCalled(t, "CountPurchasesByUser", mock.Anything)
+}
+
+func TestChecker_FirstPurchaseOnly(t *testing.T) {
+	campaigns, purchases := new(mockCampaigns), new(mockPurchases)
+	checker := NewChecker(campaigns, purchases)
+
+	rules := &model.EligibilityRules{FirstPurchaseOnly: true}
+	campaigns.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123", Eligibility: rules}, nil)
+	purchases.On("CountPurchasesByUser", "user1").Return(int64(0), nil)
+	purchases.On("CountPurchasesByUser", "user2").Return(int64(2), nil)
+
+	assert.NoError(t, checker.Check("campaign123", &model.User{Id: "user1"}, nil), "New customers should be eligible")
+
+	err := checker.Check("campaign123", &model.User{Id: "user2"}, nil)
+	var ruleErr *RuleError
+	assert.ErrorAs(t, err, &ruleErr, "Returning customers should be refused")
+	assert.Equal(t, RuleFirstPurchaseOnly, ruleErr.Rule)
+}
+
+func TestChecker_ConditionOnlyWithOrder(t *testing.T) {
+	campaigns, purchases := new(mockCampaigns), new(mockPurchases)
+	checker := NewChecker(campaigns, purchases)
+
+	campaigns.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123", Condition: `plan == "gold"`}, nil)
+	purchases.On("CountPurchasesByUser", "user1").Return(int64(0), nil)
+	user := &model.User{Id: "user1"}
+
+	assert.NoError(t, checker.Check("campaign123", user, nil), "Conditions should wait for an order")
+	assert.NoError(t, checker.Check("campaign123", user, &Order{Plan: model.PlanGold, Amount: 200}))
+
+	err := checker.Check("campaign123", user, &Order{Plan: model.PlanSilver, Amount: 100})
+	var ruleErr *RuleError
+	assert.ErrorAs(t, err, &ruleErr, "Orders failing the condition should be refused")
+	assert.Equal(t, RuleCondition, ruleErr.Rule)
+}
@@ -0,0 +1,123 @@
+package eligibility
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/file"
+	"github.com/expr-lang/expr/vm"
+)
+
+// MaxConditionLength bounds the source of a campaign condition
+const MaxConditionLength = 2000
+
+// ErrInvalidCondition is returned for conditions that do not compile
+var ErrInvalidCondition = errors.New("invalid campaign condition")
+
+// ConditionError reports where a condition failed to compile. Line and
+// Column are 1-based.
+type ConditionError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ConditionError) Error() string {
+	return fmt.Sprintf("%s: line %d, column %d: %s", ErrInvalidCondition, e.Line, e.Column, e.Message)
+}
+
+func (e *ConditionError) Unwrap() error {
+	return ErrInvalidCondition
+}
+
+// ConditionEnv is everything a condition can refer to, for example
+// plan == "gold" && user.purchases == 0 && now.weekday in [6, 7]
+type ConditionEnv struct {
+	Plan   string        `expr:"plan"`
+	Amount float64       `expr:"amount"`
+	User   ConditionUser `expr:"user"`
+	Now    ConditionTime `expr:"now"`
+}
+
+// ConditionUser describes the user in a condition
+type ConditionUser struct {
+	ID           string    `expr:"id"`
+	Purchases    int64     `expr:"purchases"`
+	Locale       string    `expr:"locale"`
+	Country      string    `expr:"country"`
+	RegisteredAt time.Time `expr:"registered_at"`
+}
+
+// ConditionTime describes the evaluation time in a condition; weekday runs
+// from 1 for Monday to 7 for Sunday
+type ConditionTime struct {
+	Weekday int       `expr:"weekday"`
+	Hour    int       `expr:"hour"`
+	Time    time.Time `expr:"time"`
+}
+
+// programs caches compiled conditions by source
+var programs sync.Map
+
+// CompileCondition type-checks a condition against ConditionEnv. It must
+// evaluate to a boolean; compile errors are returned as *ConditionError.
+func CompileCondition(source string) (*vm.Program, error) {
+	if cached, ok := programs.Load(source); ok {
+		return cached.(*vm.Program), nil
+	}
+	if len(source) > MaxConditionLength {
+		return nil, &ConditionError{Line: 1, Column: 1, Message: fmt.Sprintf("condition is longer than %d characters", MaxConditionLength)}
+	}
+
+	program, err := expr.Compile(source, expr.Env(ConditionEnv{}), expr.AsBool())
+	if err != nil {
+		var fileErr *file.Error
+		if errors.As(err, &fileErr) {
+			return nil, &ConditionError{Line: fileErr.Line, Column: fileErr.Column + 1, Message: fileErr.Message}
+		}
+		return nil, &ConditionError{Line: 1, Column: 1, Message: err.Error()}
+	}
+
+	programs.Store(source, program)
+	return program, nil
+}
+
+// EvaluateCondition runs a condition against the facts at the given time
+func EvaluateCondition(source string, facts Facts, now time.Time) (bool, error) {
+	program, err := CompileCondition(source)
+	if err != nil {
+		return false, err
+	}
+
+	env := ConditionEnv{
+		User: ConditionUser{
+			ID:           facts.User.Id,
+			Purchases:    facts.PurchaseCount,
+			Locale:       facts.User.Locale,
+			Country:      facts.User.Country,
+			RegisteredAt: facts.User.CreatedAt,
+		},
+		Now: ConditionTime{Weekday: isoWeekday(now), Hour: now.Hour(), Time: now},
+	}
+	if facts.Order != nil {
+		env.Plan = string(facts.Order.Plan)
+		env.Amount = facts.Order.Amount
+	}
+
+	result, err := expr.Run(program, env)
+	if err != nil {
+		return false, err
+	}
+	return result.(bool), nil
+}
+
+// isoWeekday numbers the days of the week from Monday as 1 to Sunday as 7
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
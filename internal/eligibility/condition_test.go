@@ -0,0 +1,55 @@
+package eligibility
+
+import (
+	"testing"
+	"time"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileCondition_ReportsPosition(t *testing.T) {
+	_, err := CompileCondition("plan == \"gold\" &&\nuser.purchasez == 0")
+
+	var conditionErr *ConditionError
+	require.ErrorAs(t, err, &conditionErr)
+	assert.ErrorIs(t, err, ErrInvalidCondition)
+	assert.Equal(t, 2, conditionErr.Line, "Unexpected error line")
+	assert.Equal(t, 6, conditionErr.Column, "Unexpected error column")
+}
+
+func TestCompileCondition_RequiresBoolean(t *testing.T) {
+	_, err := CompileCondition("amount * 2")
+
+	assert.ErrorIs(t, err, ErrInvalidCondition, "Non-boolean conditions should be refused")
+}
+
+func TestEvaluateCondition(t *testing.T) {
+	condition := `plan == "gold" && user.purchases == 0 && now.weekday in [6, 7]`
+	saturday := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	monday := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	user := &model.User{Id: "user1"}
+	gold := &Order{Plan: model.PlanGold, Amount: 200}
+
+	tests := []struct {
+		name  string
+		facts Facts
+		now   time.Time
+		met   bool
+	}{
+		{"weekend first gold purchase", Facts{User: user, Order: gold}, saturday, true},
+		{"weekday", Facts{User: user, Order: gold}, monday, false},
+		{"returning customer", Facts{User: user, Order: gold, PurchaseCount: 1}, saturday, false},
+		{"silver plan", Facts{User: user, Order: &Order{Plan: model.PlanSilver, Amount: 100}}, saturday, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			met, err := EvaluateCondition(condition, tt.facts, tt.now)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.met, met)
+		})
+	}
+}
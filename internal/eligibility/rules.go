@@ -0,0 +1,120 @@
+package eligibility
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"trinity/internal/model"
+)
+
+// Rule names one eligibility rule of a campaign
+type Rule string
+
+const (
+	RulePlans             Rule = "plans"
+	RuleFirstPurchaseOnly Rule = "first_purchase_only"
+	RuleRegisteredAfter   Rule = "registered_after"
+	RuleRegisteredBefore  Rule = "registered_before"
+	RuleLocales           Rule = "locales"
+	RuleCountries         Rule = "countries"
+	RuleMinOrderAmount    Rule = "min_order_amount"
+	RuleCondition         Rule = "condition"
+)
+
+var (
+	// ErrNotEligible is returned when a user or order fails a campaign rule
+	ErrNotEligible = errors.New("not eligible for this campaign")
+	// ErrInvalidRules is returned for rule sets that can never be evaluated sensibly
+	ErrInvalidRules = errors.New("invalid eligibility rules")
+)
+
+// RuleError reports the rule a user or order failed
+type RuleError struct {
+	Rule   Rule
+	Reason string
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrNotEligible, e.Rule, e.Reason)
+}
+
+func (e *RuleError) Unwrap() error {
+	return ErrNotEligible
+}
+
+// Order describes the purchase a voucher is applied to
+type Order struct {
+	Plan   model.SubscriptionPlan
+	Amount float64
+}
+
+// Facts are what the rules are evaluated against
+type Facts struct {
+	User *model.User
+	// PurchaseCount is the number of purchases the user completed before
+	PurchaseCount int64
+	// Order is nil when a voucher is redeemed or viewed outside a purchase;
+	// rules about the order are then skipped
+	Order *Order
+}
+
+// Evaluate checks the facts against every rule and returns a *RuleError for
+// the first rule that fails
+func Evaluate(rules model.EligibilityRules, facts Facts) error {
+	user := facts.User
+
+	if rules.FirstPurchaseOnly && facts.PurchaseCount > 0 {
+		return &RuleError{Rule: RuleFirstPurchaseOnly, Reason: "only valid on the first purchase"}
+	}
+	if rules.RegisteredAfter != nil && !user.CreatedAt.After(*rules.RegisteredAfter) {
+		return &RuleError{Rule: RuleRegisteredAfter, Reason: fmt.Sprintf("only for users registered after %s", rules.RegisteredAfter.Format(time.DateOnly))}
+	}
+	if rules.RegisteredBefore != nil && !user.CreatedAt.Before(*rules.RegisteredBefore) {
+		return &RuleError{Rule: RuleRegisteredBefore, Reason: fmt.Sprintf("only for users registered before %s", rules.RegisteredBefore.Format(time.DateOnly))}
+	}
+	if len(rules.Locales) > 0 && !slices.ContainsFunc(rules.Locales, func(locale string) bool { return localeMatches(locale, user.Locale) }) {
+		return &RuleError{Rule: RuleLocales, Reason: fmt.Sprintf("only for locales %s", strings.Join(rules.Locales, ", "))}
+	}
+	if len(rules.Countries) > 0 && !slices.ContainsFunc(rules.Countries, func(country string) bool { return strings.EqualFold(country, user.Country) }) {
+		return &RuleError{Rule: RuleCountries, Reason: fmt.Sprintf("only for countries %s", strings.Join(rules.Countries, ", "))}
+	}
+
+	order := facts.Order
+	if order == nil {
+		return nil
+	}
+	if len(rules.Plans) > 0 && !slices.Contains(rules.Plans, order.Plan) {
+		return &RuleError{Rule: RulePlans, Reason: fmt.Sprintf("not valid for the %s plan", order.Plan)}
+	}
+	if order.Amount < rules.MinOrderAmount {
+		return &RuleError{Rule: RuleMinOrderAmount, Reason: fmt.Sprintf("requires an order of at least %.2f", rules.MinOrderAmount)}
+	}
+	return nil
+}
+
+// Validate checks that a rule set is well formed
+func Validate(rules model.EligibilityRules) error {
+	for _, plan := range rules.Plans {
+		if !plan.IsValid() {
+			return fmt.Errorf("%w: unknown plan %q", ErrInvalidRules, plan)
+		}
+	}
+	if rules.RegisteredAfter != nil && rules.RegisteredBefore != nil && !rules.RegisteredAfter.Before(*rules.RegisteredBefore) {
+		return fmt.Errorf("%w: registered_after must be before registered_before", ErrInvalidRules)
+	}
+	if rules.MinOrderAmount < 0 {
+		return fmt.Errorf("%w: min_order_amount must not be negative", ErrInvalidRules)
+	}
+	return nil
+}
+
+// localeMatches reports whether a user's locale satisfies an allowed
+// locale, either exactly or as a regional variant of its language
+func localeMatches(allowed, locale string) bool {
+	if strings.EqualFold(allowed, locale) {
+		return true
+	}
+	return len(locale) > len(allowed) && locale[len(allowed)] == '-' && strings.EqualFold(allowed, locale[:len(allowed)])
+}
@@ -0,0 +1,61 @@
+package eligibility
+
+import (
+	"testing"
+	"time"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	launch := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	returning := &model.User{Id: "user1", Locale: "en-GB", Country: "GB", CreatedAt: launch.AddDate(-1, 0, 0)}
+	newcomer := &model.User{Id: "user2", Locale: "de", Country: "DE", CreatedAt: launch.AddDate(0, 1, 0)}
+	gold := &Order{Plan: model.PlanGold, Amount: 200}
+
+	tests := []struct {
+		name  string
+		rules model.EligibilityRules
+		facts Facts
+		rule  Rule
+	}{
+		{"no rules", model.EligibilityRules{}, Facts{User: returning, Order: gold}, ""},
+		{"allowed plan", model.EligibilityRules{Plans: []model.SubscriptionPlan{model.PlanGold}}, Facts{User: returning, Order: gold}, ""},
+		{"other plan", model.EligibilityRules{Plans: []model.SubscriptionPlan{model.PlanSilver}}, Facts{User: returning, Order: gold}, RulePlans},
+		{"order rules skipped without order", model.EligibilityRules{Plans: []model.SubscriptionPlan{model.PlanSilver}, MinOrderAmount: 500}, Facts{User: returning}, ""},
+		{"first purchase", model.EligibilityRules{FirstPurchaseOnly: true}, Facts{User: newcomer}, ""},
+		{"returning customer", model.EligibilityRules{FirstPurchaseOnly: true}, Facts{User: returning, PurchaseCount: 3}, RuleFirstPurchaseOnly},
+		{"registered after", model.EligibilityRules{RegisteredAfter: &launch}, Facts{User: returning}, RuleRegisteredAfter},
+		{"registered before", model.EligibilityRules{RegisteredBefore: &launch}, Facts{User: newcomer}, RuleRegisteredBefore},
+		{"locale by language", model.EligibilityRules{Locales: []string{"en"}}, Facts{User: returning}, ""},
+		{"other locale", model.EligibilityRules{Locales: []string{"en"}}, Facts{User: newcomer}, RuleLocales},
+		{"country ignores case", model.EligibilityRules{Countries: []string{"gb"}}, Facts{User: returning}, ""},
+		{"other country", model.EligibilityRules{Countries: []string{"GB"}}, Facts{User: newcomer}, RuleCountries},
+		{"minimum order met", model.EligibilityRules{MinOrderAmount: 200}, Facts{User: returning, Order: gold}, ""},
+		{"minimum order missed", model.EligibilityRules{MinOrderAmount: 250}, Facts{User: returning, Order: gold}, RuleMinOrderAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Evaluate(tt.rules, tt.facts)
+			if tt.rule == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var ruleErr *RuleError
+			assert.ErrorAs(t, err, &ruleErr)
+			assert.ErrorIs(t, err, ErrNotEligible)
+			assert.Equal(t, tt.rule, ruleErr.Rule, "Unexpected failed rule")
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	early, late := time.Now(), time.Now().Add(time.Hour)
+
+	assert.NoError(t, Validate(model.EligibilityRules{Plans: []model.SubscriptionPlan{model.PlanGold}, RegisteredAfter: &early, RegisteredBefore: &late}))
+	assert.ErrorIs(t, Validate(model.EligibilityRules{Plans: []model.SubscriptionPlan{"platinum"}}), ErrInvalidRules)
+	assert.ErrorIs(t, Validate(model.EligibilityRules{RegisteredAfter: &late, RegisteredBefore: &early}), ErrInvalidRules)
+	assert.ErrorIs(t, Validate(model.EligibilityRules{MinOrderAmount: -1}), ErrInvalidRules)
+}
@@ -26,11 +26,64 @@ func NewMongoDB(uri string) (*mongo.Database, error) {
 }
 
 func SetupIndexes(db *mongo.Database) error {
-	voucherCollection := db.Collection("vouchers")
-	indexModel := mongo.IndexModel{
-		Keys:    bson.D{{Key: "code", Value: 1}},
-		Options: options.Index().SetUnique(true),
+	indexes := []struct {
+		collection string
+		model      mongo.IndexModel
+	}{
+		{"vouchers", mongo.IndexModel{
+			Keys:    bson.D{{Key: "code", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		{"users", mongo.IndexModel{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		{"voucher_user_redemptions", mongo.IndexModel{
+			Keys:    bson.D{{Key: "voucher_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		{"redemptions", mongo.IndexModel{
+			Keys: bson.D{{Key: "voucher_id", Value: 1}, {Key: "redeemed_at", Value: 1}},
+		}},
+		{"purchases", mongo.IndexModel{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		}},
+		{"vouchers", mongo.IndexModel{
+			Keys:    bson.D{{Key: "job_id", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"job_id": bson.M{"$exists": true}}),
+		}},
+		{"campaigns", mongo.IndexModel{
+			Keys:    bson.D{{Key: "automatic", Value: 1}, {Key: "end_date", Value: 1}},
+			Options: options.Index().SetPartialFilterExpression(bson.M{"automatic": true}),
+		}},
+		{"campaign_templates", mongo.IndexModel{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		{"campaign_exposures", mongo.IndexModel{
+			Keys:    bson.D{{Key: "campaign_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		{"purchases", mongo.IndexModel{
+			Keys: bson.D{{Key: "discounts.campaign_id", Value: 1}},
+		}},
+		{"redemptions", mongo.IndexModel{
+			Keys: bson.D{{Key: "campaign_id", Value: 1}, {Key: "variant", Value: 1}},
+		}},
+		{"campaign_history", mongo.IndexModel{
+			Keys: bson.D{{Key: "campaign_id", Value: 1}, {Key: "at", Value: -1}},
+		}},
+		{"api_keys", mongo.IndexModel{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
 	}
-	_, err := voucherCollection.Indexes().CreateOne(context.Background(), indexModel)
-	return err
+
+	for _, index := range indexes {
+		_, err := db.Collection(index.collection).Indexes().CreateOne(context.Background(), index.model)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
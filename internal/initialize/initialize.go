@@ -2,10 +2,15 @@ package initialize
 
 import (
 	"trinity/config"
+	"trinity/internal/apikey"
+	"trinity/internal/auth"
 	"trinity/internal/campaign"
+	"trinity/internal/eligibility"
 	"trinity/internal/infra/database"
+	"trinity/internal/job"
 	"trinity/internal/purchase"
 	"trinity/internal/subscription"
+	"trinity/internal/user"
 	"trinity/internal/voucher"
 	"trinity/pkg/logger"
 
@@ -16,9 +21,13 @@ var log logger.Logger
 
 type App struct {
 	DB              *mongo.Database
+	Authenticator   *auth.Authenticator
 	CampaignHandler *campaign.Handler
 	VoucherHandler  *voucher.Handler
 	PurchaseHandler *purchase.Handler
+	UserHandler     *user.Handler
+	APIKeyHandler   *apikey.Handler
+	JobHandler      *job.Handler
 }
 
 // Initialize sets up the application dependencies
@@ -38,27 +47,69 @@ func Initialize(cfg *config.Config) (*App, error) {
 		return nil, err
 	}
 
+	authenticator, err := auth.NewAuthenticator(cfg.JWTSecret, cfg.JWKSPath, cfg.JWTIssuer, cfg.JWTTokenTTL)
+	if err != nil {
+		log.Errorf("failed to set up authentication: %v", err)
+		return nil, err
+	}
+
 	// Repositories
 	campaignRepo := campaign.NewRepository(db)
 	voucherRepo := voucher.NewRepository(db)
 	subscriptionRepo := subscription.NewRepository(db)
 	purchaseRepo := purchase.NewRepository(db)
+	userRepo := user.NewRepository(db)
+	apiKeyRepo := apikey.NewRepository(db)
+	jobRepo := job.NewRepository(db)
+
+	// Flag the vouchers whose codes predate check characters
+	if _, err := voucherRepo.MarkLegacyVouchers(); err != nil {
+		log.Errorf("failed to mark legacy vouchers: %v", err)
+		return nil, err
+	}
 
 	// Services
-	campaignService := campaign.NewService(campaignRepo, voucherRepo)
-	voucherService := voucher.NewService(voucherRepo)
-	purchaseService := purchase.NewService(purchaseRepo, voucherRepo, subscriptionRepo)
+	eligibilityChecker := eligibility.NewChecker(campaignRepo, purchaseRepo)
+	campaignService := campaign.NewService(campaignRepo, voucherRepo, jobRepo)
+	voucherService := voucher.NewService(voucherRepo, userRepo, cfg.VoucherReservationTTL, eligibilityChecker, campaignRepo)
+	purchaseService := purchase.NewService(purchaseRepo, voucherService, subscriptionRepo, userRepo, campaignRepo, eligibilityChecker)
+	userService := user.NewService(userRepo)
+	apiKeyService := apikey.NewService(apiKeyRepo)
+	jobService := job.NewService(jobRepo)
+	authenticator.SetKeyVerifier(apiKeyService)
+	authenticator.SetRoleStore(userService)
+
+	// Seed the first admin, who can then assign roles to other users
+	if cfg.AdminEmail != "" {
+		if err := userService.EnsureAdmin(cfg.AdminEmail, cfg.AdminPassword); err != nil {
+			log.Errorf("failed to set up the admin: %v", err)
+			return nil, err
+		}
+	}
 
 	// Handlers
 	campaignHandler := campaign.NewHandler(campaignService)
 	voucherHandler := voucher.NewHandler(voucherService)
 	purchaseHandler := purchase.NewHandler(purchaseService)
+	userHandler := user.NewHandler(userService, authenticator)
+	apiKeyHandler := apikey.NewHandler(apiKeyService)
+	jobHandler := job.NewHandler(jobService)
+
+	// Pick up voucher generation jobs interrupted by the last shutdown
+	if err := campaignService.ResumeVoucherJobs(); err != nil {
+		log.Errorf("failed to resume jobs: %v", err)
+		return nil, err
+	}
 
 	app := &App{
 		DB:              db,
+		Authenticator:   authenticator,
 		CampaignHandler: campaignHandler,
 		VoucherHandler:  voucherHandler,
 		PurchaseHandler: purchaseHandler,
+		UserHandler:     userHandler,
+		APIKeyHandler:   apiKeyHandler,
+		JobHandler:      jobHandler,
 	}
 
 	return app, nil
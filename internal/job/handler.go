@@ -0,0 +1,67 @@
+package job
+
+import (
+	"errors"
+	"net/http"
+	"trinity/internal/auth"
+	"trinity/pkg/logger"
+	"trinity/pkg/reason"
+	"trinity/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler handles background job requests
+type Handler struct {
+	service Service
+	logger  logger.Logger
+}
+
+// NewHandler creates a new job handler
+func NewHandler(service Service) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger.NewLogger("jobHandler"),
+	}
+}
+
+// RegisterRoutes registers the job routes with the Gin router
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/:id", h.GetJob)
+}
+
+// GetJob godoc
+// @Summary Get a background job
+// @Description Report the status, progress and errors of a background job
+// @Tags Job
+// @Produce  json
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Param id path string true "Job ID"
+// @Success 200 {object} model.Job
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /jobs/{id} [get]
+func (h *Handler) GetJob(c *gin.Context) {
+	job, err := h.service.GetJob(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ErrJobNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.JobNotFound.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	// Partner keys may only follow jobs for the campaigns they are scoped to
+	if !auth.CampaignAllowed(c, job.CampaignID) {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: reason.Forbidden.Message()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
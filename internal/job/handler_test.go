@@ -0,0 +1,55 @@
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"trinity/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupRouter initializes the Gin engine with the job routes
+func setupRouter(handler *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	handler.RegisterRoutes(r.Group("/jobs"))
+	return r
+}
+
+func performRequest(r http.Handler, method, path string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_GetJob_Success(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService))
+
+	job := &model.Job{Id: "job123", CampaignID: "campaign123", Status: model.JobFailed, Total: 100, Generated: 40, Errors: []string{"too many voucher code collisions"}}
+	mockService.On("GetJob", "job123").Return(job, nil)
+
+	w := performRequest(router, "GET", "/jobs/job123")
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status code 200")
+	var response model.Job
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	assert.Equal(t, 40, response.Generated, "Expected the progress to be reported")
+	assert.Equal(t, job.Errors, response.Errors, "Expected the errors to be reported")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_GetJob_NotFound(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService))
+
+	mockService.On("GetJob", "missing").Return(nil, ErrJobNotFound)
+
+	w := performRequest(router, "GET", "/jobs/missing")
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "Expected status code 404")
+}
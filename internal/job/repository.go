@@ -0,0 +1,160 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"trinity/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrJobNotFound is returned when no job matches the lookup
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobClaimed is returned when another worker holds the job or it has
+	// already finished
+	ErrJobClaimed = errors.New("job claimed by another worker")
+)
+
+// Repository defines background job data access methods
+type Repository interface {
+	CreateJob(job *model.Job) error
+	GetJobByID(id string) (*model.Job, error)
+	ListUnfinishedJobs(jobType model.JobType) ([]model.Job, error)
+	ClaimJob(id string, owner string, leaseUntil time.Time) error
+	SetProgress(id string, owner string, generated int, leaseUntil time.Time) error
+	FinishJob(id string, owner string, status model.JobStatus, jobErr string) error
+}
+
+// repository implements Repository interface
+type repository struct {
+	collection *mongo.Collection
+}
+
+// NewRepository creates a new job repository
+func NewRepository(db *mongo.Database) Repository {
+	return &repository{
+		collection: db.Collection("jobs"),
+	}
+}
+
+// CreateJob inserts a new job into the database
+func (r *repository) CreateJob(job *model.Job) error {
+	result, err := r.collection.InsertOne(context.Background(), job)
+	if err != nil {
+		return err
+	}
+
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return fmt.Errorf("failed to convert InsertedID to ObjectID")
+	}
+
+	job.Id = oid.Hex()
+	return nil
+}
+
+// GetJobByID retrieves a job by its ID
+func (r *repository) GetJobByID(id string) (*model.Job, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrJobNotFound
+	}
+
+	var job model.Job
+	err = r.collection.FindOne(context.Background(), bson.M{"_id": objID}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListUnfinishedJobs retrieves jobs of a type that are pending or running
+func (r *repository) ListUnfinishedJobs(jobType model.JobType) ([]model.Job, error) {
+	filter := bson.M{
+		"type":   jobType,
+		"status": bson.M{"$in": []model.JobStatus{model.JobPending, model.JobRunning}},
+	}
+
+	cursor, err := r.collection.Find(context.Background(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var jobs []model.Job
+	if err := cursor.All(context.Background(), &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ClaimJob marks an unfinished job as running under owner until
+// leaseUntil. Jobs held by another owner whose lease has not run out are
+// left to it and ErrJobClaimed is returned.
+func (r *repository) ClaimJob(id string, owner string, leaseUntil time.Time) error {
+	now := time.Now()
+	filter := bson.M{
+		"status": bson.M{"$in": []model.JobStatus{model.JobPending, model.JobRunning}},
+		"$or": bson.A{
+			bson.M{"owner": bson.M{"$in": bson.A{nil, owner}}},
+			bson.M{"lease_until": bson.M{"$lt": now}},
+		},
+	}
+	return r.updateOwned(id, filter, bson.M{"$set": bson.M{
+		"status":      model.JobRunning,
+		"owner":       owner,
+		"lease_until": leaseUntil,
+		"updated_at":  now,
+	}})
+}
+
+// SetProgress records the number of items a job has generated and extends
+// the owner's lease. It returns ErrJobClaimed if the job has changed owner.
+func (r *repository) SetProgress(id string, owner string, generated int, leaseUntil time.Time) error {
+	return r.updateOwned(id, bson.M{"owner": owner}, bson.M{"$set": bson.M{
+		"generated":   generated,
+		"lease_until": leaseUntil,
+		"updated_at":  time.Now(),
+	}})
+}
+
+// FinishJob marks a job as completed or failed, recording the error if any.
+// It returns ErrJobClaimed if the job has changed owner.
+func (r *repository) FinishJob(id string, owner string, status model.JobStatus, jobErr string) error {
+	now := time.Now()
+	update := bson.M{"$set": bson.M{"status": status, "updated_at": now, "completed_at": now}}
+	if jobErr != "" {
+		update["$push"] = bson.M{"errors": jobErr}
+	}
+	return r.updateOwned(id, bson.M{"owner": owner}, update)
+}
+
+// updateOwned updates the job if it also matches filter, returning
+// ErrJobClaimed if it does not
+func (r *repository) updateOwned(id string, filter bson.M, update bson.M) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrJobNotFound
+	}
+	filter["_id"] = objID
+
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrJobClaimed
+	}
+
+	return nil
+}
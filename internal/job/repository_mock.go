@@ -0,0 +1,50 @@
+package job
+
+import (
+	"time"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateJob(job *model.Job) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetJobByID(id string) (*model.Job, error) {
+	args := m.Called(id)
+	job := args.Get(0)
+	if job == nil {
+		return nil, args.Error(1)
+	}
+	return job.(*model.Job), args.Error(1)
+}
+
+func (m *MockRepository) ListUnfinishedJobs(jobType model.JobType) ([]model.Job, error) {
+	args := m.Called(jobType)
+	if jobs, ok := args.Get(0).([]model.Job); ok {
+		return jobs, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) ClaimJob(id string, owner string, leaseUntil time.Time) error {
+	args := m.Called(id, owner, leaseUntil)
+	return args.Error(0)
+}
+
+func (m *MockRepository) SetProgress(id string, owner string, generated int, leaseUntil time.Time) error {
+	args := m.Called(id, owner, generated, leaseUntil)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FinishJob(id string, owner string, status model.JobStatus, jobErr string) error {
+	args := m.Called(id, owner, status, jobErr)
+	return args.Error(0)
+}
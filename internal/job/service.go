@@ -0,0 +1,37 @@
+package job
+
+import (
+	"trinity/internal/model"
+	"trinity/pkg/logger"
+)
+
+// Service defines background job business logic methods
+type Service interface {
+	GetJob(id string) (*model.Job, error)
+}
+
+// service implements Service interface
+type service struct {
+	repo   Repository
+	logger logger.Logger
+}
+
+// NewService creates a new job service
+func NewService(repo Repository) Service {
+	return &service{
+		repo:   repo,
+		logger: logger.NewLogger("jobService"),
+	}
+}
+
+// GetJob retrieves a job and its progress
+func (s *service) GetJob(id string) (*model.Job, error) {
+	job, err := s.repo.GetJobByID(id)
+	if err != nil {
+		if err != ErrJobNotFound {
+			s.logger.Errorf("Failed to get job: %v", err)
+		}
+		return nil, err
+	}
+	return job, nil
+}
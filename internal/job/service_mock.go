@@ -0,0 +1,21 @@
+package job
+
+import (
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the Service interface
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) GetJob(id string) (*model.Job, error) {
+	args := m.Called(id)
+	job := args.Get(0)
+	if job == nil {
+		return nil, args.Error(1)
+	}
+	return job.(*model.Job), args.Error(1)
+}
@@ -0,0 +1,54 @@
+package job
+
+import (
+	"errors"
+	"testing"
+	"trinity/internal/model"
+	"trinity/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupService(mockRepo *MockRepository) *service {
+	return &service{
+		repo:   mockRepo,
+		logger: logger.NewLogger("jobService"),
+	}
+}
+
+func TestService_GetJob_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := setupService(mockRepo)
+
+	job := &model.Job{Id: "job123", Status: model.JobRunning, Total: 100, Generated: 40}
+	mockRepo.On("GetJobByID", "job123").Return(job, nil)
+
+	result, err := service.GetJob("job123")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, job, result, "Expected the job to be returned")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetJob_NotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := setupService(mockRepo)
+
+	mockRepo.On("GetJobByID", "missing").Return(nil, ErrJobNotFound)
+
+	result, err := service.GetJob("missing")
+
+	assert.ErrorIs(t, err, ErrJobNotFound, "Expected the lookup to fail")
+	assert.Nil(t, result, "Expected no job to be returned")
+}
+
+func TestService_GetJob_RepositoryError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := setupService(mockRepo)
+
+	mockRepo.On("GetJobByID", "job123").Return(nil, errors.New("database error"))
+
+	_, err := service.GetJob("job123")
+
+	assert.EqualError(t, err, "database error", "Expected the repository error")
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+type APIKey struct {
+	Id          string     `bson:"_id,omitempty" json:"id"`
+	Name        string     `bson:"name" json:"name"`
+	Prefix      string     `bson:"prefix" json:"prefix"`
+	Hash        string     `bson:"hash" json:"-"`
+	CampaignIDs []string   `bson:"campaign_ids" json:"campaign_ids"`
+	Actions     []string   `bson:"actions" json:"actions"`
+	CreatedBy   string     `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	LastUsedAt  *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// Allows reports whether the key is scoped to the action
+func (k *APIKey) Allows(action string) bool {
+	for _, a := range k.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// CoversCampaign reports whether the key is scoped to the campaign
+func (k *APIKey) CoversCampaign(campaignID string) bool {
+	for _, id := range k.CampaignIDs {
+		if id == campaignID {
+			return true
+		}
+	}
+	return false
+}
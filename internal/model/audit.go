@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// AuditAction is the kind of change an audit entry records
+type AuditAction string
+
+const (
+	AuditCreate       AuditAction = "create"
+	AuditUpdate       AuditAction = "update"
+	AuditStatusChange AuditAction = "status_change"
+	// AuditGenerateVouchers covers every way vouchers are issued: generated,
+	// imported or created as a shared code
+	AuditGenerateVouchers AuditAction = "generate_vouchers"
+	AuditRevokeVouchers   AuditAction = "revoke_vouchers"
+)
+
+// ActorSystem is the actor of changes the service makes on its own, such
+// as exhausting a campaign whose budget a purchase used up
+const ActorSystem = "system"
+
+// AuditEntry records one change to a campaign. Before and After hold the
+// changed fields only, keyed by their JSON names.
+type AuditEntry struct {
+	Id         string                 `bson:"_id,omitempty" json:"id"`
+	CampaignID string                 `bson:"campaign_id" json:"campaign_id"`
+	Actor      string                 `bson:"actor" json:"actor"`
+	Action     AuditAction            `bson:"action" json:"action"`
+	Before     map[string]interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After      map[string]interface{} `bson:"after,omitempty" json:"after,omitempty"`
+	// Note describes changes that are not to campaign fields, such as how
+	// many vouchers were revoked and why
+	Note string    `bson:"note,omitempty" json:"note,omitempty"`
+	At   time.Time `bson:"at" json:"at"`
+}
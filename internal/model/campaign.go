@@ -3,12 +3,196 @@ package model
 import "time"
 
 type Campaign struct {
-	Id          string    `bson:"_id,omitempty" json:"id"`
-	Name        string    `bson:"name" json:"name"`
-	Discount    float64   `bson:"discount" json:"discount"`
-	MaxUsers    int       `bson:"max_users" json:"max_users"`
-	UsedUsers   int       `bson:"used_users" json:"used_users"`
-	StartDate   time.Time `bson:"start_date" json:"start_date"`
-	EndDate     time.Time `bson:"end_date" json:"end_date"`
-	Description string    `bson:"description" json:"description"`
+	Id       string  `bson:"_id,omitempty" json:"id"`
+	Name     string  `bson:"name" json:"name"`
+	Discount float64 `bson:"discount" json:"discount"`
+	// MaxUsers caps how often the campaign can be redeemed
+	MaxUsers int `bson:"max_users" json:"max_users"`
+	// MaxIssued caps how many vouchers can be issued; it defaults to
+	// MaxUsers and can exceed it to over-issue codes
+	MaxIssued int `bson:"max_issued,omitempty" json:"max_issued,omitempty"`
+	// Issued counts the vouchers generated, still stored as used_users from
+	// before redemptions were counted on their own
+	Issued   int `bson:"used_users" json:"issued"`
+	Redeemed int `bson:"redeemed" json:"redeemed"`
+	// Budget caps the total discount the campaign gives away; Spent is
+	// what its purchases have been discounted so far
+	Budget      float64        `bson:"budget,omitempty" json:"budget,omitempty"`
+	Spent       float64        `bson:"spent" json:"spent"`
+	Status      CampaignStatus `bson:"status,omitempty" json:"status,omitempty"`
+	StartDate   time.Time      `bson:"start_date" json:"start_date"`
+	EndDate     time.Time      `bson:"end_date" json:"end_date"`
+	Description string         `bson:"description" json:"description"`
+	CodeFormat  *CodeFormat    `bson:"code_format,omitempty" json:"code_format,omitempty"`
+	// Validity makes each voucher expire relative to when it was issued or
+	// first viewed; without it vouchers expire at EndDate
+	Validity *VoucherValidity `bson:"validity,omitempty" json:"validity,omitempty"`
+	// Eligibility restricts which users and orders the campaign's vouchers
+	// apply to; without it they apply to everyone
+	Eligibility *EligibilityRules `bson:"eligibility,omitempty" json:"eligibility,omitempty"`
+	// Condition is an expression over the purchase that must hold for the
+	// campaign to apply, e.g. plan == "gold" && now.weekday in [6, 7]
+	Condition string `bson:"condition,omitempty" json:"condition,omitempty"`
+	// Stacking decides whether the discount combines with other campaigns
+	// on one purchase; without it the campaign is exclusive
+	Stacking *StackingPolicy `bson:"stacking,omitempty" json:"stacking,omitempty"`
+	// Tiers replace Discount for the orders they match, for example a
+	// higher discount on gold or on orders above a price
+	Tiers []DiscountTier `bson:"tiers,omitempty" json:"tiers,omitempty"`
+	// Variants split the campaign's users into an A/B test, each variant
+	// giving its own discount in place of Discount
+	Variants []CampaignVariant `bson:"variants,omitempty" json:"variants,omitempty"`
+	// Automatic campaigns need no code: they apply at checkout to every
+	// eligible order within their dates, each purchase taking one of MaxUsers
+	Automatic bool `bson:"automatic,omitempty" json:"automatic,omitempty"`
+}
+
+// IssueLimit returns how many vouchers the campaign can issue
+func (c *Campaign) IssueLimit() int {
+	if c.MaxIssued > 0 {
+		return c.MaxIssued
+	}
+	return c.MaxUsers
+}
+
+// Remaining returns how many redemptions the campaign has left
+func (c *Campaign) Remaining() int {
+	return max(c.MaxUsers-c.Redeemed, 0)
+}
+
+// StackingMode controls whether a campaign's discount combines with others
+type StackingMode string
+
+const (
+	// StackExclusive discounts apply on their own
+	StackExclusive StackingMode = "exclusive"
+	// StackStackable discounts combine with the other stackable discounts
+	StackStackable StackingMode = "stackable"
+)
+
+// IsValid reports whether the stacking mode is known
+func (m StackingMode) IsValid() bool {
+	return m == StackExclusive || m == StackStackable
+}
+
+// StackingPolicy places a campaign among the discounts of a purchase.
+// Stacked discounts apply in descending Priority, each to the amount left
+// by the ones before it; Priority also decides ties between exclusive ones.
+type StackingPolicy struct {
+	Mode     StackingMode `bson:"mode" json:"mode"`
+	Priority int          `bson:"priority,omitempty" json:"priority,omitempty"`
+}
+
+// IsStackable reports whether the campaign's discount combines with others
+func (c *Campaign) IsStackable() bool {
+	return c.Stacking != nil && c.Stacking.Mode == StackStackable
+}
+
+// Priority returns the campaign's stacking priority
+func (c *Campaign) Priority() int {
+	if c.Stacking == nil {
+		return 0
+	}
+	return c.Stacking.Priority
+}
+
+// Clone returns an active copy of the campaign's settings running from
+// start for as long as the campaign does. Counters, spend and the ID are
+// not copied.
+func (c *Campaign) Clone(start time.Time) *Campaign {
+	return &Campaign{
+		Name:        c.Name,
+		Discount:    c.Discount,
+		MaxUsers:    c.MaxUsers,
+		MaxIssued:   c.MaxIssued,
+		Budget:      c.Budget,
+		Status:      CampaignActive,
+		StartDate:   start,
+		EndDate:     start.Add(c.EndDate.Sub(c.StartDate)),
+		Description: c.Description,
+		CodeFormat:  c.CodeFormat,
+		Validity:    c.Validity,
+		Eligibility: c.Eligibility,
+		Condition:   c.Condition,
+		Stacking:    c.Stacking,
+		Tiers:       c.Tiers,
+		Variants:    c.Variants,
+		Automatic:   c.Automatic,
+	}
+}
+
+// DiscountTier gives its discount to orders of its plan or, without a plan,
+// to orders from MinAmount up to but excluding MaxAmount; a MaxAmount of
+// zero leaves the range open
+type DiscountTier struct {
+	Plan      SubscriptionPlan `bson:"plan,omitempty" json:"plan,omitempty"`
+	MinAmount float64          `bson:"min_amount,omitempty" json:"min_amount,omitempty"`
+	MaxAmount float64          `bson:"max_amount,omitempty" json:"max_amount,omitempty"`
+	Discount  float64          `bson:"discount" json:"discount"`
+}
+
+// CampaignVariant is one arm of a campaign's A/B test. Users are bucketed
+// into variants in proportion to their weights.
+type CampaignVariant struct {
+	Name     string  `bson:"name" json:"name"`
+	Weight   int     `bson:"weight" json:"weight"`
+	Discount float64 `bson:"discount" json:"discount"`
+}
+
+// CampaignStatus is where a campaign stands; campaigns without one are active
+type CampaignStatus string
+
+const (
+	CampaignActive CampaignStatus = "active"
+	// CampaignExhausted campaigns have used up their budget and give no
+	// more discounts
+	CampaignExhausted CampaignStatus = "exhausted"
+)
+
+// IsExhausted reports whether the campaign has used up its budget
+func (c *Campaign) IsExhausted() bool {
+	return c.Status == CampaignExhausted
+}
+
+// EligibilityRules are the conditions a user and order must meet to use a
+// campaign's vouchers. Unset rules are not checked.
+type EligibilityRules struct {
+	Plans             []SubscriptionPlan `bson:"plans,omitempty" json:"plans,omitempty"`
+	FirstPurchaseOnly bool               `bson:"first_purchase_only,omitempty" json:"first_purchase_only,omitempty"`
+	RegisteredAfter   *time.Time         `bson:"registered_after,omitempty" json:"registered_after,omitempty"`
+	RegisteredBefore  *time.Time         `bson:"registered_before,omitempty" json:"registered_before,omitempty"`
+	// Locales match a user's locale exactly or by language, so "en" allows "en-GB"
+	Locales        []string `bson:"locales,omitempty" json:"locales,omitempty"`
+	Countries      []string `bson:"countries,omitempty" json:"countries,omitempty"`
+	MinOrderAmount float64  `bson:"min_order_amount,omitempty" json:"min_order_amount,omitempty"`
+}
+
+// ValidityStart is the moment a voucher's relative validity starts counting
+type ValidityStart string
+
+const (
+	// ValidFromIssue counts from generation, which is also when personal
+	// vouchers are assigned to their user
+	ValidFromIssue ValidityStart = "issue"
+	// ValidFromFirstView counts from the first time the voucher is viewed
+	// or redeemed
+	ValidFromFirstView ValidityStart = "first_view"
+)
+
+// VoucherValidity keeps each voucher valid for Days from its start,
+// never past the campaign's EndDate
+type VoucherValidity struct {
+	Days int           `bson:"days" json:"days"`
+	From ValidityStart `bson:"from" json:"from"`
+}
+
+// CodeFormat describes how voucher codes of a campaign are generated,
+// e.g. prefix "SUMMER", length 8, group size 4 and separator "-" yield
+// codes like SUMMER-7KQ2-MX9P
+type CodeFormat struct {
+	Prefix    string `bson:"prefix,omitempty" json:"prefix,omitempty"`
+	Length    int    `bson:"length" json:"length"`
+	Alphabet  string `bson:"alphabet" json:"alphabet"`
+	GroupSize int    `bson:"group_size,omitempty" json:"group_size,omitempty"`
+	Separator string `bson:"separator,omitempty" json:"separator,omitempty"`
 }
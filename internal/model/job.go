@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// JobStatus is the lifecycle state of a background job
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// JobType identifies the work a background job performs
+type JobType string
+
+const (
+	JobGenerateVouchers JobType = "generate_vouchers"
+)
+
+type Job struct {
+	Id         string    `bson:"_id,omitempty" json:"id"`
+	Type       JobType   `bson:"type" json:"type"`
+	Status     JobStatus `bson:"status" json:"status"`
+	CampaignID string    `bson:"campaign_id" json:"campaign_id"`
+	Total      int       `bson:"total" json:"total"`
+	Generated  int       `bson:"generated" json:"generated"`
+	UserIDs    []string  `bson:"user_ids,omitempty" json:"-"`
+	Errors     []string  `bson:"errors" json:"errors"`
+	CreatedBy  string    `bson:"created_by" json:"created_by"`
+	// Owner is the worker running the job, which holds it until LeaseUntil
+	Owner       string     `bson:"owner,omitempty" json:"-"`
+	LeaseUntil  *time.Time `bson:"lease_until,omitempty" json:"-"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// Finished reports whether the job has stopped running
+func (j *Job) Finished() bool {
+	return j.Status == JobCompleted || j.Status == JobFailed
+}
@@ -3,12 +3,25 @@ package model
 import "time"
 
 type Purchase struct {
-	Id             string    `bson:"_id,omitempty" json:"id"`
-	UserId         string    `bson:"user_id" json:"user_id"`
-	SubscriptionId string    `bson:"subscription_id" json:"subscription_id"`
-	Amount         float64   `bson:"amount" json:"amount"`
-	Discount       float64   `bson:"discount" json:"discount"`
-	Total          float64   `bson:"total" json:"total"`
-	VoucherCode    string    `bson:"voucher_code,omitempty" json:"voucher_code"`
-	PurchaseDate   time.Time `bson:"purchase_date" json:"purchase_date"`
+	Id             string  `bson:"_id,omitempty" json:"id"`
+	UserId         string  `bson:"user_id" json:"user_id"`
+	SubscriptionId string  `bson:"subscription_id" json:"subscription_id"`
+	Amount         float64 `bson:"amount" json:"amount"`
+	// Discounts lists every discount applied, in the order they were applied
+	Discounts    []DiscountLine `bson:"discounts,omitempty" json:"discounts"`
+	Total        float64        `bson:"total" json:"total"`
+	PurchaseDate time.Time      `bson:"purchase_date" json:"purchase_date"`
+}
+
+// DiscountLine is one campaign's discount on a purchase
+type DiscountLine struct {
+	CampaignID string `bson:"campaign_id" json:"campaign_id"`
+	// VoucherCode is the code the discount was redeemed with; automatic
+	// campaigns apply without one
+	VoucherCode string `bson:"voucher_code,omitempty" json:"voucher_code,omitempty"`
+	Automatic   bool   `bson:"automatic,omitempty" json:"automatic,omitempty"`
+	// Variant is the campaign variant whose discount was given
+	Variant string  `bson:"variant,omitempty" json:"variant,omitempty"`
+	Percent float64 `bson:"percent" json:"percent"`
+	Amount  float64 `bson:"amount" json:"amount"`
 }
@@ -9,6 +9,15 @@ const (
 	PlanGold   SubscriptionPlan = "gold"
 )
 
+// IsValid reports whether the plan is one of the known plans
+func (p SubscriptionPlan) IsValid() bool {
+	switch p {
+	case PlanSilver, PlanGold:
+		return true
+	}
+	return false
+}
+
 type Subscription struct {
 	Id        string           `bson:"_id,omitempty" json:"id"`
 	UserId    string           `bson:"user_id" json:"user_id"`
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// CampaignTemplate keeps the settings of a recurring campaign under a name
+// so new campaigns can start from them
+type CampaignTemplate struct {
+	Id          string            `bson:"_id,omitempty" json:"id"`
+	Name        string            `bson:"name" json:"name"`
+	Discount    float64           `bson:"discount" json:"discount"`
+	Tiers       []DiscountTier    `bson:"tiers,omitempty" json:"tiers,omitempty"`
+	Stacking    *StackingPolicy   `bson:"stacking,omitempty" json:"stacking,omitempty"`
+	Automatic   bool              `bson:"automatic,omitempty" json:"automatic,omitempty"`
+	MaxUsers    int               `bson:"max_users,omitempty" json:"max_users,omitempty"`
+	MaxIssued   int               `bson:"max_issued,omitempty" json:"max_issued,omitempty"`
+	Budget      float64           `bson:"budget,omitempty" json:"budget,omitempty"`
+	Description string            `bson:"description" json:"description"`
+	CodeFormat  *CodeFormat       `bson:"code_format,omitempty" json:"code_format,omitempty"`
+	Validity    *VoucherValidity  `bson:"validity,omitempty" json:"validity,omitempty"`
+	Eligibility *EligibilityRules `bson:"eligibility,omitempty" json:"eligibility,omitempty"`
+	Condition   string            `bson:"condition,omitempty" json:"condition,omitempty"`
+	CreatedAt   time.Time         `bson:"created_at" json:"created_at"`
+}
+
+// NewCampaign returns an active campaign with the template's settings and
+// no dates
+func (t *CampaignTemplate) NewCampaign() *Campaign {
+	return &Campaign{
+		Name:        t.Name,
+		Discount:    t.Discount,
+		Tiers:       t.Tiers,
+		Stacking:    t.Stacking,
+		Automatic:   t.Automatic,
+		MaxUsers:    t.MaxUsers,
+		MaxIssued:   t.MaxIssued,
+		Budget:      t.Budget,
+		Description: t.Description,
+		CodeFormat:  t.CodeFormat,
+		Validity:    t.Validity,
+		Eligibility: t.Eligibility,
+		Condition:   t.Condition,
+		Status:      CampaignActive,
+	}
+}
@@ -1,7 +1,31 @@
 package model
 
+import "time"
+
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleMarketer Role = "marketer"
+	RoleSupport  Role = "support"
+	RoleCustomer Role = "customer"
+)
+
+// IsValid reports whether the role is one of the known roles
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleMarketer, RoleSupport, RoleCustomer:
+		return true
+	}
+	return false
+}
+
 type User struct {
-	Id       string `bson:"_id,omitempty" json:"id"`
-	Email    string `bson:"email" json:"email"`
-	Password string `bson:"password,omitempty" json:"-"`
+	Id        string    `bson:"_id,omitempty" json:"id"`
+	Email     string    `bson:"email" json:"email"`
+	Password  string    `bson:"password,omitempty" json:"-"`
+	Role      Role      `bson:"role" json:"role"`
+	Locale    string    `bson:"locale,omitempty" json:"locale,omitempty"`
+	Country   string    `bson:"country,omitempty" json:"country,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 }
@@ -2,11 +2,92 @@ package model
 
 import "time"
 
+// VoucherMode controls how many times a voucher can be redeemed
+type VoucherMode string
+
+const (
+	// VoucherSingleUse vouchers are redeemed once, by one user
+	VoucherSingleUse VoucherMode = "single"
+	// VoucherMultiUse vouchers are shared codes redeemed up to MaxRedemptions
+	// times in total and PerUserLimit times per user
+	VoucherMultiUse VoucherMode = "multi"
+)
+
 type Voucher struct {
-	Id         string    `bson:"_id,omitempty" json:"id"`
-	Code       string    `bson:"code" json:"code"`
-	CampaignID string    `bson:"campaign_id" json:"campaign_id"`
-	UserId     string    `bson:"user_id,omitempty" json:"user_id"`
-	Used       bool      `bson:"used" json:"used"`
-	ExpiryDate time.Time `bson:"expiry_date" json:"expiry_date"`
+	Id              string      `bson:"_id,omitempty" json:"id"`
+	Code            string      `bson:"code" json:"code"`
+	CampaignID      string      `bson:"campaign_id" json:"campaign_id"`
+	UserId          string      `bson:"user_id,omitempty" json:"user_id"`
+	AssignedUserId  string      `bson:"assigned_user_id,omitempty" json:"assigned_user_id,omitempty"`
+	Used            bool        `bson:"used" json:"used"`
+	ExpiryDate      time.Time   `bson:"expiry_date" json:"expiry_date"`
+	Mode            VoucherMode `bson:"mode,omitempty" json:"mode,omitempty"`
+	MaxRedemptions  int         `bson:"max_redemptions,omitempty" json:"max_redemptions,omitempty"`
+	PerUserLimit    int         `bson:"per_user_limit,omitempty" json:"per_user_limit,omitempty"`
+	RedemptionCount int         `bson:"redemption_count" json:"redemption_count"`
+	RevokedAt       *time.Time  `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	RevokedReason   string      `bson:"revoked_reason,omitempty" json:"revoked_reason,omitempty"`
+	ReservedBy      string      `bson:"reserved_by,omitempty" json:"reserved_by,omitempty"`
+	ReservedUntil   *time.Time  `bson:"reserved_until,omitempty" json:"reserved_until,omitempty"`
+	// ValidityDays is set on vouchers whose validity starts at first view;
+	// until then ExpiryDate holds the campaign end as an upper bound
+	ValidityDays  int        `bson:"validity_days,omitempty" json:"validity_days,omitempty"`
+	FirstViewedAt *time.Time `bson:"first_viewed_at,omitempty" json:"first_viewed_at,omitempty"`
+	// Legacy is set on vouchers generated before codes carried a check
+	// character, which are exempt from the check character test
+	Legacy bool `bson:"legacy,omitempty" json:"legacy,omitempty"`
+	// JobID is the background job that generated the voucher, if any
+	JobID string `bson:"job_id,omitempty" json:"job_id,omitempty"`
+}
+
+// StartsOnView reports whether the voucher's validity clock is still
+// waiting for its first view
+func (v *Voucher) StartsOnView() bool {
+	return v.ValidityDays > 0 && v.FirstViewedAt == nil
+}
+
+// ExpiryFromView returns the expiry of a voucher first viewed at the given
+// time, capped by its current expiry
+func (v *Voucher) ExpiryFromView(viewedAt time.Time) time.Time {
+	expiry := viewedAt.AddDate(0, 0, v.ValidityDays)
+	if expiry.After(v.ExpiryDate) {
+		return v.ExpiryDate
+	}
+	return expiry
+}
+
+// IsHeldByOther reports whether another user holds an unexpired
+// reservation on the voucher at the given time
+func (v *Voucher) IsHeldByOther(userID string, now time.Time) bool {
+	return v.ReservedBy != "" && v.ReservedBy != userID &&
+		v.ReservedUntil != nil && v.ReservedUntil.After(now)
+}
+
+// IsRevoked reports whether the voucher has been voided
+func (v *Voucher) IsRevoked() bool {
+	return v.RevokedAt != nil
+}
+
+// IsAssignedTo reports whether the voucher may be redeemed by the user;
+// vouchers without an assigned user may be redeemed by anyone
+func (v *Voucher) IsAssignedTo(userID string) bool {
+	return v.AssignedUserId == "" || v.AssignedUserId == userID
+}
+
+// IsMultiUse reports whether the voucher is a shared code
+func (v *Voucher) IsMultiUse() bool {
+	return v.Mode == VoucherMultiUse
+}
+
+// Redemption records one use of a voucher
+type Redemption struct {
+	Id         string `bson:"_id,omitempty" json:"id"`
+	VoucherID  string `bson:"voucher_id" json:"voucher_id"`
+	Code       string `bson:"code" json:"code"`
+	CampaignID string `bson:"campaign_id" json:"campaign_id"`
+	UserId     string `bson:"user_id" json:"user_id"`
+	// Variant is the campaign variant the user was bucketed into, if the
+	// campaign runs an A/B test
+	Variant    string    `bson:"variant,omitempty" json:"variant,omitempty"`
+	RedeemedAt time.Time `bson:"redeemed_at" json:"redeemed_at"`
 }
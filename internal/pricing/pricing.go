@@ -0,0 +1,105 @@
+package pricing
+
+import (
+	"slices"
+	"trinity/internal/model"
+)
+
+// Candidate is a campaign discount that could apply to an order
+type Candidate struct {
+	Campaign *model.Campaign
+	// VoucherCode is the code that brought the campaign in; it is empty for
+	// automatic campaigns
+	VoucherCode string
+	// Variant is the variant the buyer is bucketed into when the campaign
+	// runs an A/B test; its discount replaces the campaign's
+	Variant *model.CampaignVariant
+}
+
+// Quote is the discounts chosen for an order and the amount left to pay
+type Quote struct {
+	Amount float64
+	Lines  []model.DiscountLine
+	Total  float64
+}
+
+// Discount returns the sum of the discount lines
+func (q Quote) Discount() float64 {
+	return q.Amount - q.Total
+}
+
+// Best picks the combination of candidates that leaves the lowest total for
+// an order of the plan at the amount. Each campaign's discount comes from
+// its variant or else its tier for the order. Each exclusive campaign is
+// tried on its own and all stackable campaigns are tried together. A
+// campaign counts once, however many of its codes are given. Ties go to
+// the combination holding the highest priority.
+func Best(plan model.SubscriptionPlan, amount float64, candidates []Candidate) Quote {
+	campaigns := distinct(candidates)
+	slices.SortStableFunc(campaigns, func(a, b Candidate) int {
+		return b.Campaign.Priority() - a.Campaign.Priority()
+	})
+
+	var stackable []Candidate
+	options := make([][]Candidate, 0, len(campaigns))
+	for _, candidate := range campaigns {
+		if !candidate.Campaign.IsStackable() {
+			options = append(options, []Candidate{candidate})
+			continue
+		}
+		// The stacked option sits where its highest priority member would
+		if stackable == nil {
+			options = append(options, nil)
+		}
+		stackable = append(stackable, candidate)
+	}
+
+	best := Quote{Amount: amount, Total: amount}
+	for _, option := range options {
+		if option == nil {
+			option = stackable
+		}
+		if quote := apply(plan, amount, option); quote.Total < best.Total {
+			best = quote
+		}
+	}
+	return best
+}
+
+// apply takes each discount off the amount left by the ones before it.
+// Tiers are matched against the full amount of the order.
+func apply(plan model.SubscriptionPlan, amount float64, candidates []Candidate) Quote {
+	quote := Quote{Amount: amount, Total: amount}
+	for _, candidate := range candidates {
+		percent := DiscountFor(candidate.Campaign, plan, amount)
+		var variant string
+		if candidate.Variant != nil {
+			percent, variant = candidate.Variant.Discount, candidate.Variant.Name
+		}
+		discount := min(quote.Total*percent/100, quote.Total)
+		quote.Total -= discount
+		quote.Lines = append(quote.Lines, model.DiscountLine{
+			CampaignID:  candidate.Campaign.Id,
+			VoucherCode: candidate.VoucherCode,
+			Automatic:   candidate.VoucherCode == "",
+			Variant:     variant,
+			Percent:     percent,
+			Amount:      discount,
+		})
+	}
+	return quote
+}
+
+// distinct keeps the first candidate of each campaign
+func distinct(candidates []Candidate) []Candidate {
+	seen := make(map[string]bool, len(candidates))
+	campaigns := make([]Candidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if seen[candidate.Campaign.Id] {
+			continue
+		}
+		seen[candidate.Campaign.Id] = true
+		campaigns = append(campaigns, candidate)
+	}
+	return campaigns
+}
@@ -0,0 +1,92 @@
+package pricing
+
+import (
+	"testing"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func campaignWith(id string, discount float64, mode model.StackingMode, priority int) *model.Campaign {
+	return &model.Campaign{Id: id, Discount: discount, Stacking: &model.StackingPolicy{Mode: mode, Priority: priority}}
+}
+
+func TestBest(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		total      float64
+		campaigns  []string
+	}{
+		{"no candidates", nil, 200, nil},
+		{"single campaign", []Candidate{{Campaign: &model.Campaign{Id: "a", Discount: 30}}}, 140, []string{"a"}},
+		{
+			"best exclusive wins",
+			[]Candidate{
+				{Campaign: campaignWith("a", 10, model.StackExclusive, 0)},
+				{Campaign: campaignWith("b", 25, model.StackExclusive, 0)},
+			},
+			150, []string{"b"},
+		},
+		{
+			"stackables apply in priority order",
+			[]Candidate{
+				{Campaign: campaignWith("low", 10, model.StackStackable, 1)},
+				{Campaign: campaignWith("high", 50, model.StackStackable, 5)},
+			},
+			90, []string{"high", "low"},
+		},
+		{
+			"stack beats a smaller exclusive",
+			[]Candidate{
+				{Campaign: campaignWith("solo", 20, model.StackExclusive, 0)},
+				{Campaign: campaignWith("a", 15, model.StackStackable, 0)},
+				{Campaign: campaignWith("b", 15, model.StackStackable, 0)},
+			},
+			144.5, []string{"a", "b"},
+		},
+		{
+			"exclusive beats a smaller stack",
+			[]Candidate{
+				{Campaign: campaignWith("a", 10, model.StackStackable, 0)},
+				{Campaign: campaignWith("solo", 40, model.StackExclusive, 0)},
+			},
+			120, []string{"solo"},
+		},
+		{
+			"ties go to the higher priority",
+			[]Candidate{
+				{Campaign: campaignWith("a", 20, model.StackExclusive, 0)},
+				{Campaign: campaignWith("b", 20, model.StackExclusive, 3)},
+			},
+			160, []string{"b"},
+		},
+		{
+			"a campaign counts once",
+			[]Candidate{
+				{Campaign: campaignWith("a", 10, model.StackStackable, 0), VoucherCode: "ONE"},
+				{Campaign: campaignWith("a", 10, model.StackStackable, 0), VoucherCode: "TWO"},
+			},
+			180, []string{"a"},
+		},
+		{
+			"discount never exceeds the amount",
+			[]Candidate{{Campaign: &model.Campaign{Id: "a", Discount: 150}}},
+			0, []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := Best(model.PlanGold, 200, tt.candidates)
+
+			assert.InDelta(t, tt.total, quote.Total, 0.001)
+			var campaigns []string
+			for _, line := range quote.Lines {
+				campaigns = append(campaigns, line.CampaignID)
+			}
+			assert.Equal(t, tt.campaigns, campaigns)
+			assert.InDelta(t, 200-tt.total, quote.Discount(), 0.001)
+		})
+	}
+}
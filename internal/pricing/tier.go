@@ -0,0 +1,92 @@
+package pricing
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"trinity/internal/model"
+)
+
+// ErrInvalidTiers is returned for tier tables that cannot be resolved unambiguously
+var ErrInvalidTiers = errors.New("invalid discount tiers")
+
+// DiscountFor returns the campaign's discount for an order, taken from the
+// tier the order matches or else from the campaign itself
+func DiscountFor(campaign *model.Campaign, plan model.SubscriptionPlan, amount float64) float64 {
+	for _, tier := range campaign.Tiers {
+		if tier.Plan != "" {
+			if tier.Plan == plan {
+				return tier.Discount
+			}
+			continue
+		}
+		if amount >= tier.MinAmount && (tier.MaxAmount == 0 || amount < tier.MaxAmount) {
+			return tier.Discount
+		}
+	}
+	return campaign.Discount
+}
+
+// ValidateTiers checks that a tier table is keyed one way only and that no
+// order can match two tiers
+func ValidateTiers(tiers []model.DiscountTier) error {
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	byPlan := tiers[0].Plan != ""
+	for _, tier := range tiers {
+		if (tier.Plan != "") != byPlan {
+			return fmt.Errorf("%w: tiers must all be keyed by plan or all by amount", ErrInvalidTiers)
+		}
+		if tier.Discount <= 0 || tier.Discount > 100 {
+			return fmt.Errorf("%w: discount must be above 0 and at most 100", ErrInvalidTiers)
+		}
+		if byPlan {
+			if !tier.Plan.IsValid() {
+				return fmt.Errorf("%w: unknown plan %q", ErrInvalidTiers, tier.Plan)
+			}
+			if tier.MinAmount != 0 || tier.MaxAmount != 0 {
+				return fmt.Errorf("%w: plan tiers cannot have amounts", ErrInvalidTiers)
+			}
+			continue
+		}
+		if tier.MinAmount < 0 || (tier.MaxAmount != 0 && tier.MaxAmount <= tier.MinAmount) {
+			return fmt.Errorf("%w: range %.2f to %.2f is empty", ErrInvalidTiers, tier.MinAmount, tier.MaxAmount)
+		}
+	}
+
+	if byPlan {
+		return overlappingPlans(tiers)
+	}
+	return overlappingRanges(tiers)
+}
+
+// overlappingPlans rejects a plan listed twice
+func overlappingPlans(tiers []model.DiscountTier) error {
+	seen := make(map[model.SubscriptionPlan]bool, len(tiers))
+	for _, tier := range tiers {
+		if seen[tier.Plan] {
+			return fmt.Errorf("%w: plan %q has more than one tier", ErrInvalidTiers, tier.Plan)
+		}
+		seen[tier.Plan] = true
+	}
+	return nil
+}
+
+// overlappingRanges rejects amount ranges that share an amount
+func overlappingRanges(tiers []model.DiscountTier) error {
+	sorted := slices.Clone(tiers)
+	slices.SortFunc(sorted, func(a, b model.DiscountTier) int {
+		return cmp.Compare(a.MinAmount, b.MinAmount)
+	})
+
+	for i := 1; i < len(sorted); i++ {
+		previous := sorted[i-1]
+		if previous.MaxAmount == 0 || previous.MaxAmount > sorted[i].MinAmount {
+			return fmt.Errorf("%w: ranges from %.2f and %.2f overlap", ErrInvalidTiers, previous.MinAmount, sorted[i].MinAmount)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pricing
+
+import (
+	"testing"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscountFor(t *testing.T) {
+	byPlan := &model.Campaign{Discount: 5, Tiers: []model.DiscountTier{
+		{Plan: model.PlanSilver, Discount: 10},
+		{Plan: model.PlanGold, Discount: 25},
+	}}
+	byAmount := &model.Campaign{Discount: 5, Tiers: []model.DiscountTier{
+		{MinAmount: 100, MaxAmount: 200, Discount: 10},
+		{MinAmount: 200, Discount: 20},
+	}}
+
+	tests := []struct {
+		name     string
+		campaign *model.Campaign
+		plan     model.SubscriptionPlan
+		amount   float64
+		want     float64
+	}{
+		{"silver tier", byPlan, model.PlanSilver, 100, 10},
+		{"gold tier", byPlan, model.PlanGold, 200, 25},
+		{"below every range", byAmount, model.PlanSilver, 50, 5},
+		{"lower range", byAmount, model.PlanSilver, 100, 10},
+		{"range end is exclusive", byAmount, model.PlanGold, 200, 20},
+		{"open range", byAmount, model.PlanGold, 5000, 20},
+		{"no tiers", &model.Campaign{Discount: 30}, model.PlanGold, 200, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DiscountFor(tt.campaign, tt.plan, tt.amount))
+		})
+	}
+}
+
+func TestValidateTiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		tiers []model.DiscountTier
+		valid bool
+	}{
+		{"none", nil, true},
+		{"one per plan", []model.DiscountTier{{Plan: model.PlanSilver, Discount: 10}, {Plan: model.PlanGold, Discount: 25}}, true},
+		{"adjacent ranges", []model.DiscountTier{{MinAmount: 200, Discount: 20}, {MinAmount: 0, MaxAmount: 200, Discount: 10}}, true},
+		{"plan twice", []model.DiscountTier{{Plan: model.PlanGold, Discount: 10}, {Plan: model.PlanGold, Discount: 25}}, false},
+		{"overlapping ranges", []model.DiscountTier{{MinAmount: 0, MaxAmount: 150, Discount: 10}, {MinAmount: 100, Discount: 20}}, false},
+		{"two open ranges", []model.DiscountTier{{MinAmount: 100, Discount: 10}, {MinAmount: 300, Discount: 20}}, false},
+		{"mixed keys", []model.DiscountTier{{Plan: model.PlanGold, Discount: 10}, {MinAmount: 100, Discount: 20}}, false},
+		{"unknown plan", []model.DiscountTier{{Plan: "platinum", Discount: 10}}, false},
+		{"empty range", []model.DiscountTier{{MinAmount: 100, MaxAmount: 100, Discount: 10}}, false},
+		{"discount over 100", []model.DiscountTier{{Plan: model.PlanGold, Discount: 120}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTiers(tt.tiers)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidTiers)
+			}
+		})
+	}
+}
+
+func TestBest_UsesTierForOrder(t *testing.T) {
+	campaign := &model.Campaign{Id: "tiered", Discount: 5, Tiers: []model.DiscountTier{
+		{Plan: model.PlanSilver, Discount: 10},
+		{Plan: model.PlanGold, Discount: 25},
+	}}
+
+	quote := Best(model.PlanGold, 200, []Candidate{{Campaign: campaign, VoucherCode: "TIER1X"}})
+
+	assert.Equal(t, 150.0, quote.Total)
+	assert.Equal(t, 25.0, quote.Lines[0].Percent, "The line should record the tier's discount")
+}
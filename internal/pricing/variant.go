@@ -0,0 +1,68 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"trinity/internal/model"
+)
+
+// ErrInvalidVariants is returned for variant lists users cannot be split across
+var ErrInvalidVariants = errors.New("invalid campaign variants")
+
+// VariantFor returns the variant of the campaign the user is bucketed into,
+// or nil for campaigns without variants. The bucket is a hash of the
+// campaign and user IDs, so a user keeps their variant for as long as the
+// variants and weights stay the same, and lands independently in each
+// campaign.
+func VariantFor(campaign *model.Campaign, userID string) *model.CampaignVariant {
+	total := 0
+	for _, variant := range campaign.Variants {
+		total += variant.Weight
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(campaign.Id + "/" + userID))
+	bucket := int(h.Sum32() % uint32(total))
+
+	for i := range campaign.Variants {
+		bucket -= campaign.Variants[i].Weight
+		if bucket < 0 {
+			return &campaign.Variants[i]
+		}
+	}
+	return nil
+}
+
+// ValidateVariants checks that variants are named uniquely and that each
+// has a positive weight and a usable discount
+func ValidateVariants(variants []model.CampaignVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	if len(variants) < 2 {
+		return fmt.Errorf("%w: an A/B test needs at least two variants", ErrInvalidVariants)
+	}
+
+	seen := make(map[string]bool, len(variants))
+	for _, variant := range variants {
+		if variant.Name == "" {
+			return fmt.Errorf("%w: variants need a name", ErrInvalidVariants)
+		}
+		if seen[variant.Name] {
+			return fmt.Errorf("%w: variant %q is listed twice", ErrInvalidVariants, variant.Name)
+		}
+		seen[variant.Name] = true
+
+		if variant.Weight <= 0 {
+			return fmt.Errorf("%w: variant %q needs a positive weight", ErrInvalidVariants, variant.Name)
+		}
+		if variant.Discount <= 0 || variant.Discount > 100 {
+			return fmt.Errorf("%w: discount of variant %q must be above 0 and at most 100", ErrInvalidVariants, variant.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package pricing
+
+import (
+	"fmt"
+	"testing"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func abTest() *model.Campaign {
+	return &model.Campaign{Id: "campaign123", Discount: 20, Variants: []model.CampaignVariant{
+		{Name: "control", Weight: 3, Discount: 15},
+		{Name: "treatment", Weight: 1, Discount: 25},
+	}}
+}
+
+func TestVariantFor_IsDeterministic(t *testing.T) {
+	campaign := abTest()
+
+	first := VariantFor(campaign, "user123")
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, VariantFor(campaign, "user123"), "A user should keep their variant")
+	}
+}
+
+func TestVariantFor_FollowsWeights(t *testing.T) {
+	campaign := abTest()
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[VariantFor(campaign, fmt.Sprintf("user%d", i)).Name]++
+	}
+
+	assert.InDelta(t, 7500, counts["control"], 300, "About three in four users should get control")
+	assert.InDelta(t, 2500, counts["treatment"], 300, "About one in four users should get treatment")
+}
+
+func TestVariantFor_NoVariants(t *testing.T) {
+	assert.Nil(t, VariantFor(&model.Campaign{Id: "campaign123", Discount: 20}, "user123"))
+}
+
+func TestBest_UsesVariantDiscount(t *testing.T) {
+	campaign := abTest()
+	variant := &campaign.Variants[1]
+
+	quote := Best(model.PlanGold, 200, []Candidate{{Campaign: campaign, VoucherCode: "AB1X", Variant: variant}})
+
+	assert.InDelta(t, 150.0, quote.Total, 0.001, "The variant's 25% should apply instead of the campaign's 20%")
+	assert.Equal(t, "treatment", quote.Lines[0].Variant)
+}
+
+func TestValidateVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []model.CampaignVariant
+		valid    bool
+	}{
+		{"none", nil, true},
+		{"weighted pair", abTest().Variants, true},
+		{"single", []model.CampaignVariant{{Name: "a", Weight: 1, Discount: 10}}, false},
+		{"unnamed", []model.CampaignVariant{{Weight: 1, Discount: 10}, {Name: "b", Weight: 1, Discount: 20}}, false},
+		{"duplicate name", []model.CampaignVariant{{Name: "a", Weight: 1, Discount: 10}, {Name: "a", Weight: 1, Discount: 20}}, false},
+		{"zero weight", []model.CampaignVariant{{Name: "a", Weight: 0, Discount: 10}, {Name: "b", Weight: 1, Discount: 20}}, false},
+		{"discount above 100", []model.CampaignVariant{{Name: "a", Weight: 1, Discount: 10}, {Name: "b", Weight: 1, Discount: 120}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateVariants(tt.variants)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidVariants)
+			}
+		})
+	}
+}
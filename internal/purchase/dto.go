@@ -1,10 +1,37 @@
 package purchase
 
-import "trinity/internal/model"
+import (
+	"slices"
+	"trinity/internal/model"
+)
 
 // ProcessPurchaseRequest represents the request payload for processing a purchase
 type ProcessPurchaseRequest struct {
-	UserId      string                 `json:"user_id" binding:"required"`
-	Plan        model.SubscriptionPlan `json:"plan" binding:"required"`
-	VoucherCode string                 `json:"voucher_code,omitempty"`
+	Plan model.SubscriptionPlan `json:"plan" binding:"required"`
+	// VoucherCode is a single code, kept for clients that predate voucher_codes
+	VoucherCode string `json:"voucher_code,omitempty"`
+	// VoucherCodes are tried together; at most five per purchase
+	VoucherCodes []string `json:"voucher_codes,omitempty" binding:"omitempty,max=5,dive,required"`
+}
+
+// QuoteResponse represents the price of a purchase with its discounts
+type QuoteResponse struct {
+	Amount    float64              `json:"amount"`
+	Discounts []model.DiscountLine `json:"discounts"`
+	Total     float64              `json:"total"`
+}
+
+// codes returns the requested voucher codes without duplicates
+func (r ProcessPurchaseRequest) codes() []string {
+	codes := slices.Clone(r.VoucherCodes)
+	if r.VoucherCode != "" {
+		codes = append([]string{r.VoucherCode}, codes...)
+	}
+	unique := codes[:0]
+	for _, code := range codes {
+		if !slices.Contains(unique, code) {
+			unique = append(unique, code)
+		}
+	}
+	return unique
 }
@@ -1,8 +1,12 @@
 package purchase
 
 import (
+	"errors"
 	"net/http"
-	"trinity/internal/model"
+	"trinity/internal/auth"
+	"trinity/internal/campaign"
+	"trinity/internal/user"
+	"trinity/internal/voucher"
 	"trinity/pkg/logger"
 	"trinity/pkg/reason"
 	"trinity/pkg/response"
@@ -25,25 +29,27 @@ func NewHandler(service Service) *Handler {
 // RegisterRoutes registers the purchase routes with the Gin router
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.POST("/", h.ProcessPurchase)
+	rg.POST("/quote", h.Quote)
 }
 
 // ProcessPurchase godoc
 // @Summary Process a subscription purchase
-// @Description Process a subscription purchase with optional voucher code
+// @Description Process a subscription purchase for the authenticated user with optional voucher codes. The combination of codes giving the lowest total is applied, following each campaign's stacking policy.
 // @Tags Purchase
 // @Accept  json
 // @Produce  json
+// @Security BearerAuth
 // @Param request body purchase.ProcessPurchaseRequest true "Purchase data"
 // @Success 200 {object} model.Purchase
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.RuleErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /purchases [post]
 func (h *Handler) ProcessPurchase(c *gin.Context) {
-	var req struct {
-		UserId      string                 `json:"user_id"`
-		Plan        model.SubscriptionPlan `json:"plan"`
-		VoucherCode string                 `json:"voucher_code,omitempty"`
-	}
+	var req ProcessPurchaseRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		msg := reason.InvalidRequestFormat.Message()
@@ -52,19 +58,112 @@ func (h *Handler) ProcessPurchase(c *gin.Context) {
 		return
 	}
 
-	if req.UserId == "" || req.Plan == "" {
-		msg := reason.InvalidRequest.Message()
-		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+	userID := auth.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.Unauthorized.Message()})
 		return
 	}
 
-	purchase, err := h.service.ProcessPurchase(req.UserId, req.Plan, req.VoucherCode)
+	purchase, err := h.service.ProcessPurchase(userID, req.Plan, req.codes())
 	if err != nil {
-		msg := reason.InternalServerError.Message()
-		h.logger.Errorf("%s: %v", msg, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		h.respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, purchase)
 }
+
+// Quote godoc
+// @Summary Price a subscription purchase
+// @Description Price a purchase for the authenticated user without making it. The voucher codes and the automatic campaigns the order qualifies for are combined into the lowest total, following each campaign's stacking policy.
+// @Tags Purchase
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param request body purchase.ProcessPurchaseRequest true "Purchase data"
+// @Success 200 {object} purchase.QuoteResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.RuleErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /purchases/quote [post]
+func (h *Handler) Quote(c *gin.Context) {
+	var req ProcessPurchaseRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	userID := auth.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.Unauthorized.Message()})
+		return
+	}
+
+	quote, err := h.service.Quote(userID, req.Plan, req.codes())
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, QuoteResponse{Amount: quote.Amount, Discounts: quote.Lines, Total: quote.Total})
+}
+
+// respondError maps a pricing or purchase error to its response
+func (h *Handler) respondError(c *gin.Context, err error) {
+	if errors.Is(err, user.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.UserNotFound.Message()})
+		return
+	}
+	if errors.Is(err, ErrInvalidPlan) {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: reason.InvalidRequest.Message()})
+		return
+	}
+	if errors.Is(err, voucher.ErrVoucherNotFound) {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.VoucherNotFound.Message()})
+		return
+	}
+	if errors.Is(err, voucher.ErrCodeMistyped) {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: reason.CodeMistyped.Message()})
+		return
+	}
+	if errors.Is(err, voucher.ErrVoucherNotAssigned) {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: reason.VoucherNotAssigned.Message()})
+		return
+	}
+	if voucher.RespondNotEligible(c, err) {
+		return
+	}
+	if msg, ok := voucher.UnavailableReason(err); ok {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+	if errors.Is(err, voucher.ErrVoucherReserved) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.VoucherReserved.Message()})
+		return
+	}
+	if errors.Is(err, voucher.ErrRedemptionLimitReached) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.VoucherFullyRedeemed.Message()})
+		return
+	}
+	if errors.Is(err, voucher.ErrUserLimitReached) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.VoucherUserLimit.Message()})
+		return
+	}
+	if errors.Is(err, campaign.ErrBudgetExhausted) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.BudgetExhausted.Message()})
+		return
+	}
+	if errors.Is(err, voucher.ErrCampaignFull) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.CampaignFull.Message()})
+		return
+	}
+	msg := reason.InternalServerError.Message()
+	h.logger.Errorf("%s: %v", msg, err)
+	c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+}
@@ -0,0 +1,68 @@
+package purchase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"trinity/internal/auth"
+	"trinity/internal/model"
+	"trinity/internal/voucher"
+	"trinity/pkg/reason"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuthenticator verifies the tokens minted by the tests
+var testAuthenticator, _ = auth.NewAuthenticator("test-secret", "", "trinity", time.Hour)
+
+// performPurchase posts a purchase of the gold plan with the code as user123
+func performPurchase(t *testing.T, service *MockService, code string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewHandler(service).RegisterRoutes(r.Group("/purchases", testAuthenticator.Middleware()))
+
+	token, err := testAuthenticator.IssueToken(&model.User{Id: "user123"})
+	require.NoError(t, err)
+
+	body, _ := json.Marshal(ProcessPurchaseRequest{Plan: model.PlanGold, VoucherCodes: []string{code}})
+	req, _ := http.NewRequest("POST", "/purchases/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestProcessPurchase_UnavailableVoucher(t *testing.T) {
+	for err, msg := range map[error]string{
+		voucher.ErrVoucherRevoked: reason.VoucherRevoked.Message(),
+		voucher.ErrVoucherUsed:    reason.VoucherUsed.Message(),
+		voucher.ErrVoucherExpired: reason.VoucherExpired.Message(),
+	} {
+		service := new(MockService)
+		service.On("ProcessPurchase", "user123", model.PlanGold, []string{"CODE1"}).Return(nil, err)
+
+		w := performPurchase(t, service, "CODE1")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "Unexpected status for %v", err)
+		assert.Contains(t, w.Body.String(), msg, "The reason should match the redeem endpoint's for %v", err)
+	}
+}
+
+func TestProcessPurchase_InternalError(t *testing.T) {
+	service := new(MockService)
+	service.On("ProcessPurchase", "user123", model.PlanGold, mock.Anything).Return(nil, errors.New("failed to create purchase"))
+
+	w := performPurchase(t, service, "CODE1")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "Expected status 500 Internal Server Error")
+	assert.NotContains(t, w.Body.String(), "failed to create purchase", "Internal errors should not leak")
+}
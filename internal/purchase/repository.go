@@ -5,12 +5,14 @@ import (
 
 	"trinity/internal/model"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Repository defines purchase data access methods
 type Repository interface {
 	CreatePurchase(purchase *model.Purchase) error
+	CountPurchasesByUser(userID string) (int64, error)
 }
 
 // repository implements Repository interface
@@ -30,3 +32,8 @@ func (r *repository) CreatePurchase(purchase *model.Purchase) error {
 	_, err := r.collection.InsertOne(context.Background(), purchase)
 	return err
 }
+
+// CountPurchasesByUser returns how many purchases the user has made
+func (r *repository) CountPurchasesByUser(userID string) (int64, error) {
+	return r.collection.CountDocuments(context.Background(), bson.M{"user_id": userID})
+}
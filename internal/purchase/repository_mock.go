@@ -0,0 +1,22 @@
+package purchase
+
+import (
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreatePurchase(purchase *model.Purchase) error {
+	args := m.Called(purchase)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CountPurchasesByUser(userID string) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -3,78 +3,89 @@ package purchase
 import (
 	"errors"
 	"time"
+	"trinity/internal/campaign"
+	"trinity/internal/eligibility"
 	"trinity/internal/model"
+	"trinity/internal/pricing"
 	"trinity/internal/subscription"
+	"trinity/internal/user"
 	"trinity/internal/voucher"
 	"trinity/pkg/logger"
 )
 
+// ErrInvalidPlan is returned for subscription plans that cannot be bought
+var ErrInvalidPlan = errors.New("invalid subscription plan")
+
+// CampaignSource looks up the campaigns that can discount a purchase
+type CampaignSource interface {
+	GetCampaignByID(id string) (*model.Campaign, error)
+	ListAutomaticCampaigns(now time.Time) ([]model.Campaign, error)
+	ClaimRedemption(id string) error
+	ReleaseRedemption(id string) error
+	AddSpend(id string, amount float64) (*model.Campaign, error)
+	RefundSpend(id string, amount float64) error
+	SetStatus(id string, status model.CampaignStatus) error
+	RecordExposure(campaignID string, variant string, userID string) error
+	CreateAuditEntry(entry *model.AuditEntry) error
+}
+
 // Service defines purchase business logic methods
 type Service interface {
-	ProcessPurchase(userID string, plan model.SubscriptionPlan, voucherCode string) (*model.Purchase, error)
+	ProcessPurchase(userID string, plan model.SubscriptionPlan, voucherCodes []string) (*model.Purchase, error)
+	Quote(userID string, plan model.SubscriptionPlan, voucherCodes []string) (*pricing.Quote, error)
 }
 
 // service implements Service interface
 type service struct {
 	purchaseRepo     Repository
-	voucherRepo      voucher.Repository
+	voucherService   voucher.Service
 	subscriptionRepo subscription.Repository
+	userRepo         user.Repository
+	campaigns        CampaignSource
+	eligibility      eligibility.Checker
 	logger           logger.Logger
 }
 
 // NewService creates a new Purchase service
-func NewService(purchaseRepo Repository, voucherRepo voucher.Repository, subscriptionRepo subscription.Repository) Service {
+func NewService(purchaseRepo Repository, voucherService voucher.Service, subscriptionRepo subscription.Repository, userRepo user.Repository, campaigns CampaignSource, checker eligibility.Checker) Service {
 	return &service{
 		purchaseRepo:     purchaseRepo,
-		voucherRepo:      voucherRepo,
+		voucherService:   voucherService,
 		subscriptionRepo: subscriptionRepo,
+		userRepo:         userRepo,
+		campaigns:        campaigns,
+		eligibility:      checker,
 		logger:           logger.NewLogger("purchaseService"),
 	}
 }
 
-// ProcessPurchase processes a purchase
-func (s *service) ProcessPurchase(userId string, plan model.SubscriptionPlan, voucherCode string) (*model.Purchase, error) {
-	// Define base prices
-	var basePrice float64
-	switch plan {
-	case model.PlanSilver:
-		basePrice = 100.0 // Base price for Silver plan
-	case model.PlanGold:
-		basePrice = 200.0 // Base price for Gold plan
-	default:
-		return nil, errors.New("invalid subscription plan")
-	}
-
-	// Initialize discount
-	discount := 0.0
-
-	// If voucher code is provided, validate and apply discount
-	if voucherCode != "" {
-		voucher, err := s.voucherRepo.GetVoucherByCode(voucherCode)
-		if err != nil {
-			return nil, errors.New("invalid voucher code")
-		}
-		if voucher.Used {
-			return nil, errors.New("voucher already used")
-		}
-		if time.Now().After(voucher.ExpiryDate) {
-			return nil, errors.New("voucher expired")
+// ProcessPurchase processes a purchase. The order is priced as Quote does,
+// each discount is added to its campaign's spend within the budget, then
+// the codes in the chosen combination are redeemed and each automatic
+// campaign in it counts a redemption. Campaigns whose budget the purchase
+// uses up are marked exhausted.
+func (s *service) ProcessPurchase(userId string, plan model.SubscriptionPlan, voucherCodes []string) (*model.Purchase, error) {
+	customer, err := s.userRepo.GetUserByID(userId)
+	if err != nil {
+		if !errors.Is(err, user.ErrUserNotFound) {
+			s.logger.Errorf("Failed to get user %s: %v", userId, err)
 		}
+		return nil, err
+	}
 
-		// Apply discount (assuming 30% as per requirement)
-		discount = basePrice * 0.30
-
-		// Mark voucher as used
-		voucher.Used = true
-		voucher.UserId = userId
-		err = s.voucherRepo.UpdateVoucher(voucher)
-		if err != nil {
-			return nil, errors.New("failed to update voucher")
-		}
+	order, quote, err := s.price(customer, plan, voucherCodes)
+	if err != nil {
+		return nil, err
 	}
 
-	// Calculate total amount
-	totalAmount := basePrice - discount
+	exhausted, err := s.spend(quote.Lines)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redeem(userId, order, quote.Lines); err != nil {
+		s.refund(quote.Lines)
+		return nil, err
+	}
 
 	// Create subscription
 	subscription := &model.Subscription{
@@ -85,8 +96,10 @@ func (s *service) ProcessPurchase(userId string, plan model.SubscriptionPlan, vo
 		IsActive:  true,
 	}
 
-	err := s.subscriptionRepo.CreateSubscription(subscription)
+	err = s.subscriptionRepo.CreateSubscription(subscription)
 	if err != nil {
+		s.release(userId, quote.Lines)
+		s.refund(quote.Lines)
 		return nil, errors.New("failed to create subscription")
 	}
 
@@ -94,17 +107,248 @@ func (s *service) ProcessPurchase(userId string, plan model.SubscriptionPlan, vo
 	purchase := &model.Purchase{
 		UserId:         userId,
 		SubscriptionId: subscription.Id,
-		Amount:         basePrice,
-		Discount:       discount,
-		Total:          totalAmount,
-		VoucherCode:    voucherCode,
+		Amount:         quote.Amount,
+		Discounts:      quote.Lines,
+		Total:          quote.Total,
 		PurchaseDate:   time.Now(),
 	}
 
 	err = s.purchaseRepo.CreatePurchase(purchase)
 	if err != nil {
+		s.release(userId, quote.Lines)
+		s.refund(quote.Lines)
 		return nil, errors.New("failed to create purchase")
 	}
 
+	for _, campaignID := range exhausted {
+		s.exhaust(campaignID)
+	}
+
 	return purchase, nil
 }
+
+// spend adds each discount to its campaign's spend and returns the
+// campaigns whose budget is now used up. If a budget cannot cover its
+// discount, the discounts already added are refunded; the campaign stays
+// active for smaller discounts unless its budget is fully spent.
+func (s *service) spend(lines []model.DiscountLine) ([]string, error) {
+	var exhausted []string
+	for i, line := range lines {
+		updated, err := s.campaigns.AddSpend(line.CampaignID, line.Amount)
+		if err != nil {
+			s.refund(lines[:i])
+			if errors.Is(err, campaign.ErrBudgetExhausted) {
+				s.exhaustIfSpent(line.CampaignID)
+			} else {
+				s.logger.Errorf("Failed to add spend to campaign %s: %v", line.CampaignID, err)
+			}
+			return nil, err
+		}
+		if updated.Budget > 0 && updated.Spent >= updated.Budget {
+			exhausted = append(exhausted, line.CampaignID)
+		}
+	}
+	return exhausted, nil
+}
+
+// refund takes the discounts back out of their campaigns' spend
+func (s *service) refund(lines []model.DiscountLine) {
+	for _, line := range lines {
+		if err := s.campaigns.RefundSpend(line.CampaignID, line.Amount); err != nil {
+			s.logger.Errorf("Failed to refund %.2f to campaign %s: %v", line.Amount, line.CampaignID, err)
+		}
+	}
+}
+
+// exhaustIfSpent marks the campaign exhausted if its budget is fully spent
+func (s *service) exhaustIfSpent(campaignID string) {
+	c, err := s.campaigns.GetCampaignByID(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to get campaign %s: %v", campaignID, err)
+		return
+	}
+	if c.Budget > 0 && c.Spent >= c.Budget && !c.IsExhausted() {
+		s.exhaust(campaignID)
+	}
+}
+
+// exhaust marks a campaign whose budget is used up and records the status
+// change in the campaign's history
+func (s *service) exhaust(campaignID string) {
+	if err := s.campaigns.SetStatus(campaignID, model.CampaignExhausted); err != nil {
+		s.logger.Errorf("Failed to mark campaign %s exhausted: %v", campaignID, err)
+		return
+	}
+
+	entry := &model.AuditEntry{
+		CampaignID: campaignID,
+		Actor:      model.ActorSystem,
+		Action:     model.AuditStatusChange,
+		Before:     map[string]interface{}{"status": model.CampaignActive},
+		After:      map[string]interface{}{"status": model.CampaignExhausted},
+		Note:       "budget used up",
+		At:         time.Now(),
+	}
+	if err := s.campaigns.CreateAuditEntry(entry); err != nil {
+		s.logger.Errorf("Failed to record exhaustion of campaign %s: %v", campaignID, err)
+	}
+}
+
+// redeem redeems the codes of the discount lines and counts a redemption
+// for each automatic campaign among them. If one fails, the ones already
+// made are released.
+func (s *service) redeem(userID string, order *eligibility.Order, lines []model.DiscountLine) error {
+	redeemed := make([]model.DiscountLine, 0, len(lines))
+	// Codes go first: they are rechecked on redemption and more likely to
+	// fail than the atomic claims of automatic campaigns
+	for _, line := range lines {
+		if line.Automatic {
+			continue
+		}
+		if _, err := s.voucherService.RedeemForOrder(line.VoucherCode, userID, order); err != nil {
+			s.release(userID, redeemed)
+			return err
+		}
+		redeemed = append(redeemed, line)
+	}
+	for _, line := range lines {
+		if !line.Automatic {
+			continue
+		}
+		if err := s.campaigns.ClaimRedemption(line.CampaignID); err != nil {
+			if !errors.Is(err, voucher.ErrCampaignFull) {
+				s.logger.Errorf("Failed to claim a redemption of campaign %s: %v", line.CampaignID, err)
+			}
+			s.release(userID, redeemed)
+			return err
+		}
+		redeemed = append(redeemed, line)
+	}
+	return nil
+}
+
+// release undoes the redemptions of the discount lines for a purchase that
+// did not go through, so the user keeps their codes
+func (s *service) release(userID string, lines []model.DiscountLine) {
+	for _, line := range lines {
+		if line.Automatic {
+			if err := s.campaigns.ReleaseRedemption(line.CampaignID); err != nil {
+				s.logger.Errorf("Failed to release a redemption of campaign %s: %v", line.CampaignID, err)
+			}
+			continue
+		}
+		if err := s.voucherService.CancelRedemption(line.VoucherCode, userID); err != nil {
+			s.logger.Errorf("Failed to cancel redemption of %s by %s: %v", line.VoucherCode, userID, err)
+		}
+	}
+}
+
+// Quote prices a purchase without making it. Every voucher code is checked
+// against the order and joined by the automatic campaigns the user and order
+// qualify for, then the pricing engine picks the combination that leaves
+// the lowest total. Campaigns running an A/B test give the discount of the
+// variant the user is bucketed into.
+func (s *service) Quote(userId string, plan model.SubscriptionPlan, voucherCodes []string) (*pricing.Quote, error) {
+	customer, err := s.userRepo.GetUserByID(userId)
+	if err != nil {
+		if !errors.Is(err, user.ErrUserNotFound) {
+			s.logger.Errorf("Failed to get user %s: %v", userId, err)
+		}
+		return nil, err
+	}
+
+	_, quote, err := s.price(customer, plan, voucherCodes)
+	if err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+// price builds the order for the plan and picks its discounts
+func (s *service) price(customer *model.User, plan model.SubscriptionPlan, voucherCodes []string) (*eligibility.Order, pricing.Quote, error) {
+	// Define base prices
+	var basePrice float64
+	switch plan {
+	case model.PlanSilver:
+		basePrice = 100.0 // Base price for Silver plan
+	case model.PlanGold:
+		basePrice = 200.0 // Base price for Gold plan
+	default:
+		return nil, pricing.Quote{}, ErrInvalidPlan
+	}
+
+	order := &eligibility.Order{Plan: plan, Amount: basePrice}
+	candidates, err := s.candidates(customer.Id, voucherCodes, order)
+	if err != nil {
+		return nil, pricing.Quote{}, err
+	}
+	automatic, err := s.automaticCandidates(customer, order, time.Now())
+	if err != nil {
+		return nil, pricing.Quote{}, err
+	}
+
+	candidates = append(candidates, automatic...)
+	s.recordExposures(customer.Id, candidates)
+	return order, pricing.Best(plan, basePrice, candidates), nil
+}
+
+// recordExposures notes the variant each A/B tested candidate showed the
+// user, whether or not the pricing engine picks it. Failures are logged;
+// they only cost the test a data point.
+func (s *service) recordExposures(userID string, candidates []pricing.Candidate) {
+	for _, candidate := range candidates {
+		if candidate.Variant == nil {
+			continue
+		}
+		if err := s.campaigns.RecordExposure(candidate.Campaign.Id, candidate.Variant.Name, userID); err != nil {
+			s.logger.Errorf("Failed to record exposure of %s to campaign %s: %v", userID, candidate.Campaign.Id, err)
+		}
+	}
+}
+
+// candidates checks each voucher code against the order and pairs it with
+// its campaign. A code that cannot be used fails the whole purchase.
+func (s *service) candidates(userID string, voucherCodes []string, order *eligibility.Order) ([]pricing.Candidate, error) {
+	candidates := make([]pricing.Candidate, 0, len(voucherCodes))
+	for _, code := range voucherCodes {
+		v, err := s.voucherService.CheckVoucher(code, userID, order)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := s.campaigns.GetCampaignByID(v.CampaignID)
+		if err != nil {
+			s.logger.Errorf("Failed to get campaign %s of voucher %s: %v", v.CampaignID, code, err)
+			return nil, errors.New("failed to get campaign")
+		}
+		if c.IsExhausted() {
+			return nil, campaign.ErrBudgetExhausted
+		}
+		candidates = append(candidates, pricing.Candidate{Campaign: c, VoucherCode: code, Variant: pricing.VariantFor(c, userID)})
+	}
+	return candidates, nil
+}
+
+// automaticCandidates returns the running automatic campaigns whose
+// eligibility rules and condition the user and order meet
+func (s *service) automaticCandidates(customer *model.User, order *eligibility.Order, now time.Time) ([]pricing.Candidate, error) {
+	campaigns, err := s.campaigns.ListAutomaticCampaigns(now)
+	if err != nil {
+		s.logger.Errorf("Failed to list automatic campaigns: %v", err)
+		return nil, errors.New("failed to list automatic campaigns")
+	}
+
+	var candidates []pricing.Candidate
+	for i := range campaigns {
+		if err := s.eligibility.Check(campaigns[i].Id, customer, order); err != nil {
+			// A promotion that cannot be checked is left out rather than
+			// failing a purchase that never asked for it
+			if !errors.Is(err, eligibility.ErrNotEligible) {
+				s.logger.Errorf("Failed to check eligibility for campaign %s: %v", campaigns[i].Id, err)
+			}
+			continue
+		}
+		candidates = append(candidates, pricing.Candidate{Campaign: &campaigns[i], Variant: pricing.VariantFor(&campaigns[i], customer.Id)})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,31 @@
+package purchase
+
+import (
+	"trinity/internal/model"
+	"trinity/internal/pricing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the Service interface
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) ProcessPurchase(userID string, plan model.SubscriptionPlan, voucherCodes []string) (*model.Purchase, error) {
+	args := m.Called(userID, plan, voucherCodes)
+	purchase := args.Get(0)
+	if purchase == nil {
+		return nil, args.Error(1)
+	}
+	return purchase.(*model.Purchase), args.Error(1)
+}
+
+func (m *MockService) Quote(userID string, plan model.SubscriptionPlan, voucherCodes []string) (*pricing.Quote, error) {
+	args := m.Called(userID, plan, voucherCodes)
+	quote := args.Get(0)
+	if quote == nil {
+		return nil, args.Error(1)
+	}
+	return quote.(*pricing.Quote), args.Error(1)
+}
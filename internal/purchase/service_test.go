@@ -0,0 +1,342 @@
+package purchase
+
+import (
+	"errors"
+	"testing"
+	"trinity/internal/campaign"
+	"trinity/internal/eligibility"
+	"trinity/internal/model"
+	"trinity/internal/subscription"
+	"trinity/internal/user"
+	"trinity/internal/voucher"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestService_ProcessPurchase_RejectsOthersPersonalVoucher(t *testing.T) {
+	mockUserRepo := new(user.MockRepository)
+	mockVoucherService := new(voucher.MockService)
+	service := NewService(nil, mockVoucherService, nil, mockUserRepo, nil, nil)
+
+	mockUserRepo.On("GetUserByID", "intruder").Return(&model.User{Id: "intruder"}, nil)
+	mockVoucherService.On("CheckVoucher", "PERSONAL1X", "intruder", mock.AnythingOfType("*eligibility.Order")).Return(nil, voucher.ErrVoucherNotAssigned)
+
+	purchase, err := service.ProcessPurchase("intruder", model.PlanGold, []string{"PERSONAL1X"})
+
+	assert.ErrorIs(t, err, voucher.ErrVoucherNotAssigned, "The purchase should be refused")
+	assert.Nil(t, purchase, "No purchase should be created")
+}
+
+func TestService_ProcessPurchase_UserLookupFails(t *testing.T) {
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(nil, nil, nil, mockUserRepo, nil, nil)
+
+	dbErr := errors.New("database error")
+	mockUserRepo.On("GetUserByID", "user1").Return(nil, dbErr)
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, nil)
+
+	assert.ErrorIs(t, err, dbErr, "A failed lookup should not be reported as a missing user")
+	assert.NotErrorIs(t, err, user.ErrUserNotFound)
+	assert.Nil(t, purchase, "No purchase should be created")
+}
+
+func TestService_ProcessPurchase_VoucherHeldByAnotherUser(t *testing.T) {
+	mockUserRepo := new(user.MockRepository)
+	mockVoucherService := new(voucher.MockService)
+	service := NewService(nil, mockVoucherService, nil, mockUserRepo, nil, nil)
+
+	mockUserRepo.On("GetUserByID", "user2").Return(&model.User{Id: "user2"}, nil)
+	mockVoucherService.On("CheckVoucher", "HELD1X", "user2", mock.AnythingOfType("*eligibility.Order")).Return(nil, voucher.ErrVoucherReserved)
+
+	purchase, err := service.ProcessPurchase("user2", model.PlanSilver, []string{"HELD1X"})
+
+	assert.ErrorIs(t, err, voucher.ErrVoucherReserved, "The purchase should wait for the hold to expire")
+	assert.Nil(t, purchase, "No purchase should be created")
+}
+
+func TestService_ProcessPurchase_ChecksEligibilityAgainstOrder(t *testing.T) {
+	mockUserRepo := new(user.MockRepository)
+	mockVoucherService := new(voucher.MockService)
+	service := NewService(nil, mockVoucherService, nil, mockUserRepo, nil, nil)
+
+	order := &eligibility.Order{Plan: model.PlanSilver, Amount: 100}
+	mockUserRepo.On("GetUserByID", "user1").Return(&model.User{Id: "user1"}, nil)
+	mockVoucherService.On("CheckVoucher", "GOLDONLY1X", "user1", order).Return(nil, &eligibility.RuleError{Rule: eligibility.RulePlans})
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanSilver, []string{"GOLDONLY1X"})
+
+	assert.ErrorIs(t, err, eligibility.ErrNotEligible, "The purchase should be refused")
+	assert.Nil(t, purchase, "No purchase should be created")
+	mockVoucherService.AssertExpectations(t)
+}
+
+// checkout wires a service whose repositories accept every write and
+// whose running automatic campaigns are the ones given
+func checkout(vouchers *voucher.MockService, campaigns *campaign.MockRepository, checker *eligibility.MockChecker, automatic ...model.Campaign) Service {
+	campaigns.On("ListAutomaticCampaigns", mock.Anything).Return(automatic, nil)
+	userRepo := new(user.MockRepository)
+	userRepo.On("GetUserByID", "user1").Return(&model.User{Id: "user1"}, nil)
+	subscriptionRepo := new(subscription.MockRepository)
+	subscriptionRepo.On("CreateSubscription", mock.AnythingOfType("*model.Subscription")).Return(nil)
+	purchaseRepo := new(MockRepository)
+	purchaseRepo.On("CreatePurchase", mock.AnythingOfType("*model.Purchase")).Return(nil)
+	return NewService(purchaseRepo, vouchers, subscriptionRepo, userRepo, campaigns, checker)
+}
+
+// unbudgeted lets the campaigns take any spend
+func unbudgeted(campaigns *campaign.MockRepository) {
+	campaigns.On("AddSpend", mock.Anything, mock.Anything).Return(&model.Campaign{}, nil)
+	campaigns.On("RefundSpend", mock.Anything, mock.Anything).Return(nil).Maybe()
+}
+
+// offer registers a redeemable code of a campaign with the mocks
+func offer(vouchers *voucher.MockService, campaigns *campaign.MockRepository, code string, c *model.Campaign) {
+	vouchers.On("CheckVoucher", code, "user1", mock.Anything).Return(&model.Voucher{Code: code, CampaignID: c.Id}, nil)
+	vouchers.On("RedeemForOrder", code, "user1", mock.Anything).Return(&model.Voucher{Code: code, CampaignID: c.Id}, nil).Maybe()
+	campaigns.On("GetCampaignByID", c.Id).Return(c, nil)
+}
+
+func TestService_ProcessPurchase_StacksStackableCodes(t *testing.T) {
+	vouchers, campaigns := new(voucher.MockService), new(campaign.MockRepository)
+	service := checkout(vouchers, campaigns, nil)
+	unbudgeted(campaigns)
+
+	stackable := &model.StackingPolicy{Mode: model.StackStackable}
+	offer(vouchers, campaigns, "TEN1X", &model.Campaign{Id: "ten", Discount: 10, Stacking: stackable})
+	offer(vouchers, campaigns, "TWENTY1X", &model.Campaign{Id: "twenty", Discount: 20, Stacking: stackable})
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, []string{"TEN1X", "TWENTY1X"})
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Len(t, purchase.Discounts, 2, "Both stackable discounts should apply")
+	assert.InDelta(t, 144.0, purchase.Total, 0.001, "10% and 20% off 200 should leave 144")
+	vouchers.AssertCalled(t, "RedeemForOrder", "TEN1X", "user1", mock.Anything)
+	vouchers.AssertCalled(t, "RedeemForOrder", "TWENTY1X", "user1", mock.Anything)
+}
+
+func TestService_ProcessPurchase_RedeemsOnlyTheBestExclusiveCode(t *testing.T) {
+	vouchers, campaigns := new(voucher.MockService), new(campaign.MockRepository)
+	service := checkout(vouchers, campaigns, nil)
+	unbudgeted(campaigns)
+
+	offer(vouchers, campaigns, "SMALL1X", &model.Campaign{Id: "small", Discount: 10})
+	offer(vouchers, campaigns, "BIG1X", &model.Campaign{Id: "big", Discount: 30})
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanSilver, []string{"SMALL1X", "BIG1X"})
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, []model.DiscountLine{{CampaignID: "big", VoucherCode: "BIG1X", Percent: 30, Amount: 30}}, purchase.Discounts)
+	assert.Equal(t, 70.0, purchase.Total)
+	vouchers.AssertNotCalled(t, "RedeemForOrder", "SMALL1X", mock.Anything, mock.Anything)
+}
+
+func TestService_ProcessPurchase_AppliesAutomaticCampaign(t *testing.T) {
+	vouchers, campaigns, checker := new(voucher.MockService), new(campaign.MockRepository), new(eligibility.MockChecker)
+	weekend := model.Campaign{Id: "weekend", Discount: 20, Automatic: true}
+	service := checkout(vouchers, campaigns, checker, weekend)
+	unbudgeted(campaigns)
+
+	checker.On("Check", "weekend", mock.Anything, mock.Anything).Return(nil)
+	campaigns.On("ClaimRedemption", "weekend").Return(nil)
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, nil)
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, []model.DiscountLine{{CampaignID: "weekend", Automatic: true, Percent: 20, Amount: 40}}, purchase.Discounts)
+	assert.Equal(t, 160.0, purchase.Total)
+	campaigns.AssertExpectations(t)
+}
+
+func TestService_ProcessPurchase_SkipsIneligibleAutomaticCampaign(t *testing.T) {
+	vouchers, campaigns, checker := new(voucher.MockService), new(campaign.MockRepository), new(eligibility.MockChecker)
+	service := checkout(vouchers, campaigns, checker, model.Campaign{Id: "goldweekend", Discount: 20, Automatic: true})
+
+	checker.On("Check", "goldweekend", mock.Anything, mock.Anything).Return(&eligibility.RuleError{Rule: eligibility.RulePlans})
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanSilver, nil)
+
+	assert.NoError(t, err, "An automatic campaign the order misses should not fail it")
+	assert.Empty(t, purchase.Discounts)
+	assert.Equal(t, 100.0, purchase.Total)
+	campaigns.AssertNotCalled(t, "ClaimRedemption", mock.Anything)
+}
+
+func TestService_ProcessPurchase_AutomaticCampaignFilledMeanwhile(t *testing.T) {
+	vouchers, campaigns, checker := new(voucher.MockService), new(campaign.MockRepository), new(eligibility.MockChecker)
+	service := checkout(vouchers, campaigns, checker, model.Campaign{Id: "weekend", Discount: 20, Automatic: true})
+	unbudgeted(campaigns)
+
+	checker.On("Check", "weekend", mock.Anything, mock.Anything).Return(nil)
+	campaigns.On("ClaimRedemption", "weekend").Return(voucher.ErrCampaignFull)
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, nil)
+
+	assert.ErrorIs(t, err, voucher.ErrCampaignFull, "The purchase should not go through at a price it no longer gets")
+	assert.Nil(t, purchase)
+	campaigns.AssertCalled(t, "RefundSpend", "weekend", 40.0)
+}
+
+func TestService_Quote_DoesNotRedeem(t *testing.T) {
+	vouchers, campaigns, checker := new(voucher.MockService), new(campaign.MockRepository), new(eligibility.MockChecker)
+	service := checkout(vouchers, campaigns, checker, model.Campaign{Id: "weekend", Discount: 10, Automatic: true, Stacking: &model.StackingPolicy{Mode: model.StackStackable}})
+
+	checker.On("Check", "weekend", mock.Anything, mock.Anything).Return(nil)
+	offer(vouchers, campaigns, "EXTRA1X", &model.Campaign{Id: "extra", Discount: 50, Stacking: &model.StackingPolicy{Mode: model.StackStackable}})
+
+	quote, err := service.Quote("user1", model.PlanGold, []string{"EXTRA1X"})
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Len(t, quote.Lines, 2, "The code should stack with the automatic campaign")
+	assert.InDelta(t, 90.0, quote.Total, 0.001)
+	vouchers.AssertNotCalled(t, "RedeemForOrder", mock.Anything, mock.Anything, mock.Anything)
+	campaigns.AssertNotCalled(t, "ClaimRedemption", mock.Anything)
+}
+
+func TestService_ProcessPurchase_OverBudget(t *testing.T) {
+	vouchers, campaigns := new(voucher.MockService), new(campaign.MockRepository)
+	service := checkout(vouchers, campaigns, nil)
+
+	offer(vouchers, campaigns, "BUDGET1X", &model.Campaign{Id: "budgeted", Discount: 30, Budget: 1000, Spent: 990})
+	campaigns.On("AddSpend", "budgeted", 60.0).Return(nil, campaign.ErrBudgetExhausted)
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, []string{"BUDGET1X"})
+
+	assert.ErrorIs(t, err, campaign.ErrBudgetExhausted, "A discount over the budget should refuse the purchase")
+	assert.Nil(t, purchase)
+	campaigns.AssertNotCalled(t, "SetStatus", mock.Anything, mock.Anything)
+	vouchers.AssertNotCalled(t, "RedeemForOrder", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestService_ProcessPurchase_OverBudgetWhenFullySpent(t *testing.T) {
+	vouchers, campaigns := new(voucher.MockService), new(campaign.MockRepository)
+	service := checkout(vouchers, campaigns, nil)
+
+	// Another purchase spent the rest of the budget after this one was quoted
+	c := &model.Campaign{Id: "budgeted", Discount: 30, Budget: 1000, Spent: 990}
+	offer(vouchers, campaigns, "BUDGET1X", c)
+	campaigns.On("AddSpend", "budgeted", 60.0).Run(func(mock.Arguments) { c.Spent = 1000 }).Return(nil, campaign.ErrBudgetExhausted)
+	campaigns.On("SetStatus", "budgeted", model.CampaignExhausted).Return(nil)
+	campaigns.On("CreateAuditEntry", mock.AnythingOfType("*model.AuditEntry")).Return(nil)
+
+	_, err := service.ProcessPurchase("user1", model.PlanGold, []string{"BUDGET1X"})
+
+	assert.ErrorIs(t, err, campaign.ErrBudgetExhausted)
+	campaigns.AssertCalled(t, "SetStatus", "budgeted", model.CampaignExhausted)
+}
+
+func TestService_ProcessPurchase_UsesUpBudget(t *testing.T) {
+	vouchers, campaigns := new(voucher.MockService), new(campaign.MockRepository)
+	service := checkout(vouchers, campaigns, nil)
+
+	offer(vouchers, campaigns, "BUDGET1X", &model.Campaign{Id: "budgeted", Discount: 30, Budget: 1000, Spent: 940})
+	campaigns.On("AddSpend", "budgeted", 60.0).Return(&model.Campaign{Id: "budgeted", Budget: 1000, Spent: 1000}, nil)
+	campaigns.On("SetStatus", "budgeted", model.CampaignExhausted).Return(nil)
+	campaigns.On("CreateAuditEntry", mock.MatchedBy(func(entry *model.AuditEntry) bool {
+		return entry.CampaignID == "budgeted" && entry.Action == model.AuditStatusChange && entry.Actor == model.ActorSystem
+	})).Return(nil)
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, []string{"BUDGET1X"})
+
+	assert.NoError(t, err, "The purchase fitting the rest of the budget should go through")
+	assert.Equal(t, 140.0, purchase.Total)
+	campaigns.AssertCalled(t, "SetStatus", "budgeted", model.CampaignExhausted)
+	campaigns.AssertCalled(t, "CreateAuditEntry", mock.Anything)
+}
+
+func TestService_ProcessPurchase_ExhaustedCampaignCode(t *testing.T) {
+	vouchers, campaigns := new(voucher.MockService), new(campaign.MockRepository)
+	service := checkout(vouchers, campaigns, nil)
+
+	offer(vouchers, campaigns, "SPENT1X", &model.Campaign{Id: "spent", Discount: 30, Budget: 1000, Spent: 1000, Status: model.CampaignExhausted})
+
+	_, err := service.ProcessPurchase("user1", model.PlanGold, []string{"SPENT1X"})
+
+	assert.ErrorIs(t, err, campaign.ErrBudgetExhausted, "Codes of exhausted campaigns should be refused")
+	campaigns.AssertNotCalled(t, "AddSpend", mock.Anything, mock.Anything)
+}
+
+func TestService_ProcessPurchase_GivesTheUsersVariant(t *testing.T) {
+	vouchers, campaigns := new(voucher.MockService), new(campaign.MockRepository)
+	service := checkout(vouchers, campaigns, nil)
+	unbudgeted(campaigns)
+	campaigns.On("RecordExposure", "abtest", "treatment", "user1").Return(nil)
+
+	// A lone variant takes every user
+	offer(vouchers, campaigns, "AB1X", &model.Campaign{Id: "abtest", Discount: 15, Variants: []model.CampaignVariant{
+		{Name: "treatment", Weight: 1, Discount: 25},
+	}})
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, []string{"AB1X"})
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, "treatment", purchase.Discounts[0].Variant, "The purchase should record the variant")
+	assert.InDelta(t, 150.0, purchase.Total, 0.001, "The variant's 25% should replace the campaign's 15%")
+	campaigns.AssertCalled(t, "RecordExposure", "abtest", "treatment", "user1")
+}
+
+func TestService_ProcessPurchase_ReleasesCodesWhenALaterOneFails(t *testing.T) {
+	vouchers, campaigns := new(voucher.MockService), new(campaign.MockRepository)
+	service := checkout(vouchers, campaigns, nil)
+	unbudgeted(campaigns)
+
+	stackable := &model.StackingPolicy{Mode: model.StackStackable}
+	first := &model.Campaign{Id: "first", Discount: 20, Stacking: &model.StackingPolicy{Mode: model.StackStackable, Priority: 2}}
+	vouchers.On("CheckVoucher", "FIRST1X", "user1", mock.Anything).Return(&model.Voucher{Code: "FIRST1X", CampaignID: "first"}, nil)
+	vouchers.On("RedeemForOrder", "FIRST1X", "user1", mock.Anything).Return(&model.Voucher{Code: "FIRST1X", CampaignID: "first"}, nil)
+	campaigns.On("GetCampaignByID", "first").Return(first, nil)
+	vouchers.On("CheckVoucher", "SECOND1X", "user1", mock.Anything).Return(&model.Voucher{Code: "SECOND1X", CampaignID: "second"}, nil)
+	vouchers.On("RedeemForOrder", "SECOND1X", "user1", mock.Anything).Return(nil, voucher.ErrVoucherUsed)
+	campaigns.On("GetCampaignByID", "second").Return(&model.Campaign{Id: "second", Discount: 10, Stacking: stackable}, nil)
+	vouchers.On("CancelRedemption", "FIRST1X", "user1").Return(nil)
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, []string{"FIRST1X", "SECOND1X"})
+
+	assert.ErrorIs(t, err, voucher.ErrVoucherUsed)
+	assert.Nil(t, purchase)
+	vouchers.AssertCalled(t, "CancelRedemption", "FIRST1X", "user1")
+	vouchers.AssertNotCalled(t, "CancelRedemption", "SECOND1X", mock.Anything)
+}
+
+func TestService_ProcessPurchase_ReleasesCodesWhenAutomaticClaimFails(t *testing.T) {
+	vouchers, campaigns, checker := new(voucher.MockService), new(campaign.MockRepository), new(eligibility.MockChecker)
+	stackable := &model.StackingPolicy{Mode: model.StackStackable}
+	service := checkout(vouchers, campaigns, checker, model.Campaign{Id: "weekend", Discount: 20, Automatic: true, Stacking: stackable})
+	unbudgeted(campaigns)
+
+	checker.On("Check", "weekend", mock.Anything, mock.Anything).Return(nil)
+	offer(vouchers, campaigns, "CODE1X", &model.Campaign{Id: "code", Discount: 10, Stacking: stackable})
+	campaigns.On("ClaimRedemption", "weekend").Return(voucher.ErrCampaignFull)
+	vouchers.On("CancelRedemption", "CODE1X", "user1").Return(nil)
+
+	_, err := service.ProcessPurchase("user1", model.PlanGold, []string{"CODE1X"})
+
+	assert.ErrorIs(t, err, voucher.ErrCampaignFull)
+	vouchers.AssertCalled(t, "CancelRedemption", "CODE1X", "user1")
+	campaigns.AssertNotCalled(t, "ReleaseRedemption", mock.Anything)
+}
+
+func TestService_ProcessPurchase_ReleasesRedemptionsWhenPurchaseNotSaved(t *testing.T) {
+	vouchers, campaigns, checker := new(voucher.MockService), new(campaign.MockRepository), new(eligibility.MockChecker)
+	campaigns.On("ListAutomaticCampaigns", mock.Anything).Return([]model.Campaign{{Id: "weekend", Discount: 20, Automatic: true}}, nil)
+	unbudgeted(campaigns)
+	checker.On("Check", "weekend", mock.Anything, mock.Anything).Return(nil)
+	campaigns.On("ClaimRedemption", "weekend").Return(nil)
+	campaigns.On("ReleaseRedemption", "weekend").Return(nil)
+
+	userRepo := new(user.MockRepository)
+	userRepo.On("GetUserByID", "user1").Return(&model.User{Id: "user1"}, nil)
+	subscriptionRepo := new(subscription.MockRepository)
+	subscriptionRepo.On("CreateSubscription", mock.AnythingOfType("*model.Subscription")).Return(nil)
+	purchaseRepo := new(MockRepository)
+	purchaseRepo.On("CreatePurchase", mock.AnythingOfType("*model.Purchase")).Return(errors.New("write failed"))
+	service := NewService(purchaseRepo, vouchers, subscriptionRepo, userRepo, campaigns, checker)
+
+	purchase, err := service.ProcessPurchase("user1", model.PlanGold, nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, purchase)
+	campaigns.AssertCalled(t, "ReleaseRedemption", "weekend")
+	campaigns.AssertCalled(t, "RefundSpend", "weekend", 40.0)
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	_ "trinity/docs"
+	"trinity/internal/auth"
 	"trinity/internal/initialize"
 	"trinity/pkg/logger"
 
@@ -21,18 +22,38 @@ func SetupRouter(app *initialize.App) *gin.Engine {
 	// Register routes
 	api := router.Group("/")
 
+	// Routes below require a verified bearer token
+	authenticated := api.Group("/", app.Authenticator.Middleware())
+
 	// Campaign routes
-	campaignRoutes := api.Group("/campaigns")
-	app.CampaignHandler.RegisterRoutes(campaignRoutes)
+	campaignRoutes := authenticated.Group("/campaigns")
+	app.CampaignHandler.RegisterRoutes(campaignRoutes.Group("/", auth.RequirePermission(auth.PermViewCampaigns)))
+	app.CampaignHandler.RegisterAdminRoutes(campaignRoutes.Group("/", auth.RequirePermission(auth.PermManageCampaigns)))
+	app.CampaignHandler.RegisterVoucherRoutes(campaignRoutes.Group("/", auth.RequirePermission(auth.PermGenerateVouchers), auth.RequireCampaignScope("id")))
+
+	// Job routes
+	jobRoutes := authenticated.Group("/jobs", auth.RequirePermission(auth.PermGenerateVouchers))
+	app.JobHandler.RegisterRoutes(jobRoutes)
 
 	// Voucher routes
-	voucherRoutes := api.Group("/vouchers")
+	voucherRoutes := authenticated.Group("/vouchers", auth.RequirePermission(auth.PermRedeemVouchers))
 	app.VoucherHandler.RegisterRoutes(voucherRoutes)
+	app.VoucherHandler.RegisterAdminRoutes(authenticated.Group("/vouchers", auth.RequirePermission(auth.PermManageCampaigns)))
 
 	// Purchase routes
-	purchaseRoutes := api.Group("/purchases")
+	purchaseRoutes := authenticated.Group("/purchases", auth.RequirePermission(auth.PermPurchase))
 	app.PurchaseHandler.RegisterRoutes(purchaseRoutes)
 
+	// User routes
+	userRoutes := api.Group("/users")
+	app.UserHandler.RegisterRoutes(userRoutes)
+	app.UserHandler.RegisterAuthenticatedRoutes(authenticated.Group("/users"))
+	app.UserHandler.RegisterAdminRoutes(authenticated.Group("/users", auth.RequirePermission(auth.PermManageUsers)))
+
+	// API key routes
+	apiKeyRoutes := authenticated.Group("/api-keys", auth.RequirePermission(auth.PermManageAPIKeys))
+	app.APIKeyHandler.RegisterRoutes(apiKeyRoutes)
+
 	// Health check route
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "OK"})
@@ -0,0 +1,193 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"trinity/internal/apikey"
+	"trinity/internal/auth"
+	"trinity/internal/campaign"
+	"trinity/internal/initialize"
+	"trinity/internal/job"
+	"trinity/internal/model"
+	"trinity/internal/purchase"
+	"trinity/internal/user"
+	"trinity/internal/voucher"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTestRouter builds the real router on top of mocked services
+func setupTestRouter(t *testing.T) (*gin.Engine, *auth.Authenticator) {
+	gin.SetMode(gin.TestMode)
+
+	authenticator, err := auth.NewAuthenticator("test-secret", "", "trinity", time.Hour)
+	require.NoError(t, err)
+
+	campaignService := new(campaign.MockService)
+	campaignService.On("ListCampaigns").Return([]model.Campaign{}, nil)
+	campaignService.On("UpdateCampaign", mock.Anything, mock.Anything, mock.Anything).Return(nil, campaign.ErrInvalidCampaign)
+	campaignService.On("ListTemplates").Return([]model.CampaignTemplate{}, nil)
+	campaignService.On("GetVariantResults", mock.Anything).Return(nil, campaign.ErrNoVariants)
+	campaignService.On("GetHistory", mock.Anything).Return([]model.AuditEntry{}, nil)
+	userService := new(user.MockService)
+	userService.On("GetUser", mock.Anything).Return(&model.User{}, nil)
+	apiKeyService := new(apikey.MockService)
+	apiKeyService.On("VerifyKey", "trk_generate").Return(&model.APIKey{
+		Id:          "key1",
+		CampaignIDs: []string{"abc"},
+		Actions:     []string{string(auth.PermGenerateVouchers)},
+	}, nil)
+	apiKeyService.On("VerifyKey", "trk_redeem").Return(&model.APIKey{
+		Id:          "key2",
+		CampaignIDs: []string{"abc"},
+		Actions:     []string{string(auth.PermRedeemVouchers)},
+	}, nil)
+	apiKeyService.On("VerifyKey", mock.Anything).Return(nil, apikey.ErrKeyNotFound)
+	authenticator.SetKeyVerifier(apiKeyService)
+	voucherService := new(voucher.MockService)
+	voucherService.On("ViewVoucher", mock.Anything, mock.Anything, mock.Anything).Return(nil, voucher.ErrVoucherNotFound)
+	jobService := new(job.MockService)
+	jobService.On("GetJob", "abc").Return(&model.Job{Id: "abc", CampaignID: "abc"}, nil)
+	jobService.On("GetJob", "other").Return(&model.Job{Id: "other", CampaignID: "other"}, nil)
+
+	app := &initialize.App{
+		Authenticator:   authenticator,
+		CampaignHandler: campaign.NewHandler(campaignService),
+		VoucherHandler:  voucher.NewHandler(voucherService),
+		PurchaseHandler: purchase.NewHandler(new(purchase.MockService)),
+		UserHandler:     user.NewHandler(userService, authenticator),
+		APIKeyHandler:   apikey.NewHandler(apiKeyService),
+		JobHandler:      job.NewHandler(jobService),
+	}
+
+	return SetupRouter(app), authenticator
+}
+
+// Mutating routes are called with an empty JSON object, so a caller that
+// passes access control gets a validation error instead of reaching a service
+var routeAccess = []struct {
+	method  string
+	path    string
+	allowed []model.Role
+}{
+	{"GET", "/campaigns/", []model.Role{model.RoleAdmin, model.RoleMarketer, model.RoleSupport}},
+	{"POST", "/campaigns/", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"PATCH", "/campaigns/abc", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/campaigns/abc/clone", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"GET", "/campaigns/abc/results", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"GET", "/campaigns/abc/history", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"GET", "/campaigns/templates", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/campaigns/templates", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/campaigns/templates/monthly/campaigns", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/campaigns/abc/vouchers", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"GET", "/campaigns/abc/vouchers/export?format=pdf", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/campaigns/abc/vouchers/import", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/campaigns/abc/vouchers/shared", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/campaigns/abc/vouchers/revoke", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"GET", "/jobs/abc", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/vouchers/abc/revoke", []model.Role{model.RoleAdmin, model.RoleMarketer}},
+	{"POST", "/vouchers/redeem", []model.Role{model.RoleAdmin, model.RoleCustomer}},
+	{"POST", "/vouchers/reserve", []model.Role{model.RoleAdmin, model.RoleCustomer}},
+	{"GET", "/vouchers/abc", []model.Role{model.RoleAdmin, model.RoleCustomer}},
+	{"POST", "/purchases/", []model.Role{model.RoleAdmin, model.RoleCustomer}},
+	{"POST", "/purchases/quote", []model.Role{model.RoleAdmin, model.RoleCustomer}},
+	{"PUT", "/users/abc/role", []model.Role{model.RoleAdmin}},
+	{"POST", "/api-keys/", []model.Role{model.RoleAdmin}},
+	{"GET", "/users/me", []model.Role{model.RoleAdmin, model.RoleMarketer, model.RoleSupport, model.RoleCustomer}},
+}
+
+var allRoles = []model.Role{model.RoleAdmin, model.RoleMarketer, model.RoleSupport, model.RoleCustomer}
+
+func TestSetupRouter_RoleAccess(t *testing.T) {
+	router, authenticator := setupTestRouter(t)
+
+	for _, route := range routeAccess {
+		for _, role := range allRoles {
+			t.Run(fmt.Sprintf("%s %s as %s", route.method, route.path, role), func(t *testing.T) {
+				token, err := authenticator.IssueToken(&model.User{Id: "user123", Role: role})
+				require.NoError(t, err)
+
+				req, _ := http.NewRequest(route.method, route.path, bytes.NewBufferString("{}"))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", "Bearer "+token)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				allowed := false
+				for _, r := range route.allowed {
+					allowed = allowed || r == role
+				}
+				if allowed {
+					assert.NotEqual(t, http.StatusForbidden, w.Code, "Role should be allowed")
+					assert.NotEqual(t, http.StatusUnauthorized, w.Code, "Role should be authenticated")
+				} else {
+					assert.Equal(t, http.StatusForbidden, w.Code, "Role should be denied")
+					assert.Contains(t, w.Body.String(), "error", "Denied calls should carry a reason")
+				}
+			})
+		}
+	}
+}
+
+func TestSetupRouter_RequiresToken(t *testing.T) {
+	router, _ := setupTestRouter(t)
+
+	for _, route := range routeAccess {
+		req, _ := http.NewRequest(route.method, route.path, bytes.NewBufferString("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "%s %s should require a token", route.method, route.path)
+	}
+}
+
+func TestSetupRouter_PublicRoutes(t *testing.T) {
+	router, _ := setupTestRouter(t)
+
+	req, _ := http.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Health check should not require a token")
+}
+
+func TestSetupRouter_APIKeyScopes(t *testing.T) {
+	router, _ := setupTestRouter(t)
+
+	for _, tc := range []struct {
+		key      string
+		method   string
+		path     string
+		expected int
+	}{
+		{"trk_generate", "POST", "/campaigns/abc/vouchers", http.StatusBadRequest},
+		{"trk_generate", "POST", "/campaigns/abc/vouchers/revoke", http.StatusForbidden},
+		{"trk_generate", "POST", "/campaigns/other/vouchers", http.StatusForbidden},
+		{"trk_generate", "POST", "/vouchers/redeem", http.StatusForbidden},
+		{"trk_generate", "GET", "/jobs/abc", http.StatusOK},
+		{"trk_generate", "GET", "/jobs/other", http.StatusForbidden},
+		{"trk_redeem", "GET", "/jobs/abc", http.StatusForbidden},
+		{"trk_redeem", "POST", "/vouchers/redeem", http.StatusBadRequest},
+		{"trk_redeem", "POST", "/campaigns/abc/vouchers", http.StatusForbidden},
+		{"trk_redeem", "POST", "/campaigns/", http.StatusForbidden},
+		{"trk_redeem", "POST", "/purchases/", http.StatusForbidden},
+		{"trk_redeem", "POST", "/api-keys/", http.StatusForbidden},
+		{"trk_unknown", "POST", "/vouchers/redeem", http.StatusUnauthorized},
+	} {
+		req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(auth.APIKeyHeader, tc.key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.expected, w.Code, "%s %s with %s", tc.method, tc.path, tc.key)
+	}
+}
@@ -0,0 +1,17 @@
+package subscription
+
+import (
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateSubscription(subscription *model.Subscription) error {
+	args := m.Called(subscription)
+	return args.Error(0)
+}
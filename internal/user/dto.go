@@ -0,0 +1,30 @@
+package user
+
+import "trinity/internal/model"
+
+// RegisterRequest represents the request payload for registering a user
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+	// Locale is a BCP 47 language tag such as en-GB
+	Locale string `json:"locale,omitempty" binding:"omitempty,bcp47_language_tag"`
+	// Country is an ISO 3166-1 alpha-2 code such as GB
+	Country string `json:"country,omitempty" binding:"omitempty,iso3166_1_alpha2"`
+}
+
+// LoginRequest represents the request payload for logging in
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// UpdateRoleRequest represents the request payload for changing a user's role
+type UpdateRoleRequest struct {
+	Role model.Role `json:"role" binding:"required"`
+}
+
+// LoginResponse carries the issued access token and the logged-in user
+type LoginResponse struct {
+	Token string      `json:"token"`
+	User  *model.User `json:"user"`
+}
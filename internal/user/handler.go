@@ -0,0 +1,205 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"trinity/internal/auth"
+	"trinity/internal/model"
+	"trinity/pkg/logger"
+	"trinity/pkg/reason"
+	"trinity/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenIssuer issues access tokens for authenticated users
+type TokenIssuer interface {
+	IssueToken(user *model.User) (string, error)
+}
+
+// Handler handles user-related requests
+type Handler struct {
+	service Service
+	tokens  TokenIssuer
+	logger  logger.Logger
+}
+
+// NewHandler creates a new User handler
+func NewHandler(service Service, tokens TokenIssuer) *Handler {
+	return &Handler{
+		service: service,
+		tokens:  tokens,
+		logger:  logger.NewLogger("userHandler"),
+	}
+}
+
+// RegisterRoutes registers the public user routes with the Gin router
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/register", h.Register)
+	rg.POST("/login", h.Login)
+}
+
+// RegisterAuthenticatedRoutes registers the user routes that require a token
+func (h *Handler) RegisterAuthenticatedRoutes(rg *gin.RouterGroup) {
+	rg.GET("/me", h.Me)
+}
+
+// RegisterAdminRoutes registers the user management routes
+func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.PUT("/:id/role", h.UpdateRole)
+}
+
+// Register godoc
+// @Summary Register a new user
+// @Description Create a user account with email and password, and optionally the locale and country used by campaign eligibility rules
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param request body user.RegisterRequest true "Registration data"
+// @Success 201 {object} model.User
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /users/register [post]
+func (h *Handler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	user, err := h.service.Register(req.Email, req.Password, req.Locale, req.Country)
+	if err != nil {
+		if errors.Is(err, ErrEmailTaken) {
+			c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.EmailAlreadyRegistered.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Verify email and password and issue an access token
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Param request body user.LoginRequest true "Login data"
+// @Success 200 {object} user.LoginResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /users/login [post]
+func (h *Handler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	user, err := h.service.Login(req.Email, req.Password)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.InvalidCredentials.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	token, err := h.tokens.IssueToken(user)
+	if err != nil {
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, LoginResponse{Token: token, User: user})
+}
+
+// Me godoc
+// @Summary Get the current user
+// @Description Retrieve the profile of the calling user
+// @Tags User
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} model.User
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /users/me [get]
+func (h *Handler) Me(c *gin.Context) {
+	userID := auth.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.Unauthorized.Message()})
+		return
+	}
+
+	user, err := h.service.GetUser(userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.UserNotFound.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// UpdateRole godoc
+// @Summary Change a user's role
+// @Description Assign one of the admin, marketer, support or customer roles to a user
+// @Tags User
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param request body user.UpdateRoleRequest true "New role"
+// @Success 200 {object} model.User
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /users/{id}/role [put]
+func (h *Handler) UpdateRole(c *gin.Context) {
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	user, err := h.service.UpdateRole(c.Param("id"), req.Role)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidRole):
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: reason.InvalidRequest.Message()})
+		case errors.Is(err, ErrUserNotFound):
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.UserNotFound.Message()})
+		default:
+			msg := reason.InternalServerError.Message()
+			h.logger.Errorf("%s: %v", msg, err)
+			c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
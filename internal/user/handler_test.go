@@ -0,0 +1,155 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"trinity/internal/auth"
+	"trinity/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAuthenticator issues and verifies the tokens used by the tests
+var testAuthenticator, _ = auth.NewAuthenticator("test-secret", "", "trinity", time.Hour)
+
+// setupRouter initializes the Gin engine with the public and authenticated user routes
+func setupRouter(handler *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	handler.RegisterRoutes(r.Group("/users"))
+	handler.RegisterAuthenticatedRoutes(r.Group("/users", testAuthenticator.Middleware()))
+	return r
+}
+
+// bearer mints a token header for the given user
+func bearer(t *testing.T, userID string) map[string]string {
+	token, err := testAuthenticator.IssueToken(&model.User{Id: userID})
+	require.NoError(t, err)
+	return map[string]string{"Authorization": "Bearer " + token}
+}
+
+// performRequest sends a JSON request through the router
+func performRequest(r http.Handler, method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reqBody).Encode(body)
+	}
+	req, _ := http.NewRequest(method, path, &reqBody)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_Register_Success(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService, testAuthenticator))
+
+	user := &model.User{Id: "user123", Email: "alice@example.com", Password: "hash"}
+	mockService.On("Register", "alice@example.com", "s3cretpass", "", "").Return(user, nil)
+
+	w := performRequest(router, "POST", "/users/register", RegisterRequest{Email: "alice@example.com", Password: "s3cretpass"}, nil)
+
+	assert.Equal(t, http.StatusCreated, w.Code, "Expected status code 201")
+	assert.NotContains(t, w.Body.String(), "hash", "Password hash must not be serialized")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Register_EmailTaken(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService, testAuthenticator))
+
+	mockService.On("Register", "alice@example.com", "s3cretpass", "", "").Return(nil, ErrEmailTaken)
+
+	w := performRequest(router, "POST", "/users/register", RegisterRequest{Email: "alice@example.com", Password: "s3cretpass"}, nil)
+
+	assert.Equal(t, http.StatusConflict, w.Code, "Expected status code 409")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Register_ShortPassword(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService, testAuthenticator))
+
+	w := performRequest(router, "POST", "/users/register", RegisterRequest{Email: "alice@example.com", Password: "short"}, nil)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected status code 400")
+	mockService.AssertNotCalled(t, "Register")
+}
+
+func TestHandler_Login_Success(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService, testAuthenticator))
+
+	user := &model.User{Id: "user123", Email: "alice@example.com"}
+	mockService.On("Login", "alice@example.com", "s3cretpass").Return(user, nil)
+
+	w := performRequest(router, "POST", "/users/login", LoginRequest{Email: "alice@example.com", Password: "s3cretpass"}, nil)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status code 200")
+	var response LoginResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	claims, err := testAuthenticator.ParseToken(response.Token)
+	assert.NoError(t, err, "Issued token should verify")
+	assert.Equal(t, "user123", claims.Subject, "Token subject should be the user ID")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Login_InvalidCredentials(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService, testAuthenticator))
+
+	mockService.On("Login", "alice@example.com", "wrongpass").Return(nil, ErrInvalidCredentials)
+
+	w := performRequest(router, "POST", "/users/login", LoginRequest{Email: "alice@example.com", Password: "wrongpass"}, nil)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Expected status code 401")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Me_Success(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService, testAuthenticator))
+
+	user := &model.User{Id: "user123", Email: "alice@example.com"}
+	mockService.On("GetUser", "user123").Return(user, nil)
+
+	w := performRequest(router, "GET", "/users/me", nil, bearer(t, "user123"))
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status code 200")
+	var response model.User
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), "Expected no error unmarshaling response")
+	assert.Equal(t, user.Email, response.Email, "Expected email to match")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Me_NotFound(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService, testAuthenticator))
+
+	mockService.On("GetUser", "ghost").Return(nil, ErrUserNotFound)
+
+	w := performRequest(router, "GET", "/users/me", nil, bearer(t, "ghost"))
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "Expected status code 404")
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_Me_Unauthenticated(t *testing.T) {
+	mockService := new(MockService)
+	router := setupRouter(NewHandler(mockService, testAuthenticator))
+
+	w := performRequest(router, "GET", "/users/me", nil, nil)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Expected status code 401")
+	mockService.AssertNotCalled(t, "GetUser")
+}
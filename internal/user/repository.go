@@ -0,0 +1,105 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"trinity/internal/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrUserNotFound is returned when no user matches the lookup
+	ErrUserNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned when the email is already registered
+	ErrEmailTaken = errors.New("email already registered")
+)
+
+// Repository defines user data access methods
+type Repository interface {
+	CreateUser(user *model.User) error
+	GetUserByID(id string) (*model.User, error)
+	GetUserByEmail(email string) (*model.User, error)
+	UpdateRole(id string, role model.Role) error
+}
+
+// repository implements Repository interface
+type repository struct {
+	collection *mongo.Collection
+}
+
+// NewRepository creates a new User repository
+func NewRepository(db *mongo.Database) Repository {
+	return &repository{
+		collection: db.Collection("users"),
+	}
+}
+
+// CreateUser inserts a new user into the database
+func (r *repository) CreateUser(user *model.User) error {
+	result, err := r.collection.InsertOne(context.Background(), user)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+
+	oid, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return fmt.Errorf("failed to convert InsertedID to ObjectID")
+	}
+
+	user.Id = oid.Hex()
+	return nil
+}
+
+// GetUserByID retrieves a user by its ID
+func (r *repository) GetUserByID(id string) (*model.User, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	return r.findOne(bson.M{"_id": objID})
+}
+
+// GetUserByEmail retrieves a user by email address
+func (r *repository) GetUserByEmail(email string) (*model.User, error) {
+	return r.findOne(bson.M{"email": email})
+}
+
+// UpdateRole sets the role of a user
+func (r *repository) UpdateRole(id string, role model.Role) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	result, err := r.collection.UpdateOne(context.Background(), bson.M{"_id": objID}, bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *repository) findOne(filter bson.M) (*model.User, error) {
+	var user model.User
+	err := r.collection.FindOne(context.Background(), filter).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
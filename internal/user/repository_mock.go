@@ -0,0 +1,40 @@
+package user
+
+import (
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRepository is a mock implementation of the Repository interface
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateUser(user *model.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetUserByID(id string) (*model.User, error) {
+	args := m.Called(id)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*model.User), args.Error(1)
+}
+
+func (m *MockRepository) GetUserByEmail(email string) (*model.User, error) {
+	args := m.Called(email)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*model.User), args.Error(1)
+}
+
+func (m *MockRepository) UpdateRole(id string, role model.Role) error {
+	args := m.Called(id, role)
+	return args.Error(0)
+}
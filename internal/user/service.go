@@ -0,0 +1,161 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"trinity/internal/model"
+	"trinity/pkg/logger"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the email or password does not match
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidRole is returned when assigning an unknown role
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrAdminPasswordRequired is returned when the configured admin has no
+	// account yet and no password to create one with
+	ErrAdminPasswordRequired = errors.New("admin password required to create the admin account")
+)
+
+// Service defines user business logic methods
+type Service interface {
+	Register(email, password, locale, country string) (*model.User, error)
+	Login(email, password string) (*model.User, error)
+	GetUser(id string) (*model.User, error)
+	UpdateRole(id string, role model.Role) (*model.User, error)
+	CurrentRole(id string) (model.Role, bool, error)
+	EnsureAdmin(email, password string) error
+}
+
+// service implements Service interface
+type service struct {
+	repo   Repository
+	logger logger.Logger
+}
+
+// NewService creates a new User service
+func NewService(repo Repository) Service {
+	return &service{
+		repo:   repo,
+		logger: logger.NewLogger("userService"),
+	}
+}
+
+// Register creates a new user with a bcrypt-hashed password
+func (s *service) Register(email, password, locale, country string) (*model.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		s.logger.Errorf("Failed to hash password: %v", err)
+		return nil, err
+	}
+
+	user := &model.User{
+		Email:     normalizeEmail(email),
+		Password:  string(hash),
+		Role:      model.RoleCustomer,
+		Locale:    locale,
+		Country:   strings.ToUpper(country),
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.repo.CreateUser(user); err != nil {
+		if !errors.Is(err, ErrEmailTaken) {
+			s.logger.Errorf("Failed to create user: %v", err)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Login verifies the credentials and returns the matching user
+func (s *service) Login(email, password string) (*model.User, error) {
+	user, err := s.repo.GetUserByEmail(normalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		s.logger.Errorf("Failed to get user: %v", err)
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// GetUser retrieves a user by ID
+func (s *service) GetUser(id string) (*model.User, error) {
+	return s.repo.GetUserByID(id)
+}
+
+// UpdateRole assigns a new role to a user
+func (s *service) UpdateRole(id string, role model.Role) (*model.User, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+
+	if err := s.repo.UpdateRole(id, role); err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			s.logger.Errorf("Failed to update role: %v", err)
+		}
+		return nil, err
+	}
+
+	return s.repo.GetUserByID(id)
+}
+
+// CurrentRole returns the stored role of the user, which the auth
+// middleware applies instead of the role in the token. found is false if
+// no user has the ID.
+func (s *service) CurrentRole(id string) (model.Role, bool, error) {
+	user, err := s.repo.GetUserByID(id)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return "", false, nil
+		}
+		s.logger.Errorf("Failed to get user %s: %v", id, err)
+		return "", false, err
+	}
+	return user.Role, true, nil
+}
+
+// EnsureAdmin makes the user with the email an admin, registering them
+// with the password if they have no account yet. It seeds the first admin
+// at startup, after which admins assign roles through the API.
+func (s *service) EnsureAdmin(email, password string) error {
+	user, err := s.repo.GetUserByEmail(normalizeEmail(email))
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		s.logger.Errorf("Failed to get user: %v", err)
+		return err
+	}
+
+	if user == nil {
+		if password == "" {
+			return ErrAdminPasswordRequired
+		}
+		if user, err = s.Register(email, password, "", ""); err != nil {
+			return err
+		}
+	}
+	if user.Role == model.RoleAdmin {
+		return nil
+	}
+
+	if err := s.repo.UpdateRole(user.Id, model.RoleAdmin); err != nil {
+		s.logger.Errorf("Failed to update role: %v", err)
+		return err
+	}
+	s.logger.Infof("Granted the admin role to %s", user.Email)
+	return nil
+}
+
+// normalizeEmail lowercases and trims the email so the unique index is case-insensitive
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
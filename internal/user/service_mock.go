@@ -0,0 +1,58 @@
+package user
+
+import (
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockService is a mock implementation of the Service interface
+type MockService struct {
+	mock.Mock
+}
+
+func (m *MockService) Register(email, password, locale, country string) (*model.User, error) {
+	args := m.Called(email, password, locale, country)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*model.User), args.Error(1)
+}
+
+func (m *MockService) Login(email, password string) (*model.User, error) {
+	args := m.Called(email, password)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*model.User), args.Error(1)
+}
+
+func (m *MockService) GetUser(id string) (*model.User, error) {
+	args := m.Called(id)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*model.User), args.Error(1)
+}
+
+func (m *MockService) UpdateRole(id string, role model.Role) (*model.User, error) {
+	args := m.Called(id, role)
+	user := args.Get(0)
+	if user == nil {
+		return nil, args.Error(1)
+	}
+	return user.(*model.User), args.Error(1)
+}
+
+func (m *MockService) CurrentRole(id string) (model.Role, bool, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.Role), args.Bool(1), args.Error(2)
+}
+
+func (m *MockService) EnsureAdmin(email, password string) error {
+	args := m.Called(email, password)
+	return args.Error(0)
+}
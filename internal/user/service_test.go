@@ -0,0 +1,194 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestService_Register_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("CreateUser", mock.AnythingOfType("*model.User")).Return(nil)
+
+	user, err := service.Register("  Alice@Example.com ", "s3cretpass", "en-GB", "gb")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, "alice@example.com", user.Email, "Email should be normalized")
+	assert.NotEqual(t, "s3cretpass", user.Password, "Password should not be stored in plain text")
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("s3cretpass")), "Stored hash should match the password")
+	assert.Equal(t, model.RoleCustomer, user.Role, "New users should be customers")
+	assert.False(t, user.CreatedAt.IsZero(), "CreatedAt should be set")
+	assert.Equal(t, "GB", user.Country, "Country should be upper case")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Register_EmailTaken(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("CreateUser", mock.AnythingOfType("*model.User")).Return(ErrEmailTaken)
+
+	user, err := service.Register("alice@example.com", "s3cretpass", "", "")
+
+	assert.ErrorIs(t, err, ErrEmailTaken, "Expected ErrEmailTaken")
+	assert.Nil(t, user, "Expected no user to be returned")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Login_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("s3cretpass"), bcrypt.MinCost)
+	stored := &model.User{Id: "user123", Email: "alice@example.com", Password: string(hash)}
+	mockRepo.On("GetUserByEmail", "alice@example.com").Return(stored, nil)
+
+	user, err := service.Login("ALICE@example.com", "s3cretpass")
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, "user123", user.Id, "Expected the stored user")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Login_WrongPassword(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("s3cretpass"), bcrypt.MinCost)
+	stored := &model.User{Id: "user123", Email: "alice@example.com", Password: string(hash)}
+	mockRepo.On("GetUserByEmail", "alice@example.com").Return(stored, nil)
+
+	user, err := service.Login("alice@example.com", "wrongpass")
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "Expected ErrInvalidCredentials")
+	assert.Nil(t, user, "Expected no user to be returned")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_Login_UnknownEmail(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetUserByEmail", "nobody@example.com").Return(nil, ErrUserNotFound)
+
+	user, err := service.Login("nobody@example.com", "whatever")
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "Unknown emails should not be distinguishable from wrong passwords")
+	assert.Nil(t, user, "Expected no user to be returned")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_GetUser_RepositoryError(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetUserByID", "user123").Return(nil, errors.New("database error"))
+
+	user, err := service.GetUser("user123")
+
+	assert.Error(t, err, "Expected an error from repository")
+	assert.Nil(t, user, "Expected no user to be returned")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_UpdateRole_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("UpdateRole", "user123", model.RoleMarketer).Return(nil)
+	mockRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123", Role: model.RoleMarketer}, nil)
+
+	user, err := service.UpdateRole("user123", model.RoleMarketer)
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Equal(t, model.RoleMarketer, user.Role, "Expected the new role")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_UpdateRole_InvalidRole(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	user, err := service.UpdateRole("user123", model.Role("superuser"))
+
+	assert.ErrorIs(t, err, ErrInvalidRole, "Expected ErrInvalidRole")
+	assert.Nil(t, user, "Expected no user to be returned")
+	mockRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+}
+
+func TestService_CurrentRole(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123", Role: model.RoleSupport}, nil)
+	mockRepo.On("GetUserByID", "ghost").Return(nil, ErrUserNotFound)
+
+	role, found, err := service.CurrentRole("user123")
+	assert.NoError(t, err, "Expected no error")
+	assert.True(t, found)
+	assert.Equal(t, model.RoleSupport, role, "Expected the stored role")
+
+	_, found, err = service.CurrentRole("ghost")
+	assert.NoError(t, err, "A missing user should not be an error")
+	assert.False(t, found)
+}
+
+func TestService_EnsureAdmin_PromotesExistingUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetUserByEmail", "ops@example.com").Return(&model.User{Id: "user123", Email: "ops@example.com", Role: model.RoleCustomer}, nil)
+	mockRepo.On("UpdateRole", "user123", model.RoleAdmin).Return(nil)
+
+	err := service.EnsureAdmin(" Ops@Example.com", "")
+
+	assert.NoError(t, err, "Expected no error")
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestService_EnsureAdmin_CreatesAccount(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetUserByEmail", "ops@example.com").Return(nil, ErrUserNotFound)
+	mockRepo.On("CreateUser", mock.AnythingOfType("*model.User")).Run(func(args mock.Arguments) {
+		args.Get(0).(*model.User).Id = "user123"
+	}).Return(nil)
+	mockRepo.On("UpdateRole", "user123", model.RoleAdmin).Return(nil)
+
+	err := service.EnsureAdmin("ops@example.com", "s3cretpass")
+
+	assert.NoError(t, err, "Expected no error")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestService_EnsureAdmin_NoPassword(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetUserByEmail", "ops@example.com").Return(nil, ErrUserNotFound)
+
+	err := service.EnsureAdmin("ops@example.com", "")
+
+	assert.ErrorIs(t, err, ErrAdminPasswordRequired, "An account cannot be created without a password")
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestService_EnsureAdmin_AlreadyAdmin(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo)
+
+	mockRepo.On("GetUserByEmail", "ops@example.com").Return(&model.User{Id: "user123", Role: model.RoleAdmin}, nil)
+
+	err := service.EnsureAdmin("ops@example.com", "")
+
+	assert.NoError(t, err, "Expected no error")
+	mockRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,30 @@
+package voucher
+
+import (
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCampaignSource is a mock implementation of the CampaignSource interface
+type MockCampaignSource struct {
+	mock.Mock
+}
+
+func (m *MockCampaignSource) GetCampaignByID(id string) (*model.Campaign, error) {
+	args := m.Called(id)
+	if campaign, ok := args.Get(0).(*model.Campaign); ok {
+		return campaign, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCampaignSource) ClaimRedemption(campaignID string) error {
+	args := m.Called(campaignID)
+	return args.Error(0)
+}
+
+func (m *MockCampaignSource) ReleaseRedemption(campaignID string) error {
+	args := m.Called(campaignID)
+	return args.Error(0)
+}
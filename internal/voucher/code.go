@@ -0,0 +1,330 @@
+package voucher
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"trinity/internal/model"
+	"unicode"
+)
+
+const (
+	// SafeAlphabet leaves out characters that are easily confused: 0/O and 1/I/L
+	SafeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	// HexAlphabet is the alphabet of the original 10-character codes
+	HexAlphabet = "0123456789ABCDEF"
+
+	// checkAlphabet assigns the values used by the check character; every
+	// code alphabet is a subset of it, so codes can be verified without
+	// knowing their campaign
+	checkAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// legacyCodeLength is the length of codes generated before check characters
+	legacyCodeLength = 10
+
+	// MaxCodeLength bounds the body of a code, including its check character
+	MaxCodeLength = 32
+	// MaxImportedCodeLength bounds externally supplied codes, including any
+	// prefix and separators
+	MaxImportedCodeLength = 64
+	// MaxCollisionProbability is the highest accepted chance that a newly
+	// generated code collides with an existing one once the campaign is full
+	MaxCollisionProbability = 0.001
+	// maxCheckDraws bounds how often a body is drawn again because its check
+	// character falls outside the code alphabet
+	maxCheckDraws = 1000
+)
+
+var (
+	// ErrInvalidCodeFormat is returned for malformed code format settings
+	ErrInvalidCodeFormat = errors.New("invalid code format")
+	// ErrCodeSpaceTooSmall is returned when a format cannot supply enough distinct codes
+	ErrCodeSpaceTooSmall = errors.New("code format too small for campaign size")
+	// ErrCodeMistyped is returned when a code's check character does not match
+	ErrCodeMistyped = errors.New("voucher code mistyped")
+	// ErrMalformedCode is returned for externally supplied codes that could
+	// not have been generated by any code format
+	ErrMalformedCode = errors.New("malformed voucher code")
+)
+
+// DefaultCodeFormat produces 10 random uppercase hex characters followed
+// by a check character
+var DefaultCodeFormat = model.CodeFormat{
+	Length:   11,
+	Alphabet: HexAlphabet,
+}
+
+// FormatOrDefault returns the campaign's format, or the default if unset
+func FormatOrDefault(format *model.CodeFormat) model.CodeFormat {
+	if format == nil {
+		return DefaultCodeFormat
+	}
+	return *format
+}
+
+// ValidateFormat checks that the format settings are usable
+func ValidateFormat(format model.CodeFormat) error {
+	if format.Length < 2 || format.Length > MaxCodeLength {
+		return fmt.Errorf("%w: length must be between 2 and %d", ErrInvalidCodeFormat, MaxCodeLength)
+	}
+	if len(format.Alphabet) < 2 {
+		return fmt.Errorf("%w: alphabet needs at least 2 characters", ErrInvalidCodeFormat)
+	}
+	seen := make(map[rune]bool)
+	for _, r := range format.Alphabet {
+		if !isCodeChar(r) {
+			return fmt.Errorf("%w: alphabet may only contain 0-9 and A-Z, got %q", ErrInvalidCodeFormat, r)
+		}
+		if seen[r] {
+			return fmt.Errorf("%w: alphabet repeats %q", ErrInvalidCodeFormat, r)
+		}
+		seen[r] = true
+	}
+	for _, r := range format.Prefix {
+		if !isCodeChar(r) {
+			return fmt.Errorf("%w: prefix may only contain 0-9 and A-Z, got %q", ErrInvalidCodeFormat, r)
+		}
+	}
+	if format.GroupSize < 0 {
+		return fmt.Errorf("%w: group size must not be negative", ErrInvalidCodeFormat)
+	}
+	if len(format.Separator) > 1 || strings.IndexFunc(format.Separator, isCodeChar) >= 0 {
+		return fmt.Errorf("%w: separator must be a single non-alphanumeric character", ErrInvalidCodeFormat)
+	}
+	if !checkReachable(format) {
+		return fmt.Errorf("%w: no code of this alphabet and prefix can end in a check character from the alphabet", ErrInvalidCodeFormat)
+	}
+	return nil
+}
+
+// checkReachable reports whether any body of the format has a check
+// character within the code alphabet. It follows the Luhn sum modulo the
+// check alphabet size through the body, position by position.
+func checkReachable(format model.CodeFormat) bool {
+	n := len(checkAlphabet)
+	bodyLength := format.Length - 1
+
+	// The prefix's rightmost character sits bodyLength places from the right
+	prefix, _ := checkValues(format.Prefix)
+	sums := make([]bool, n)
+	sums[luhnSum(prefix, bodyLength%2 == 0)%n] = true
+
+	for position := 0; position < bodyLength; position++ {
+		next := make([]bool, n)
+		for sum, ok := range sums {
+			if !ok {
+				continue
+			}
+			for _, r := range format.Alphabet {
+				addend := strings.IndexRune(checkAlphabet, r)
+				if position%2 == 0 {
+					addend *= 2
+					addend = addend/n + addend%n
+				}
+				next[(sum+addend)%n] = true
+			}
+		}
+		sums = next
+	}
+
+	for sum, ok := range sums {
+		if ok && strings.IndexByte(format.Alphabet, checkAlphabet[(n-sum)%n]) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CollisionProbability estimates the chance that a newly generated code
+// collides with one of campaignSize existing codes. The check character
+// adds no entropy, so only the random characters count.
+func CollisionProbability(format model.CodeFormat, campaignSize int) float64 {
+	logSpace := float64(format.Length-1) * math.Log(float64(len(format.Alphabet)))
+	return math.Min(1, math.Exp(math.Log(float64(campaignSize))-logSpace))
+}
+
+// CheckCapacity refuses formats whose code space is too small for the
+// number of codes the campaign will hold
+func CheckCapacity(format model.CodeFormat, campaignSize int) error {
+	if campaignSize <= 0 {
+		return nil
+	}
+	if p := CollisionProbability(format, campaignSize); p > MaxCollisionProbability {
+		return fmt.Errorf("%w: %d codes of %d characters from a %d character alphabet collide with probability %.4g",
+			ErrCodeSpaceTooSmall, campaignSize, format.Length, len(format.Alphabet), p)
+	}
+	return nil
+}
+
+// GenerateCode generates a random code in the given format. The last
+// character of the body is a check character over the prefix and body.
+func GenerateCode(format model.CodeFormat) (string, error) {
+	for draw := 0; draw < maxCheckDraws; draw++ {
+		body, err := randomString(format.Alphabet, format.Length-1)
+		if err != nil {
+			return "", err
+		}
+
+		// The check character must come from the code alphabet; bodies whose
+		// check value falls outside it are drawn again
+		check := checkCharacter(format.Prefix + body)
+		if strings.IndexByte(format.Alphabet, check) < 0 {
+			continue
+		}
+		return assembleCode(format, body+string(check)), nil
+	}
+	return "", fmt.Errorf("%w: no check character from the alphabet after %d draws", ErrInvalidCodeFormat, maxCheckDraws)
+}
+
+// WithCheckCharacter appends the check character to a hand-picked code
+func WithCheckCharacter(code string) string {
+	return code + string(checkCharacter(code))
+}
+
+// ValidateCodeSyntax checks an externally supplied code: uppercase letters
+// and digits, optionally grouped with single '-' separators
+func ValidateCodeSyntax(code string) error {
+	if code == "" || len(code) > MaxImportedCodeLength {
+		return fmt.Errorf("%w: length must be between 1 and %d", ErrMalformedCode, MaxImportedCodeLength)
+	}
+	for _, group := range strings.Split(code, "-") {
+		if group == "" {
+			return fmt.Errorf("%w: separators must sit between characters", ErrMalformedCode)
+		}
+		if i := strings.IndexFunc(group, func(r rune) bool { return !isCodeChar(r) }); i >= 0 {
+			return fmt.Errorf("%w: only 0-9, A-Z and '-' are allowed, got %q", ErrMalformedCode, group[i:i+1])
+		}
+	}
+	return nil
+}
+
+// ValidateImportedCode checks an externally supplied code against the
+// campaign's format: it must start with the prefix and draw the rest of
+// its characters from the alphabet. Without a format only the syntax is
+// checked.
+func ValidateImportedCode(code string, format *model.CodeFormat) error {
+	if err := ValidateCodeSyntax(code); err != nil {
+		return err
+	}
+	if format == nil {
+		return nil
+	}
+	body, ok := strings.CutPrefix(code, format.Prefix)
+	if !ok {
+		return fmt.Errorf("%w: must start with %q", ErrMalformedCode, format.Prefix)
+	}
+	for _, r := range body {
+		if r != '-' && !strings.ContainsRune(format.Alphabet, r) {
+			return fmt.Errorf("%w: %q is not in the campaign's alphabet", ErrMalformedCode, r)
+		}
+	}
+	return nil
+}
+
+// ValidateCode rejects codes whose check character does not match, so
+// typos are caught before the database is queried
+func ValidateCode(code string) error {
+	values, ok := checkValues(code)
+	if !ok || len(values) < 2 || luhnSum(values, false)%len(checkAlphabet) != 0 {
+		return ErrCodeMistyped
+	}
+	return nil
+}
+
+// checkCharacter computes the Luhn mod N check character of a code
+func checkCharacter(code string) byte {
+	values, _ := checkValues(code)
+	n := len(checkAlphabet)
+	return checkAlphabet[(n-luhnSum(values, true)%n)%n]
+}
+
+// luhnSum is the Luhn mod N sum over values, starting from the right.
+// Doubling starts at the rightmost value when computing a check character
+// and at the second rightmost when validating a code that carries one.
+func luhnSum(values []int, doubleFirst bool) int {
+	n := len(checkAlphabet)
+	sum := 0
+	double := doubleFirst
+	for i := len(values) - 1; i >= 0; i-- {
+		addend := values[i]
+		if double {
+			addend *= 2
+			addend = addend/n + addend%n
+		}
+		sum += addend
+		double = !double
+	}
+	return sum
+}
+
+// checkValues maps the code's characters to check values, ignoring
+// separators and case
+func checkValues(code string) ([]int, bool) {
+	values := make([]int, 0, len(code))
+	for _, r := range strings.ToUpper(code) {
+		switch {
+		case isCodeChar(r):
+			values = append(values, strings.IndexRune(checkAlphabet, r))
+		case r > unicode.MaxASCII:
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// hasLegacyShape reports whether the code has the shape of the original
+// 10 uppercase hex character codes, which carry no check character. Codes
+// of custom formats can share the shape, so only the voucher's Legacy flag
+// tells them apart.
+func hasLegacyShape(code string) bool {
+	if len(code) != legacyCodeLength {
+		return false
+	}
+	for _, r := range code {
+		if strings.IndexRune(HexAlphabet, r) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// assembleCode adds the prefix and groups the body with the separator
+func assembleCode(format model.CodeFormat, body string) string {
+	parts := make([]string, 0, 1+len(body))
+	if format.Prefix != "" {
+		parts = append(parts, format.Prefix)
+	}
+	if format.GroupSize > 0 {
+		for start := 0; start < len(body); start += format.GroupSize {
+			end := min(start+format.GroupSize, len(body))
+			parts = append(parts, body[start:end])
+		}
+	} else {
+		parts = append(parts, body)
+	}
+	return strings.Join(parts, format.Separator)
+}
+
+// randomString draws length characters uniformly from alphabet
+func randomString(alphabet string, length int) (string, error) {
+	// Reject bytes above the largest multiple of the alphabet size to avoid modulo bias
+	limit := 256 - 256%len(alphabet)
+	result := make([]byte, 0, length)
+	buf := make([]byte, length*2)
+	for len(result) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(result) < length {
+				result = append(result, alphabet[int(b)%len(alphabet)])
+			}
+		}
+	}
+	return string(result), nil
+}
+
+func isCodeChar(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z')
+}
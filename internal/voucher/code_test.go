@@ -0,0 +1,174 @@
+package voucher
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateCode_GroupedWithPrefix(t *testing.T) {
+	format := model.CodeFormat{Prefix: "SUMMER", Length: 8, Alphabet: SafeAlphabet, GroupSize: 4, Separator: "-"}
+
+	code, err := GenerateCode(format)
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Regexp(t, regexp.MustCompile(`^SUMMER-[`+SafeAlphabet+`]{4}-[`+SafeAlphabet+`]{4}$`), code, "Code should follow the format")
+}
+
+func TestGenerateCode_DefaultFormat(t *testing.T) {
+	code, err := GenerateCode(DefaultCodeFormat)
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Regexp(t, regexp.MustCompile(`^[0-9A-F]{11}$`), code, "Default codes should be 10 uppercase hex characters and a check character")
+	assert.NoError(t, ValidateCode(code), "Default codes should carry a valid check character")
+}
+
+func TestGenerateCode_UnevenGroups(t *testing.T) {
+	format := model.CodeFormat{Length: 7, Alphabet: SafeAlphabet, GroupSize: 3, Separator: " "}
+
+	code, err := GenerateCode(format)
+
+	assert.NoError(t, err, "Expected no error")
+	assert.Len(t, strings.Split(code, " "), 3, "A trailing partial group should be kept")
+}
+
+func TestSafeAlphabet_ExcludesAmbiguousCharacters(t *testing.T) {
+	for _, ambiguous := range "0O1IL" {
+		assert.NotContains(t, SafeAlphabet, string(ambiguous), "Safe alphabet should not contain %q", ambiguous)
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	valid := model.CodeFormat{Prefix: "SUMMER", Length: 8, Alphabet: SafeAlphabet, GroupSize: 4, Separator: "-"}
+	assert.NoError(t, ValidateFormat(valid), "Expected a valid format")
+
+	for name, format := range map[string]model.CodeFormat{
+		"zero length":          {Length: 0, Alphabet: SafeAlphabet},
+		"too long":             {Length: MaxCodeLength + 1, Alphabet: SafeAlphabet},
+		"single char alphabet": {Length: 8, Alphabet: "A"},
+		"lowercase alphabet":   {Length: 8, Alphabet: "abc"},
+		"repeated alphabet":    {Length: 8, Alphabet: "AAB"},
+		"prefix with dash":     {Prefix: "SUM-MER", Length: 8, Alphabet: SafeAlphabet},
+		"alphanumeric sep":     {Length: 8, Alphabet: SafeAlphabet, GroupSize: 4, Separator: "X"},
+		"negative group size":  {Length: 8, Alphabet: SafeAlphabet, GroupSize: -1},
+		"unreachable check":    {Length: 8, Alphabet: "YZ"},
+	} {
+		assert.ErrorIs(t, ValidateFormat(format), ErrInvalidCodeFormat, "Expected %s to be rejected", name)
+	}
+}
+
+func TestValidateFormat_CheckCharacterReachable(t *testing.T) {
+	// Every accepted format must be able to generate a code
+	for _, format := range []model.CodeFormat{
+		DefaultCodeFormat,
+		{Length: 8, Alphabet: SafeAlphabet},
+		{Prefix: "X", Length: 6, Alphabet: "AB"},
+		{Length: 2, Alphabet: "01"},
+	} {
+		if ValidateFormat(format) != nil {
+			continue
+		}
+		code, err := GenerateCode(format)
+		assert.NoError(t, err, "Expected %+v to generate a code", format)
+		assert.NoError(t, ValidateCode(code))
+	}
+}
+
+func TestGenerateCode_UnreachableCheckCharacter(t *testing.T) {
+	_, err := GenerateCode(model.CodeFormat{Length: 8, Alphabet: "YZ"})
+
+	assert.ErrorIs(t, err, ErrInvalidCodeFormat, "Generation should give up instead of looping forever")
+}
+
+func TestCheckCapacity(t *testing.T) {
+	small := model.CodeFormat{Length: 5, Alphabet: SafeAlphabet}
+	assert.ErrorIs(t, CheckCapacity(small, 10000), ErrCodeSpaceTooSmall, "31^4 codes are too few for 10,000 vouchers")
+	assert.NoError(t, CheckCapacity(small, 100), "31^4 codes are enough for 100 vouchers")
+
+	assert.NoError(t, CheckCapacity(DefaultCodeFormat, 1000000), "The default format should hold a million vouchers")
+}
+
+func TestGenerateCode_CarriesCheckCharacter(t *testing.T) {
+	format := model.CodeFormat{Prefix: "SUMMER", Length: 8, Alphabet: SafeAlphabet, GroupSize: 4, Separator: "-"}
+
+	for i := 0; i < 200; i++ {
+		code, err := GenerateCode(format)
+		assert.NoError(t, err, "Expected no error")
+		assert.NoError(t, ValidateCode(code), "Generated code %s should validate", code)
+	}
+}
+
+func TestValidateCode_DetectsSingleSubstitutions(t *testing.T) {
+	code := WithCheckCharacter("SUMMER7KQ2MX9")
+	assert.NoError(t, ValidateCode(code), "Expected the original code to validate")
+
+	for i := range code {
+		for _, r := range checkAlphabet {
+			if byte(r) == code[i] {
+				continue
+			}
+			typo := code[:i] + string(r) + code[i+1:]
+			assert.ErrorIs(t, ValidateCode(typo), ErrCodeMistyped, "Substitution %s should be detected", typo)
+		}
+	}
+}
+
+func TestValidateCode_DetectsAdjacentTranspositions(t *testing.T) {
+	code := WithCheckCharacter("7KQ2MX9P")
+
+	for i := 0; i+1 < len(code); i++ {
+		if code[i] == code[i+1] {
+			continue
+		}
+		swapped := code[:i] + string(code[i+1]) + string(code[i]) + code[i+2:]
+		assert.ErrorIs(t, ValidateCode(swapped), ErrCodeMistyped, "Transposition %s should be detected", swapped)
+	}
+}
+
+func TestValidateCode_IgnoresSeparatorsAndCase(t *testing.T) {
+	code := WithCheckCharacter("SUMMER7KQ2MX9")
+	grouped := code[:6] + "-" + code[6:10] + "-" + code[10:]
+
+	assert.NoError(t, ValidateCode(grouped), "Separators should not affect the check")
+	assert.NoError(t, ValidateCode(strings.ToLower(grouped)), "Case should not affect the check")
+}
+
+func TestValidateCode_HexCodesOfLegacyShape(t *testing.T) {
+	// A custom format can produce codes shaped like the check-less legacy
+	// codes; those still carry a check character that is verified
+	code, err := GenerateCode(model.CodeFormat{Length: 10, Alphabet: HexAlphabet})
+	assert.NoError(t, err, "Expected no error")
+	assert.True(t, hasLegacyShape(code), "Expected %s to share the legacy shape", code)
+	assert.NoError(t, ValidateCode(code), "Expected the generated code to validate")
+
+	for _, r := range HexAlphabet {
+		if byte(r) == code[9] {
+			continue
+		}
+		typo := code[:9] + string(r)
+		assert.ErrorIs(t, ValidateCode(typo), ErrCodeMistyped, "A mistyped check character in %s should be detected", typo)
+	}
+	assert.ErrorIs(t, ValidateCode(""), ErrCodeMistyped, "Empty codes should be rejected")
+}
+
+func TestValidateCodeSyntax(t *testing.T) {
+	for _, code := range []string{"PARTNER1", "SUMMER-AB12-CD34", "X"} {
+		assert.NoError(t, ValidateCodeSyntax(code), "%s should be accepted", code)
+	}
+	for _, code := range []string{"", "partner1", "SUMMER--AB12", "-AB12", "AB 12", "AB,12", strings.Repeat("A", MaxImportedCodeLength+1)} {
+		assert.ErrorIs(t, ValidateCodeSyntax(code), ErrMalformedCode, "%q should be rejected", code)
+	}
+}
+
+func TestValidateImportedCode(t *testing.T) {
+	format := &model.CodeFormat{Prefix: "SUMMER", Length: 8, Alphabet: SafeAlphabet, GroupSize: 4, Separator: "-"}
+
+	assert.NoError(t, ValidateImportedCode("SUMMER-7KQ2-MX9P", format), "Codes of the format should be accepted")
+	assert.NoError(t, ValidateImportedCode("PARTNER10", nil), "Without a format any well-formed code should be accepted")
+	for _, code := range []string{"WINTER-7KQ2-MX9P", "SUMMER-7KQ0-MX9P", "summer-7KQ2-MX9P"} {
+		assert.ErrorIs(t, ValidateImportedCode(code, format), ErrMalformedCode, "%q should be rejected", code)
+	}
+}
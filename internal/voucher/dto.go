@@ -2,6 +2,18 @@ package voucher
 
 // RedeemVoucherRequest represents the request payload for redeeming a voucher
 type RedeemVoucherRequest struct {
-	Code   string `json:"code" binding:"required"`
-	UserId string `json:"user_id" binding:"required"`
+	Code string `json:"code" binding:"required"`
+	// UserId names the customer when a partner redeems with an API key;
+	// it is ignored for user tokens
+	UserId string `json:"user_id,omitempty"`
+}
+
+// RevokeVoucherRequest represents the request payload for revoking a voucher
+type RevokeVoucherRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ReserveVoucherRequest represents the request payload for reserving a voucher
+type ReserveVoucherRequest struct {
+	Code string `json:"code" binding:"required"`
 }
@@ -0,0 +1,154 @@
+package voucher
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"time"
+	"trinity/internal/model"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExportFormat is a file format vouchers can be exported in
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "csv"
+	ExportXLSX ExportFormat = "xlsx"
+)
+
+// ErrUnsupportedExportFormat is returned for export formats other than CSV and XLSX
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// exportHeader names the columns of an export
+var exportHeader = []string{"code", "status", "expiry_date"}
+
+// ParseExportFormat validates a requested export format
+func ParseExportFormat(format string) (ExportFormat, error) {
+	switch f := ExportFormat(format); f {
+	case ExportCSV, ExportXLSX:
+		return f, nil
+	}
+	return "", ErrUnsupportedExportFormat
+}
+
+// ContentType returns the MIME type of the export format
+func (f ExportFormat) ContentType() string {
+	if f == ExportXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv"
+}
+
+// ExportWriter writes vouchers one row at a time. Close must be called to
+// complete the file.
+type ExportWriter interface {
+	Write(voucher model.Voucher) error
+	Close() error
+}
+
+// NewExportWriter creates a writer for the format that streams rows to w
+func NewExportWriter(format ExportFormat, w io.Writer) (ExportWriter, error) {
+	switch format {
+	case ExportCSV:
+		return newCSVExportWriter(w)
+	case ExportXLSX:
+		return newXLSXExportWriter(w)
+	}
+	return nil, ErrUnsupportedExportFormat
+}
+
+// VoucherStatus describes whether a voucher can still be redeemed
+func VoucherStatus(voucher model.Voucher, now time.Time) string {
+	switch {
+	case voucher.IsRevoked():
+		return "revoked"
+	case voucher.Used:
+		return "used"
+	case now.After(voucher.ExpiryDate):
+		return "expired"
+	}
+	return "active"
+}
+
+// exportRow formats a voucher as the columns of exportHeader
+func exportRow(voucher model.Voucher, now time.Time) []string {
+	return []string{voucher.Code, VoucherStatus(voucher, now), voucher.ExpiryDate.UTC().Format(time.RFC3339)}
+}
+
+// csvExportWriter writes vouchers through a buffered CSV writer, which
+// flushes to the response as its buffer fills
+type csvExportWriter struct {
+	writer *csv.Writer
+	now    time.Time
+}
+
+func newCSVExportWriter(w io.Writer) (*csvExportWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{writer: writer, now: time.Now()}, nil
+}
+
+func (e *csvExportWriter) Write(voucher model.Voucher) error {
+	return e.writer.Write(exportRow(voucher, e.now))
+}
+
+func (e *csvExportWriter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// xlsxExportWriter writes vouchers through excelize's stream writer, which
+// spills rows to a temporary file instead of keeping the sheet in memory
+type xlsxExportWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+	now    time.Time
+}
+
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	e := &xlsxExportWriter{out: w, file: file, stream: stream, now: time.Now()}
+	if err := e.writeRow(exportHeader); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *xlsxExportWriter) Write(voucher model.Voucher) error {
+	return e.writeRow(exportRow(voucher, e.now))
+}
+
+func (e *xlsxExportWriter) writeRow(columns []string) error {
+	e.row++
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		values[i] = column
+	}
+	return e.stream.SetRow(cell, values)
+}
+
+func (e *xlsxExportWriter) Close() error {
+	defer e.file.Close()
+	if err := e.stream.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.out)
+}
@@ -0,0 +1,62 @@
+package voucher
+
+import (
+	"bytes"
+	"testing"
+	"time"
+	"trinity/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+var exportVouchers = []model.Voucher{
+	{Code: "SUMMER-ABCD", Used: true, ExpiryDate: time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC)},
+	{Code: "SUMMER-EFGH", ExpiryDate: time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC)},
+	{Code: "SUMMER-JKLM", ExpiryDate: time.Date(2020, time.June, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+func writeExport(t *testing.T, format ExportFormat) []byte {
+	var buf bytes.Buffer
+	writer, err := NewExportWriter(format, &buf)
+	require.NoError(t, err)
+	for _, v := range exportVouchers {
+		require.NoError(t, writer.Write(v))
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestParseExportFormat(t *testing.T) {
+	format, err := ParseExportFormat("xlsx")
+	assert.NoError(t, err, "xlsx should be supported")
+	assert.Equal(t, ExportXLSX, format)
+
+	_, err = ParseExportFormat("pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedExportFormat, "pdf should be rejected")
+}
+
+func TestExportWriter_CSV(t *testing.T) {
+	out := writeExport(t, ExportCSV)
+
+	expected := "code,status,expiry_date\n" +
+		"SUMMER-ABCD,used,2030-06-01T00:00:00Z\n" +
+		"SUMMER-EFGH,active,2030-06-01T00:00:00Z\n" +
+		"SUMMER-JKLM,expired,2020-06-01T00:00:00Z\n"
+	assert.Equal(t, expected, string(out), "Expected one row per voucher after the header")
+}
+
+func TestExportWriter_XLSX(t *testing.T) {
+	out := writeExport(t, ExportXLSX)
+
+	file, err := excelize.OpenReader(bytes.NewReader(out))
+	require.NoError(t, err, "Export should be a valid workbook")
+	defer file.Close()
+
+	rows, err := file.GetRows("Sheet1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"code", "status", "expiry_date"}, rows[0], "Expected the header row")
+	assert.Len(t, rows, len(exportVouchers)+1, "Expected one row per voucher")
+	assert.Equal(t, []string{"SUMMER-ABCD", "used", "2030-06-01T00:00:00Z"}, rows[1])
+}
@@ -1,7 +1,14 @@
 package voucher
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"trinity/internal/auth"
+	"trinity/internal/eligibility"
+	"trinity/internal/model"
+	"trinity/internal/user"
 	"trinity/pkg/logger"
 	"trinity/pkg/reason"
 	"trinity/pkg/response"
@@ -24,17 +31,30 @@ func NewHandler(service Service) *Handler {
 // RegisterRoutes registers the voucher routes with the Gin router
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.POST("/redeem", h.RedeemVoucher)
+	rg.POST("/reserve", h.ReserveVoucher)
+	rg.GET("/:code", h.ViewVoucher)
+}
+
+// RegisterAdminRoutes registers the voucher management routes with the Gin router
+func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.POST("/:code/revoke", h.RevokeVoucher)
 }
 
 // RedeemVoucher godoc
 // @Summary Redeem a voucher
-// @Description Redeem a voucher using code and user ID
+// @Description Redeem a voucher code for the authenticated user, or for user_id when called by a partner API key
 // @Tags Voucher
 // @Accept  json
 // @Produce  json
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Param request body voucher.RedeemVoucherRequest true "Voucher redemption data"
 // @Success 200 {object} model.Voucher
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /vouchers/redeem [post]
 func (h *Handler) RedeemVoucher(c *gin.Context) {
@@ -45,18 +65,283 @@ func (h *Handler) RedeemVoucher(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
 		return
 	}
-	if req.Code == "" || req.UserId == "" {
+	if req.Code == "" {
 		msg := reason.InvalidRequest.Message()
-		h.logger.Errorf("%s: code or user_id missing", msg)
+		h.logger.Errorf("%s: code missing", msg)
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
 		return
 	}
-	voucher, err := h.service.RedeemVoucher(req.Code, req.UserId)
+	userID := auth.UserID(c)
+	if auth.APIKey(c) != nil {
+		// Partners redeem on behalf of a customer, within their campaign scope
+		userID = req.UserId
+		if userID == "" {
+			msg := reason.InvalidRequest.Message()
+			h.logger.Errorf("%s: user_id missing for partner redemption", msg)
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+			return
+		}
+		voucher, err := h.service.GetVoucher(req.Code)
+		if err != nil {
+			if errors.Is(err, ErrCodeMistyped) {
+				c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: reason.CodeMistyped.Message()})
+				return
+			}
+			msg := reason.InvalidToken.Message()
+			h.logger.Errorf("%s: %v", msg, err)
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+			return
+		}
+		if !auth.CampaignAllowed(c, voucher.CampaignID) {
+			c.JSON(http.StatusForbidden, response.ErrorResponse{Error: reason.Forbidden.Message()})
+			return
+		}
+	}
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.Unauthorized.Message()})
+		return
+	}
+	voucher, err := h.service.RedeemVoucher(req.Code, userID)
 	if err != nil {
-		msg := reason.InvalidToken.Message()
+		h.respondRedeemError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, voucher)
+}
+
+// ReserveVoucher godoc
+// @Summary Reserve a voucher during checkout
+// @Description Hold a single-use voucher for the authenticated user so nobody else can redeem it until the hold expires. Completing a purchase with the code converts the hold into a redemption.
+// @Tags Voucher
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param request body voucher.ReserveVoucherRequest true "Voucher to reserve"
+// @Success 200 {object} model.Voucher
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /vouchers/reserve [post]
+func (h *Handler) ReserveVoucher(c *gin.Context) {
+	var req ReserveVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	userID := auth.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.Unauthorized.Message()})
+		return
+	}
+
+	voucher, err := h.service.ReserveVoucher(req.Code, userID)
+	if err != nil {
+		if errors.Is(err, ErrNotReservable) {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: reason.InvalidRequest.Message()})
+			return
+		}
+		h.respondRedeemError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, voucher)
+}
+
+// ViewVoucher godoc
+// @Summary View a voucher
+// @Description Show a voucher to the authenticated user. For campaigns whose vouchers are valid for a number of days from first view, the first call starts that period and sets the expiry date.
+// @Tags Voucher
+// @Produce  json
+// @Security BearerAuth
+// @Param code path string true "Voucher code"
+// @Param plan query string false "Plan of the order to validate against" Enums(silver, gold)
+// @Param amount query number false "Amount of the order to validate against"
+// @Success 200 {object} model.Voucher
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.RuleErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /vouchers/{code} [get]
+func (h *Handler) ViewVoucher(c *gin.Context) {
+	userID := auth.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: reason.Unauthorized.Message()})
+		return
+	}
+
+	order, err := orderFromQuery(c)
+	if err != nil {
+		msg := reason.InvalidRequest.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+
+	voucher, err := h.service.ViewVoucher(c.Param("code"), userID, order)
+	if err != nil {
+		if RespondNotEligible(c, err) {
+			return
+		}
+		if errors.Is(err, ErrCodeMistyped) {
+			c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: reason.CodeMistyped.Message()})
+			return
+		}
+		if errors.Is(err, ErrVoucherNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.VoucherNotFound.Message()})
+			return
+		}
+		if errors.Is(err, ErrVoucherNotAssigned) {
+			c.JSON(http.StatusForbidden, response.ErrorResponse{Error: reason.VoucherNotAssigned.Message()})
+			return
+		}
+		if errors.Is(err, user.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.UserNotFound.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+	c.JSON(http.StatusOK, voucher)
+}
+
+// respondRedeemError maps the errors of redeeming or reserving a voucher
+func (h *Handler) respondRedeemError(c *gin.Context, err error) {
+	if RespondNotEligible(c, err) {
+		return
+	}
+	if errors.Is(err, user.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.UserNotFound.Message()})
+		return
+	}
+	if errors.Is(err, ErrCodeMistyped) {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: reason.CodeMistyped.Message()})
+		return
+	}
+	if errors.Is(err, ErrVoucherNotAssigned) {
+		c.JSON(http.StatusForbidden, response.ErrorResponse{Error: reason.VoucherNotAssigned.Message()})
+		return
+	}
+	if msg, ok := UnavailableReason(err); ok {
+		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+		return
+	}
+	if errors.Is(err, ErrVoucherReserved) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.VoucherReserved.Message()})
+		return
+	}
+	if errors.Is(err, ErrRedemptionLimitReached) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.VoucherFullyRedeemed.Message()})
+		return
+	}
+	if errors.Is(err, ErrUserLimitReached) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.VoucherUserLimit.Message()})
+		return
+	}
+	if errors.Is(err, ErrCampaignFull) {
+		c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.CampaignFull.Message()})
+		return
+	}
+	msg := reason.InvalidToken.Message()
+	h.logger.Errorf("%s: %v", msg, err)
+	c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
+}
+
+// RevokeVoucher godoc
+// @Summary Revoke a voucher
+// @Description Void a voucher, for example after its code leaked, so it can no longer be redeemed
+// @Tags Voucher
+// @Accept  json
+// @Produce  json
+// @Security BearerAuth
+// @Param code path string true "Voucher code"
+// @Param request body voucher.RevokeVoucherRequest true "Revocation reason"
+// @Success 200 {object} model.Voucher
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /vouchers/{code}/revoke [post]
+func (h *Handler) RevokeVoucher(c *gin.Context) {
+	var req RevokeVoucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		msg := reason.InvalidRequestFormat.Message()
 		h.logger.Errorf("%s: %v", msg, err)
 		c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: msg})
 		return
 	}
+
+	voucher, err := h.service.RevokeVoucher(c.Param("code"), req.Reason)
+	if err != nil {
+		if errors.Is(err, ErrVoucherNotFound) {
+			c.JSON(http.StatusNotFound, response.ErrorResponse{Error: reason.VoucherNotFound.Message()})
+			return
+		}
+		if errors.Is(err, ErrVoucherRevoked) {
+			c.JSON(http.StatusConflict, response.ErrorResponse{Error: reason.VoucherRevoked.Message()})
+			return
+		}
+		msg := reason.InternalServerError.Message()
+		h.logger.Errorf("%s: %v", msg, err)
+		c.JSON(http.StatusInternalServerError, response.ErrorResponse{Error: msg})
+		return
+	}
+
 	c.JSON(http.StatusOK, voucher)
 }
+
+// UnavailableReason explains why a voucher cannot be redeemed, keeping
+// revoked codes distinct from used or expired ones
+func UnavailableReason(err error) (string, bool) {
+	switch {
+	case errors.Is(err, ErrVoucherRevoked):
+		return reason.VoucherRevoked.Message(), true
+	case errors.Is(err, ErrVoucherUsed):
+		return reason.VoucherUsed.Message(), true
+	case errors.Is(err, ErrVoucherExpired):
+		return reason.VoucherExpired.Message(), true
+	}
+	return "", false
+}
+
+// RespondNotEligible answers with the failed eligibility rule if err is one,
+// and reports whether it did
+func RespondNotEligible(c *gin.Context, err error) bool {
+	var ruleErr *eligibility.RuleError
+	if !errors.As(err, &ruleErr) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, response.RuleErrorResponse{Error: reason.NotEligible.Message(), Rule: string(ruleErr.Rule)})
+	return true
+}
+
+// orderFromQuery reads the order a voucher is validated against from the
+// plan and amount query parameters, which must be given together
+func orderFromQuery(c *gin.Context) (*eligibility.Order, error) {
+	plan, amount := c.Query("plan"), c.Query("amount")
+	if plan == "" && amount == "" {
+		return nil, nil
+	}
+	if plan == "" || amount == "" {
+		return nil, errors.New("plan and amount must be given together")
+	}
+
+	order := &eligibility.Order{Plan: model.SubscriptionPlan(plan)}
+	if !order.Plan.IsValid() {
+		return nil, fmt.Errorf("unknown plan %q", plan)
+	}
+	var err error
+	if order.Amount, err = strconv.ParseFloat(amount, 64); err != nil {
+		return nil, fmt.Errorf("invalid amount: %v", err)
+	}
+	return order, nil
+}
@@ -8,21 +8,37 @@ import (
 	"testing"
 	"time"
 
+	"trinity/internal/auth"
+	"trinity/internal/eligibility"
 	"trinity/internal/model"
+	"trinity/pkg/reason"
+	"trinity/pkg/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-// setupRouter initializes the Gin engine with the voucher routes
+// testAuthenticator verifies the tokens minted by the tests
+var testAuthenticator, _ = auth.NewAuthenticator("test-secret", "", "trinity", time.Hour)
+
+// setupRouter initializes the Gin engine with the authenticated voucher routes
 func setupRouter(handler *Handler) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.Default()
-	voucherGroup := r.Group("/vouchers")
+	voucherGroup := r.Group("/vouchers", testAuthenticator.Middleware())
 	handler.RegisterRoutes(voucherGroup)
+	handler.RegisterAdminRoutes(voucherGroup)
 	return r
 }
 
+// mintToken issues a bearer token for the given user
+func mintToken(t *testing.T, userID string) string {
+	token, err := testAuthenticator.IssueToken(&model.User{Id: userID})
+	require.NoError(t, err)
+	return "Bearer " + token
+}
+
 // TestRedeemVoucher_Success tests successful voucher redemption
 func TestRedeemVoucher_Success(t *testing.T) {
 	mockService := new(MockService)
@@ -30,8 +46,7 @@ func TestRedeemVoucher_Success(t *testing.T) {
 	router := setupRouter(handler)
 
 	reqBody := RedeemVoucherRequest{
-		Code:   "VALIDCODE",
-		UserId: "user123",
+		Code: "VALIDCODE",
 	}
 	body, _ := json.Marshal(reqBody)
 
@@ -52,6 +67,7 @@ func TestRedeemVoucher_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/vouchers/redeem", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", mintToken(t, "user123"))
 
 	// Serve the HTTP request
 	router.ServeHTTP(w, req)
@@ -73,3 +89,274 @@ func TestRedeemVoucher_Success(t *testing.T) {
 	// Ensure that the mock was called as expected
 	mockService.AssertExpectations(t)
 }
+
+// TestRedeemVoucher_Unauthenticated tests that redemption requires a token
+func TestRedeemVoucher_Unauthenticated(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	body, _ := json.Marshal(RedeemVoucherRequest{Code: "VALIDCODE"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/redeem", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "Expected status 401 Unauthorized")
+	mockService.AssertNotCalled(t, "RedeemVoucher")
+}
+
+// TestRedeemVoucher_IgnoresBodyUserID tests that the user comes from the token, not the body
+func TestRedeemVoucher_IgnoresBodyUserID(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	body := []byte(`{"code": "VALIDCODE", "user_id": "victim"}`)
+	voucher := &model.Voucher{Code: "VALIDCODE", Used: true, UserId: "attacker"}
+	mockService.On("RedeemVoucher", "VALIDCODE", "attacker").Return(voucher, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/redeem", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", mintToken(t, "attacker"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK")
+	mockService.AssertExpectations(t)
+}
+
+// partnerKeys resolves every raw key to the same partner key
+type partnerKeys struct {
+	key *model.APIKey
+}
+
+func (p partnerKeys) VerifyKey(string) (*model.APIKey, error) {
+	return p.key, nil
+}
+
+// TestRedeemVoucher_PartnerOutsideScope tests that partners cannot redeem codes of other campaigns
+func TestRedeemVoucher_PartnerOutsideScope(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+
+	partnerAuthenticator, _ := auth.NewAuthenticator("test-secret", "", "trinity", time.Hour)
+	partnerAuthenticator.SetKeyVerifier(partnerKeys{key: &model.APIKey{Id: "key1", CampaignIDs: []string{"campaign123"}}})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler.RegisterRoutes(router.Group("/vouchers", partnerAuthenticator.Middleware()))
+
+	voucher := &model.Voucher{Code: "OTHERCODE", CampaignID: "campaign999"}
+	mockService.On("GetVoucher", "OTHERCODE").Return(voucher, nil)
+
+	body, _ := json.Marshal(RedeemVoucherRequest{Code: "OTHERCODE", UserId: "user123"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/redeem", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(auth.APIKeyHeader, "trk_partner")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code, "Expected status 403 Forbidden")
+	mockService.AssertNotCalled(t, "RedeemVoucher")
+}
+
+// TestRedeemVoucher_UserLimitReached tests that repeat redemptions of a shared code are a conflict
+func TestRedeemVoucher_UserLimitReached(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	body, _ := json.Marshal(RedeemVoucherRequest{Code: "WELCOME2026"})
+	mockService.On("RedeemVoucher", "WELCOME2026", "user123").Return(nil, ErrUserLimitReached)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/redeem", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", mintToken(t, "user123"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code, "Expected status 409 Conflict")
+	mockService.AssertExpectations(t)
+}
+
+// TestRedeemVoucher_Revoked tests that revoked codes get their own error message
+func TestRedeemVoucher_Revoked(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	body, _ := json.Marshal(RedeemVoucherRequest{Code: "LEAKED1"})
+	mockService.On("RedeemVoucher", "LEAKED1", "user123").Return(nil, ErrVoucherRevoked)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/redeem", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", mintToken(t, "user123"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "Expected status 400 Bad Request")
+	assert.Contains(t, w.Body.String(), reason.VoucherRevoked.Message())
+}
+
+// TestRevokeVoucher_Success tests revoking a single voucher
+func TestRevokeVoucher_Success(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	revokedAt := time.Now()
+	revoked := &model.Voucher{Code: "LEAKED1", RevokedAt: &revokedAt, RevokedReason: "posted online"}
+	mockService.On("RevokeVoucher", "LEAKED1", "posted online").Return(revoked, nil)
+
+	body, _ := json.Marshal(RevokeVoucherRequest{Reason: "posted online"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/LEAKED1/revoke", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", mintToken(t, "admin"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK")
+	mockService.AssertExpectations(t)
+}
+
+// TestRevokeVoucher_Errors tests the status codes of failed revocations
+func TestRevokeVoucher_Errors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{ErrVoucherNotFound, http.StatusNotFound},
+		{ErrVoucherRevoked, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		mockService := new(MockService)
+		router := setupRouter(NewHandler(mockService))
+		mockService.On("RevokeVoucher", "LEAKED1", "posted online").Return(nil, tt.err)
+
+		body, _ := json.Marshal(RevokeVoucherRequest{Reason: "posted online"})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/vouchers/LEAKED1/revoke", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", mintToken(t, "admin"))
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tt.status, w.Code, "Unexpected status for %v", tt.err)
+	}
+}
+
+// TestReserveVoucher_Success tests holding a voucher during checkout
+func TestReserveVoucher_Success(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	until := time.Now().Add(15 * time.Minute)
+	held := &model.Voucher{Code: "CHECKOUT1", ReservedBy: "user123", ReservedUntil: &until}
+	mockService.On("ReserveVoucher", "CHECKOUT1", "user123").Return(held, nil)
+
+	body, _ := json.Marshal(ReserveVoucherRequest{Code: "CHECKOUT1"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/reserve", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", mintToken(t, "user123"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK")
+	var response model.Voucher
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "user123", response.ReservedBy)
+}
+
+// TestReserveVoucher_HeldByAnotherUser tests that a second hold is a conflict
+func TestReserveVoucher_HeldByAnotherUser(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	mockService.On("ReserveVoucher", "CHECKOUT1", "user2").Return(nil, ErrVoucherReserved)
+
+	body, _ := json.Marshal(ReserveVoucherRequest{Code: "CHECKOUT1"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/reserve", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", mintToken(t, "user2"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code, "Expected status 409 Conflict")
+	assert.Contains(t, w.Body.String(), reason.VoucherReserved.Message())
+}
+
+// TestViewVoucher_Success tests showing a voucher to its user
+func TestViewVoucher_Success(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	viewed := &model.Voucher{Code: "RELATIVE1", ValidityDays: 7, ExpiryDate: time.Now().AddDate(0, 0, 7)}
+	mockService.On("ViewVoucher", "RELATIVE1", "user123", (*eligibility.Order)(nil)).Return(viewed, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/vouchers/RELATIVE1", nil)
+	req.Header.Set("Authorization", mintToken(t, "user123"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK")
+	mockService.AssertExpectations(t)
+}
+
+// TestViewVoucher_NotFound tests viewing an unknown code
+func TestViewVoucher_NotFound(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	mockService.On("ViewVoucher", "MISSING1", "user123", (*eligibility.Order)(nil)).Return(nil, ErrVoucherNotFound)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/vouchers/MISSING1", nil)
+	req.Header.Set("Authorization", mintToken(t, "user123"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code, "Expected status 404 Not Found")
+}
+
+// TestRedeemVoucher_NotEligible tests that the failed eligibility rule is reported
+func TestRedeemVoucher_NotEligible(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	body, _ := json.Marshal(RedeemVoucherRequest{Code: "WELCOME1"})
+	mockService.On("RedeemVoucher", "WELCOME1", "user123").Return(nil, &eligibility.RuleError{Rule: eligibility.RuleFirstPurchaseOnly})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/vouchers/redeem", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", mintToken(t, "user123"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code, "Expected status 403 Forbidden")
+	var response response.RuleErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, string(eligibility.RuleFirstPurchaseOnly), response.Rule)
+}
+
+// TestViewVoucher_ValidatesOrder tests passing an order to the validate flow
+func TestViewVoucher_ValidatesOrder(t *testing.T) {
+	mockService := new(MockService)
+	handler := NewHandler(mockService)
+	router := setupRouter(handler)
+
+	order := &eligibility.Order{Plan: model.PlanGold, Amount: 200}
+	mockService.On("ViewVoucher", "WELCOME1", "user123", order).Return(&model.Voucher{Code: "WELCOME1"}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/vouchers/WELCOME1?plan=gold&amount=200", nil)
+	req.Header.Set("Authorization", mintToken(t, "user123"))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, "Expected status 200 OK")
+	mockService.AssertExpectations(t)
+}
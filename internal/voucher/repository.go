@@ -11,26 +11,59 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Repository defines voucher data access methods
 type Repository interface {
 	CreateVoucher(voucher *model.Voucher) error
+	CreateVouchers(vouchers []model.Voucher) ([]int, error)
 	GetVoucherByCode(code string) (*model.Voucher, error)
+	StreamVouchersByCampaign(campaignID string, fn func(model.Voucher) error) error
+	CountVouchersByJob(jobID string) (int, error)
+	ListAssignedUsersByJob(jobID string) ([]string, error)
+	MarkLegacyVouchers() (int64, error)
 	UpdateVoucher(voucher *model.Voucher) error
+	ClaimVoucher(id string, userID string) error
+	UnclaimVoucher(id string, userID string) error
+	ReserveVoucher(id string, userID string, until time.Time) error
+	StartValidity(id string, viewedAt time.Time, expiry time.Time) (*model.Voucher, error)
+	AddRedemption(id string, maxRedemptions int) error
+	RemoveRedemption(id string) error
+	ClaimUserRedemption(voucherID string, userID string, limit int) error
+	ReleaseUserRedemption(voucherID string, userID string) error
+	CreateRedemption(redemption *model.Redemption) error
+	DeleteLastRedemption(voucherID string, userID string) error
+	CountRedemptionsByVariant(campaignID string) (map[string]int64, error)
+	RevokeVoucher(code string, reason string, revokedAt time.Time) error
+	RevokeVouchers(campaignID string, filter RevokeFilter, reason string, revokedAt time.Time) (int64, error)
+}
+
+// RevokeFilter narrows a bulk revocation to unused vouchers matching every
+// set field; the zero value matches all unused vouchers of the campaign
+type RevokeFilter struct {
+	// Codes limits the revocation to the listed codes
+	Codes []string
+	// IssuedAfter and IssuedBefore bound when the vouchers were generated
+	IssuedAfter  *time.Time
+	IssuedBefore *time.Time
 }
 
 // repository implements Repository interface
 type repository struct {
-	collection *mongo.Collection
-	logger     logger.Logger
+	collection   *mongo.Collection
+	redemptions  *mongo.Collection
+	userCounters *mongo.Collection
+	logger       logger.Logger
 }
 
 // NewRepository creates a new Voucher repository
 func NewRepository(db *mongo.Database) Repository {
 	return &repository{
-		collection: db.Collection("vouchers"),
-		logger:     logger.NewLogger("voucherRepository"),
+		collection:   db.Collection("vouchers"),
+		redemptions:  db.Collection("redemptions"),
+		userCounters: db.Collection("voucher_user_redemptions"),
+		logger:       logger.NewLogger("voucherRepository"),
 	}
 }
 
@@ -53,6 +86,52 @@ func (r *repository) CreateVoucher(voucher *model.Voucher) error {
 	return nil
 }
 
+// CreateVouchers inserts vouchers in a single unordered batch. Vouchers
+// rejected by the unique code index are skipped and their indexes returned,
+// so the caller can retry them with new codes; the others get their IDs set.
+func (r *repository) CreateVouchers(vouchers []model.Voucher) ([]int, error) {
+	if len(vouchers) == 0 {
+		return nil, nil
+	}
+
+	documents := make([]interface{}, len(vouchers))
+	for i := range vouchers {
+		documents[i] = vouchers[i]
+	}
+
+	result, err := r.collection.InsertMany(context.Background(), documents, options.InsertMany().SetOrdered(false))
+
+	var duplicates []int
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+			r.logger.Errorf("Failed to insert vouchers: %v", err)
+			return nil, err
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			if !mongo.IsDuplicateKeyError(writeErr) {
+				r.logger.Errorf("Failed to insert vouchers: %v", err)
+				return nil, err
+			}
+			duplicates = append(duplicates, writeErr.Index)
+		}
+	}
+
+	// InsertedIDs lines up with the input, including documents that failed
+	skipped := make(map[int]bool, len(duplicates))
+	for _, i := range duplicates {
+		skipped[i] = true
+	}
+	for i, id := range result.InsertedIDs {
+		if oid, ok := id.(primitive.ObjectID); ok && !skipped[i] {
+			vouchers[i].Id = oid.Hex()
+		}
+	}
+
+	r.logger.Infof("Inserted %d vouchers, %d duplicate codes", len(vouchers)-len(duplicates), len(duplicates))
+	return duplicates, nil
+}
+
 // GetVoucherByCode retrieves a voucher by its code
 func (r *repository) GetVoucherByCode(code string) (*model.Voucher, error) {
 	var voucher model.Voucher
@@ -64,6 +143,114 @@ func (r *repository) GetVoucherByCode(code string) (*model.Voucher, error) {
 	return &voucher, nil
 }
 
+// StreamVouchersByCampaign calls fn for each voucher of a campaign in
+// creation order, decoding one document at a time so large campaigns are
+// never held in memory. Iteration stops at the first error fn returns.
+func (r *repository) StreamVouchersByCampaign(campaignID string, fn func(model.Voucher) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetBatchSize(1000)
+	cursor, err := r.collection.Find(context.Background(), bson.M{"campaign_id": campaignID}, opts)
+	if err != nil {
+		r.logger.Errorf("Failed to find vouchers for campaign %s: %v", campaignID, err)
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var voucher model.Voucher
+		if err := cursor.Decode(&voucher); err != nil {
+			return err
+		}
+		if err := fn(voucher); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// CountVouchersByJob counts the vouchers a background job has inserted
+func (r *repository) CountVouchersByJob(jobID string) (int, error) {
+	count, err := r.collection.CountDocuments(context.Background(), bson.M{"job_id": jobID})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// ListAssignedUsersByJob returns the users assigned to the vouchers a
+// background job has inserted, one entry per voucher
+func (r *repository) ListAssignedUsersByJob(jobID string) ([]string, error) {
+	opts := options.Find().SetProjection(bson.M{"assigned_user_id": 1})
+	cursor, err := r.collection.Find(context.Background(), bson.M{"job_id": jobID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var vouchers []model.Voucher
+	if err := cursor.All(context.Background(), &vouchers); err != nil {
+		return nil, err
+	}
+
+	users := make([]string, len(vouchers))
+	for i, voucher := range vouchers {
+		users[i] = voucher.AssignedUserId
+	}
+	return users, nil
+}
+
+// MarkLegacyVouchers flags the vouchers generated before codes carried a
+// check character: codes of 10 hex characters that fail the check
+// character test. Every code stored since carries one, so codes of that
+// shape passing the test are marked as not legacy and are not revisited.
+// It returns how many vouchers were flagged as legacy.
+func (r *repository) MarkLegacyVouchers() (int64, error) {
+	filter := bson.M{"code": bson.M{"$regex": "^[0-9A-F]{10}$"}, "legacy": bson.M{"$exists": false}}
+	opts := options.Find().SetProjection(bson.M{"code": 1}).SetBatchSize(1000)
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		r.logger.Errorf("Failed to find legacy vouchers: %v", err)
+		return 0, err
+	}
+	defer cursor.Close(context.Background())
+
+	var legacy, checked []primitive.ObjectID
+	for cursor.Next(context.Background()) {
+		var voucher struct {
+			Id   primitive.ObjectID `bson:"_id"`
+			Code string             `bson:"code"`
+		}
+		if err := cursor.Decode(&voucher); err != nil {
+			return 0, err
+		}
+		if ValidateCode(voucher.Code) != nil {
+			legacy = append(legacy, voucher.Id)
+		} else {
+			checked = append(checked, voucher.Id)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+
+	var marked int64
+	for flag, ids := range map[bool][]primitive.ObjectID{true: legacy, false: checked} {
+		for start := 0; start < len(ids); start += 1000 {
+			batch := ids[start:min(start+1000, len(ids))]
+			result, err := r.collection.UpdateMany(context.Background(),
+				bson.M{"_id": bson.M{"$in": batch}},
+				bson.M{"$set": bson.M{"legacy": flag}})
+			if err != nil {
+				r.logger.Errorf("Failed to mark legacy vouchers: %v", err)
+				return marked, err
+			}
+			if flag {
+				marked += result.ModifiedCount
+			}
+		}
+	}
+	return marked, nil
+}
+
 // UpdateVoucher updates an existing voucher in the database
 func (r *repository) UpdateVoucher(voucher *model.Voucher) error {
 	objectId, err := primitive.ObjectIDFromHex(voucher.Id)
@@ -102,3 +289,306 @@ func (r *repository) UpdateVoucher(voucher *model.Voucher) error {
 
 	return nil
 }
+
+// ClaimVoucher atomically marks a single-use voucher as used by the user.
+// A hold by the user is converted into the redemption and cleared. It fails
+// with ErrVoucherUsed if another redemption or hold got there first.
+func (r *repository) ClaimVoucher(id string, userID string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid voucher ID: %v", err)
+	}
+
+	now := time.Now()
+	filter := bson.M{"_id": objectId, "used": false, "revoked_at": nil, "$or": availableTo(userID, now)}
+	update := bson.M{
+		"$set":   bson.M{"used": true, "user_id": userID, "updated": now},
+		"$inc":   bson.M{"redemption_count": 1},
+		"$unset": bson.M{"reserved_by": "", "reserved_until": ""},
+	}
+
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		r.logger.Errorf("Failed to claim voucher: %v", err)
+		return fmt.Errorf("failed to update voucher: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrVoucherUsed
+	}
+	return nil
+}
+
+// UnclaimVoucher gives back a single-use voucher claimed by the user, so it
+// can be redeemed again
+func (r *repository) UnclaimVoucher(id string, userID string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid voucher ID: %v", err)
+	}
+
+	filter := bson.M{"_id": objectId, "used": true, "user_id": userID}
+	update := bson.M{
+		"$set":   bson.M{"used": false, "updated": time.Now()},
+		"$inc":   bson.M{"redemption_count": -1},
+		"$unset": bson.M{"user_id": ""},
+	}
+
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		r.logger.Errorf("Failed to unclaim voucher: %v", err)
+		return fmt.Errorf("failed to update voucher: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("voucher %s is not claimed by %s", id, userID)
+	}
+	return nil
+}
+
+// ReserveVoucher holds an unused voucher for the user until the given time.
+// A user may renew their own hold; a voucher held by someone else whose
+// hold has not expired cannot be reserved and yields ErrVoucherReserved.
+func (r *repository) ReserveVoucher(id string, userID string, until time.Time) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid voucher ID: %v", err)
+	}
+
+	filter := bson.M{"_id": objectId, "used": false, "revoked_at": nil, "$or": availableTo(userID, time.Now())}
+	update := bson.M{"$set": bson.M{"reserved_by": userID, "reserved_until": until}}
+
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		r.logger.Errorf("Failed to reserve voucher: %v", err)
+		return fmt.Errorf("failed to reserve voucher: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrVoucherReserved
+	}
+	return nil
+}
+
+// StartValidity records the first view of a voucher whose validity starts
+// then and sets its expiry. If a concurrent view got there first, the
+// voucher is returned with the expiry that view set.
+func (r *repository) StartValidity(id string, viewedAt time.Time, expiry time.Time) (*model.Voucher, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid voucher ID: %v", err)
+	}
+
+	filter := bson.M{"_id": objectId, "first_viewed_at": nil}
+	update := bson.M{"$set": bson.M{"first_viewed_at": viewedAt, "expiry_date": expiry}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var voucher model.Voucher
+	err = r.collection.FindOneAndUpdate(context.Background(), filter, update, opts).Decode(&voucher)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = r.collection.FindOne(context.Background(), bson.M{"_id": objectId}).Decode(&voucher)
+	}
+	if err != nil {
+		r.logger.Errorf("Failed to start validity of voucher %s: %v", id, err)
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// availableTo matches vouchers that are not held, held by the user, or
+// whose hold has expired; expired holds need no cleanup to be released
+func availableTo(userID string, now time.Time) bson.A {
+	return bson.A{
+		bson.M{"reserved_by": nil},
+		bson.M{"reserved_by": userID},
+		bson.M{"reserved_until": bson.M{"$lte": now}},
+	}
+}
+
+// AddRedemption atomically counts one more redemption of a multi-use
+// voucher, failing with ErrRedemptionLimitReached once maxRedemptions is
+// reached. The voucher is marked used when its last redemption is taken.
+func (r *repository) AddRedemption(id string, maxRedemptions int) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid voucher ID: %v", err)
+	}
+
+	filter := bson.M{"_id": objectId, "redemption_count": bson.M{"$lt": maxRedemptions}, "revoked_at": nil}
+	next := bson.M{"$add": bson.A{"$redemption_count", 1}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"redemption_count": next,
+		"used":             bson.M{"$gte": bson.A{next, maxRedemptions}},
+		"updated":          time.Now(),
+	}}}}
+
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		r.logger.Errorf("Failed to add redemption: %v", err)
+		return fmt.Errorf("failed to update voucher: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrRedemptionLimitReached
+	}
+	return nil
+}
+
+// RemoveRedemption gives back a redemption counted by AddRedemption; a
+// voucher that was full is usable again
+func (r *repository) RemoveRedemption(id string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid voucher ID: %v", err)
+	}
+
+	filter := bson.M{"_id": objectId, "redemption_count": bson.M{"$gt": 0}}
+	update := bson.M{
+		"$set": bson.M{"used": false, "updated": time.Now()},
+		"$inc": bson.M{"redemption_count": -1},
+	}
+
+	if _, err := r.collection.UpdateOne(context.Background(), filter, update); err != nil {
+		r.logger.Errorf("Failed to remove redemption: %v", err)
+		return fmt.Errorf("failed to update voucher: %v", err)
+	}
+	return nil
+}
+
+// ClaimUserRedemption atomically counts a redemption of the voucher by the
+// user, failing with ErrUserLimitReached once the user has used it limit
+// times. The counter document is upserted under a unique index on
+// voucher and user, so a user at the limit no longer matches the filter
+// and the upsert is refused as a duplicate. Two first redemptions racing
+// also collide on the upsert, so a duplicate is retried once as a plain
+// conditional update, which only fails if the limit was reached.
+func (r *repository) ClaimUserRedemption(voucherID string, userID string, limit int) error {
+	filter := bson.M{"voucher_id": voucherID, "user_id": userID, "count": bson.M{"$lt": limit}}
+	update := bson.M{"$inc": bson.M{"count": 1}}
+
+	_, err := r.userCounters.UpdateOne(context.Background(), filter, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		r.logger.Errorf("Failed to claim user redemption: %v", err)
+		return err
+	}
+
+	result, err := r.userCounters.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		r.logger.Errorf("Failed to claim user redemption: %v", err)
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserLimitReached
+	}
+	return nil
+}
+
+// ReleaseUserRedemption gives back a redemption taken by ClaimUserRedemption
+func (r *repository) ReleaseUserRedemption(voucherID string, userID string) error {
+	filter := bson.M{"voucher_id": voucherID, "user_id": userID, "count": bson.M{"$gt": 0}}
+	_, err := r.userCounters.UpdateOne(context.Background(), filter, bson.M{"$inc": bson.M{"count": -1}})
+	return err
+}
+
+// CountRedemptionsByVariant counts the redemptions of a campaign's vouchers
+// by the variant their user was bucketed into
+func (r *repository) CountRedemptionsByVariant(campaignID string) (map[string]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"campaign_id": campaignID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$variant", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.redemptions.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var rows []struct {
+		Variant string `bson:"_id"`
+		Count   int64  `bson:"count"`
+	}
+	if err := cursor.All(context.Background(), &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Variant] = row.Count
+	}
+	return counts, nil
+}
+
+// CreateRedemption records who redeemed a voucher and when
+func (r *repository) CreateRedemption(redemption *model.Redemption) error {
+	result, err := r.redemptions.InsertOne(context.Background(), redemption)
+	if err != nil {
+		r.logger.Errorf("Failed to record redemption: %v", err)
+		return err
+	}
+
+	if oid, ok := result.InsertedID.(primitive.ObjectID); ok {
+		redemption.Id = oid.Hex()
+	}
+	return nil
+}
+
+// DeleteLastRedemption removes the latest record of the user redeeming the
+// voucher
+func (r *repository) DeleteLastRedemption(voucherID string, userID string) error {
+	filter := bson.M{"voucher_id": voucherID, "user_id": userID}
+	opts := options.FindOneAndDelete().SetSort(bson.D{{Key: "redeemed_at", Value: -1}})
+
+	err := r.redemptions.FindOneAndDelete(context.Background(), filter, opts).Err()
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		r.logger.Errorf("Failed to delete redemption: %v", err)
+		return err
+	}
+	return nil
+}
+
+// RevokeVoucher voids a voucher that has not been revoked yet, failing with
+// ErrVoucherNotFound if no such voucher exists
+func (r *repository) RevokeVoucher(code string, reason string, revokedAt time.Time) error {
+	filter := bson.M{"code": code, "revoked_at": nil}
+	update := bson.M{"$set": bson.M{"revoked_at": revokedAt, "revoked_reason": reason, "updated": revokedAt}}
+
+	result, err := r.collection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		r.logger.Errorf("Failed to revoke voucher: %v", err)
+		return fmt.Errorf("failed to update voucher: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrVoucherNotFound
+	}
+	return nil
+}
+
+// RevokeVouchers voids the campaign's unused vouchers that match the filter
+// and returns how many were revoked. Generation time is read from the
+// ObjectID, which embeds the insert time.
+func (r *repository) RevokeVouchers(campaignID string, filter RevokeFilter, reason string, revokedAt time.Time) (int64, error) {
+	query := bson.M{"campaign_id": campaignID, "used": false, "revoked_at": nil}
+	if len(filter.Codes) > 0 {
+		query["code"] = bson.M{"$in": filter.Codes}
+	}
+	issued := bson.M{}
+	if filter.IssuedAfter != nil {
+		issued["$gte"] = primitive.NewObjectIDFromTimestamp(*filter.IssuedAfter)
+	}
+	if filter.IssuedBefore != nil {
+		issued["$lt"] = primitive.NewObjectIDFromTimestamp(*filter.IssuedBefore)
+	}
+	if len(issued) > 0 {
+		query["_id"] = issued
+	}
+
+	update := bson.M{"$set": bson.M{"revoked_at": revokedAt, "revoked_reason": reason, "updated": revokedAt}}
+	result, err := r.collection.UpdateMany(context.Background(), query, update)
+	if err != nil {
+		r.logger.Errorf("Failed to revoke vouchers: %v", err)
+		return 0, fmt.Errorf("failed to update vouchers: %v", err)
+	}
+
+	r.logger.Infof("Revoked %d vouchers of campaign %s", result.ModifiedCount, campaignID)
+	return result.ModifiedCount, nil
+}
@@ -1,6 +1,7 @@
 package voucher
 
 import (
+	"time"
 	"trinity/internal/model"
 
 	"github.com/stretchr/testify/mock"
@@ -16,6 +17,12 @@ func (m *MockRepository) CreateVoucher(voucher *model.Voucher) error {
 	return args.Error(0)
 }
 
+func (m *MockRepository) CreateVouchers(vouchers []model.Voucher) ([]int, error) {
+	args := m.Called(vouchers)
+	duplicates, _ := args.Get(0).([]int)
+	return duplicates, args.Error(1)
+}
+
 func (m *MockRepository) GetVoucherByCode(code string) (*model.Voucher, error) {
 	args := m.Called(code)
 	voucher := args.Get(0)
@@ -25,7 +32,109 @@ func (m *MockRepository) GetVoucherByCode(code string) (*model.Voucher, error) {
 	return voucher.(*model.Voucher), args.Error(1)
 }
 
+func (m *MockRepository) MarkLegacyVouchers() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) StreamVouchersByCampaign(campaignID string, fn func(model.Voucher) error) error {
+	args := m.Called(campaignID, fn)
+	if vouchers, ok := args.Get(0).([]model.Voucher); ok {
+		for _, v := range vouchers {
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockRepository) CountVouchersByJob(jobID string) (int, error) {
+	args := m.Called(jobID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) ListAssignedUsersByJob(jobID string) ([]string, error) {
+	args := m.Called(jobID)
+	if users, ok := args.Get(0).([]string); ok {
+		return users, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockRepository) UpdateVoucher(voucher *model.Voucher) error {
 	args := m.Called(voucher)
 	return args.Error(0)
 }
+
+func (m *MockRepository) ClaimVoucher(id string, userID string) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) AddRedemption(id string, maxRedemptions int) error {
+	args := m.Called(id, maxRedemptions)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimUserRedemption(voucherID string, userID string, limit int) error {
+	args := m.Called(voucherID, userID, limit)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ReleaseUserRedemption(voucherID string, userID string) error {
+	args := m.Called(voucherID, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateRedemption(redemption *model.Redemption) error {
+	args := m.Called(redemption)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CountRedemptionsByVariant(campaignID string) (map[string]int64, error) {
+	args := m.Called(campaignID)
+	if counts, ok := args.Get(0).(map[string]int64); ok {
+		return counts, args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockRepository) RevokeVoucher(code string, reason string, revokedAt time.Time) error {
+	args := m.Called(code, reason, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RevokeVouchers(campaignID string, filter RevokeFilter, reason string, revokedAt time.Time) (int64, error) {
+	args := m.Called(campaignID, filter, reason, revokedAt)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) ReserveVoucher(id string, userID string, until time.Time) error {
+	args := m.Called(id, userID, until)
+	return args.Error(0)
+}
+
+func (m *MockRepository) StartValidity(id string, viewedAt time.Time, expiry time.Time) (*model.Voucher, error) {
+	args := m.Called(id, viewedAt, expiry)
+	voucher := args.Get(0)
+	if voucher == nil {
+		return nil, args.Error(1)
+	}
+	return voucher.(*model.Voucher), args.Error(1)
+}
+
+func (m *MockRepository) UnclaimVoucher(id string, userID string) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) RemoveRedemption(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteLastRedemption(voucherID string, userID string) error {
+	args := m.Called(voucherID, userID)
+	return args.Error(0)
+}
@@ -2,7 +2,9 @@ package voucher
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 	"trinity/internal/model"
@@ -174,3 +176,262 @@ func TestUpdateVoucher_NotFound(t *testing.T) {
 	err := testRepo.UpdateVoucher(voucher)
 	assert.NoError(t, err, "Updating non-existent voucher should return an error")
 }
+
+// TestCreateVouchers tests that CreateVouchers reports duplicate codes and
+// inserts the rest of the batch
+func TestCreateVouchers(t *testing.T) {
+	collection := testDB.Collection("vouchers")
+	_, err := collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	assert.NoError(t, err, "Creating the code index should not return an error")
+
+	err = testRepo.CreateVoucher(&model.Voucher{Code: "BATCHTAKEN", CampaignID: "campaign123"})
+	assert.NoError(t, err, "Creating voucher should not return an error")
+
+	vouchers := []model.Voucher{
+		{Code: "BATCHCODE1", CampaignID: "campaign123"},
+		{Code: "BATCHTAKEN", CampaignID: "campaign123"},
+		{Code: "BATCHCODE2", CampaignID: "campaign123"},
+	}
+
+	duplicates, err := testRepo.CreateVouchers(vouchers)
+	assert.NoError(t, err, "Duplicate codes should not fail the batch")
+	assert.Equal(t, []int{1}, duplicates, "Expected the taken code to be reported")
+	assert.NotEmpty(t, vouchers[0].Id, "Inserted vouchers should get an ID")
+	assert.Empty(t, vouchers[1].Id, "Rejected vouchers should not get an ID")
+	assert.NotEmpty(t, vouchers[2].Id, "Inserted vouchers should get an ID")
+
+	count, err := collection.CountDocuments(context.Background(), bson.M{"code": bson.M{"$in": []string{"BATCHCODE1", "BATCHCODE2"}}})
+	assert.NoError(t, err, "Counting vouchers should not return an error")
+	assert.Equal(t, int64(2), count, "Expected the other vouchers to be inserted")
+}
+
+// BenchmarkCreateVouchers measures the unordered bulk insert used for
+// voucher generation against a real collection
+func BenchmarkCreateVouchers(b *testing.B) {
+	const count = 100000
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		if _, err := testDB.Collection("vouchers").DeleteMany(context.Background(), bson.M{"campaign_id": "benchmark"}); err != nil {
+			b.Fatalf("clearing vouchers: %v", err)
+		}
+		vouchers := make([]model.Voucher, count)
+		for j := range vouchers {
+			vouchers[j] = model.Voucher{Code: fmt.Sprintf("BENCH%06d", j), CampaignID: "benchmark"}
+		}
+		b.StartTimer()
+
+		duplicates, err := testRepo.CreateVouchers(vouchers)
+		if err != nil || len(duplicates) > 0 {
+			b.Fatalf("inserted with %d duplicates: %v", len(duplicates), err)
+		}
+	}
+}
+
+// TestStreamVouchersByCampaign tests that only the campaign's vouchers are
+// streamed, in creation order
+func TestStreamVouchersByCampaign(t *testing.T) {
+	for _, code := range []string{"STREAMA", "STREAMB"} {
+		err := testRepo.CreateVoucher(&model.Voucher{Code: code, CampaignID: "stream_campaign"})
+		assert.NoError(t, err, "Creating voucher should not return an error")
+	}
+	err := testRepo.CreateVoucher(&model.Voucher{Code: "STREAMOTHER", CampaignID: "other_campaign"})
+	assert.NoError(t, err, "Creating voucher should not return an error")
+
+	var codes []string
+	err = testRepo.StreamVouchersByCampaign("stream_campaign", func(v model.Voucher) error {
+		codes = append(codes, v.Code)
+		return nil
+	})
+
+	assert.NoError(t, err, "Streaming vouchers should not return an error")
+	assert.Equal(t, []string{"STREAMA", "STREAMB"}, codes, "Expected the campaign's vouchers in order")
+}
+
+func TestVouchersByJob(t *testing.T) {
+	_, err := testRepo.CreateVouchers([]model.Voucher{
+		{Code: "JOBA", CampaignID: "job_campaign", JobID: "job1", AssignedUserId: "user1"},
+		{Code: "JOBB", CampaignID: "job_campaign", JobID: "job1", AssignedUserId: "user2"},
+		{Code: "JOBOTHER", CampaignID: "job_campaign", JobID: "job2", AssignedUserId: "user3"},
+	})
+	assert.NoError(t, err, "Creating vouchers should not return an error")
+
+	count, err := testRepo.CountVouchersByJob("job1")
+	assert.NoError(t, err, "Counting vouchers should not return an error")
+	assert.Equal(t, 2, count, "Expected only the job's vouchers to be counted")
+
+	users, err := testRepo.ListAssignedUsersByJob("job1")
+	assert.NoError(t, err, "Listing users should not return an error")
+	assert.ElementsMatch(t, []string{"user1", "user2"}, users)
+}
+
+func TestMarkLegacyVouchers(t *testing.T) {
+	checked, err := GenerateCode(model.CodeFormat{Length: 10, Alphabet: HexAlphabet})
+	assert.NoError(t, err, "Generating a code should not return an error")
+	_, err = testRepo.CreateVouchers([]model.Voucher{
+		{Code: legacyCode(), CampaignID: "legacy_campaign"},
+		{Code: checked, CampaignID: "legacy_campaign"},
+	})
+	assert.NoError(t, err, "Creating vouchers should not return an error")
+
+	marked, err := testRepo.MarkLegacyVouchers()
+	assert.NoError(t, err, "Marking legacy vouchers should not return an error")
+	assert.Equal(t, int64(1), marked, "Expected only the code without a check character to be marked")
+
+	legacy, _ := testRepo.GetVoucherByCode(legacyCode())
+	assert.True(t, legacy.Legacy, "Expected the old code to be flagged")
+	current, _ := testRepo.GetVoucherByCode(checked)
+	assert.False(t, current.Legacy, "Expected the code with a check character to stay checked")
+
+	marked, err = testRepo.MarkLegacyVouchers()
+	assert.NoError(t, err, "Marking legacy vouchers again should not return an error")
+	assert.Equal(t, int64(0), marked, "Expected marked vouchers not to be revisited")
+}
+
+// TestAddRedemption_Concurrent tests that concurrent redemptions never
+// exceed the voucher's limit
+func TestAddRedemption_Concurrent(t *testing.T) {
+	voucher := &model.Voucher{Code: "SHAREDRACE", Mode: model.VoucherMultiUse, MaxRedemptions: 5}
+	assert.NoError(t, testRepo.CreateVoucher(voucher), "Creating voucher should not return an error")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := testRepo.AddRedemption(voucher.Id, voucher.MaxRedemptions); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			} else {
+				assert.ErrorIs(t, err, ErrRedemptionLimitReached)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := testRepo.GetVoucherByCode("SHAREDRACE")
+	assert.NoError(t, err, "Should find the voucher")
+	assert.Equal(t, 5, succeeded, "Only the allowed number of redemptions should succeed")
+	assert.Equal(t, 5, stored.RedemptionCount, "The counter should stop at the limit")
+	assert.True(t, stored.Used, "A fully redeemed voucher should be marked used")
+}
+
+// TestClaimUserRedemption_Limit tests the per-user counter
+func TestClaimUserRedemption_Limit(t *testing.T) {
+	_, err := testDB.Collection("voucher_user_redemptions").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "voucher_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	assert.NoError(t, err, "Creating the counter index should not return an error")
+
+	assert.NoError(t, testRepo.ClaimUserRedemption("voucher1", "user1", 2), "First redemption should be allowed")
+	assert.NoError(t, testRepo.ClaimUserRedemption("voucher1", "user1", 2), "Second redemption should be allowed")
+	assert.ErrorIs(t, testRepo.ClaimUserRedemption("voucher1", "user1", 2), ErrUserLimitReached, "Third redemption should be refused")
+	assert.NoError(t, testRepo.ClaimUserRedemption("voucher1", "user2", 2), "Other users have their own limit")
+
+	assert.NoError(t, testRepo.ReleaseUserRedemption("voucher1", "user1"), "Releasing should not return an error")
+	assert.NoError(t, testRepo.ClaimUserRedemption("voucher1", "user1", 2), "A released slot can be used again")
+}
+
+// TestClaimUserRedemption_ConcurrentFirst tests that racing first
+// redemptions by one user all count up to the limit instead of failing on
+// the colliding upserts
+func TestClaimUserRedemption_ConcurrentFirst(t *testing.T) {
+	_, err := testDB.Collection("voucher_user_redemptions").Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "voucher_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	assert.NoError(t, err, "Creating the counter index should not return an error")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := testRepo.ClaimUserRedemption("voucherrace", "user1", 10); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, succeeded, "Every redemption within the limit should succeed")
+}
+
+// TestClaimVoucher_Once tests that a single-use voucher is claimed only once
+func TestClaimVoucher_Once(t *testing.T) {
+	voucher := &model.Voucher{Code: "CLAIMONCE"}
+	assert.NoError(t, testRepo.CreateVoucher(voucher), "Creating voucher should not return an error")
+
+	assert.NoError(t, testRepo.ClaimVoucher(voucher.Id, "user1"), "First claim should succeed")
+	assert.ErrorIs(t, testRepo.ClaimVoucher(voucher.Id, "user2"), ErrVoucherUsed, "Second claim should be refused")
+}
+
+func TestRevokeVouchers_SkipsUsed(t *testing.T) {
+	vouchers := []model.Voucher{
+		{Code: "REVOKE1", CampaignID: "revoke-campaign"},
+		{Code: "REVOKE2", CampaignID: "revoke-campaign"},
+		{Code: "REVOKE3", CampaignID: "revoke-campaign", Used: true},
+	}
+	_, err := testRepo.CreateVouchers(vouchers)
+	assert.NoError(t, err, "Creating vouchers should not return an error")
+
+	revoked, err := testRepo.RevokeVouchers("revoke-campaign", RevokeFilter{}, "batch leaked", time.Now())
+	assert.NoError(t, err, "Revoking should not return an error")
+	assert.Equal(t, int64(2), revoked, "Only unused vouchers should be revoked")
+
+	stored, err := testRepo.GetVoucherByCode("REVOKE1")
+	assert.NoError(t, err)
+	assert.True(t, stored.IsRevoked(), "Voucher should be marked revoked")
+	assert.ErrorIs(t, testRepo.ClaimVoucher(stored.Id, "user1"), ErrVoucherUsed, "Revoked vouchers cannot be claimed")
+	assert.ErrorIs(t, testRepo.RevokeVoucher("REVOKE1", "again", time.Now()), ErrVoucherNotFound, "Revoking twice should find nothing")
+}
+
+func TestReserveVoucher_HoldsForUser(t *testing.T) {
+	voucher := &model.Voucher{Code: "HOLDME"}
+	assert.NoError(t, testRepo.CreateVoucher(voucher), "Creating voucher should not return an error")
+
+	until := time.Now().Add(time.Minute)
+	assert.NoError(t, testRepo.ReserveVoucher(voucher.Id, "user1", until), "First hold should succeed")
+	assert.NoError(t, testRepo.ReserveVoucher(voucher.Id, "user1", until), "The holder may renew the hold")
+	assert.ErrorIs(t, testRepo.ReserveVoucher(voucher.Id, "user2", until), ErrVoucherReserved, "Another user cannot take the hold")
+	assert.ErrorIs(t, testRepo.ClaimVoucher(voucher.Id, "user2"), ErrVoucherUsed, "Another user cannot redeem a held voucher")
+	assert.NoError(t, testRepo.ClaimVoucher(voucher.Id, "user1"), "The holder converts the hold into a redemption")
+
+	stored, err := testRepo.GetVoucherByCode("HOLDME")
+	assert.NoError(t, err)
+	assert.Empty(t, stored.ReservedBy, "The hold should be cleared once redeemed")
+}
+
+func TestReserveVoucher_ExpiredHold(t *testing.T) {
+	voucher := &model.Voucher{Code: "HOLDEXPIRED"}
+	assert.NoError(t, testRepo.CreateVoucher(voucher), "Creating voucher should not return an error")
+
+	assert.NoError(t, testRepo.ReserveVoucher(voucher.Id, "user1", time.Now().Add(-time.Second)), "Reserving should succeed")
+	assert.NoError(t, testRepo.ClaimVoucher(voucher.Id, "user2"), "An expired hold should not block others")
+}
+
+func TestStartValidity_OnlyOnce(t *testing.T) {
+	voucher := &model.Voucher{Code: "FIRSTVIEW", ValidityDays: 7, ExpiryDate: time.Now().Add(30 * 24 * time.Hour)}
+	assert.NoError(t, testRepo.CreateVoucher(voucher), "Creating voucher should not return an error")
+
+	firstView := time.Now().Truncate(time.Millisecond)
+	started, err := testRepo.StartValidity(voucher.Id, firstView, firstView.AddDate(0, 0, 7))
+	assert.NoError(t, err, "Starting validity should not return an error")
+	assert.True(t, started.ExpiryDate.Equal(firstView.AddDate(0, 0, 7)), "Expiry should count from the first view")
+
+	later := firstView.Add(time.Hour)
+	again, err := testRepo.StartValidity(voucher.Id, later, later.AddDate(0, 0, 7))
+	assert.NoError(t, err, "A second view should not fail")
+	assert.True(t, again.ExpiryDate.Equal(started.ExpiryDate), "A second view should keep the first expiry")
+}
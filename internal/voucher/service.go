@@ -3,54 +3,439 @@ package voucher
 import (
 	"errors"
 	"time"
+	"trinity/internal/eligibility"
 	"trinity/internal/model"
+	"trinity/internal/pricing"
+	"trinity/internal/user"
 	"trinity/pkg/logger"
 )
 
+var (
+	// ErrVoucherNotFound is returned when no voucher has the code
+	ErrVoucherNotFound = errors.New("invalid voucher code")
+	// ErrVoucherUsed is returned when a single-use voucher was already redeemed
+	ErrVoucherUsed = errors.New("voucher already used")
+	// ErrVoucherExpired is returned for vouchers past their expiry date
+	ErrVoucherExpired = errors.New("voucher expired")
+	// ErrRedemptionLimitReached is returned when a multi-use voucher has no redemptions left
+	ErrRedemptionLimitReached = errors.New("voucher redemption limit reached")
+	// ErrVoucherRevoked is returned for vouchers that have been voided
+	ErrVoucherRevoked = errors.New("voucher revoked")
+	// ErrVoucherNotAssigned is returned when a personal voucher is redeemed by another user
+	ErrVoucherNotAssigned = errors.New("voucher is assigned to another user")
+	// ErrUserLimitReached is returned when the user has redeemed a multi-use voucher as often as allowed
+	ErrUserLimitReached = errors.New("voucher redeemed too often by this user")
+	// ErrVoucherReserved is returned when another user holds the voucher during checkout
+	ErrVoucherReserved = errors.New("voucher reserved by another user")
+	// ErrNotReservable is returned for shared codes, which are never held for one user
+	ErrNotReservable = errors.New("multi-use vouchers cannot be reserved")
+	// ErrCampaignFull is returned when a campaign has been redeemed MaxUsers times
+	ErrCampaignFull = errors.New("campaign redemption limit reached")
+)
+
+// CampaignSource looks up a voucher's campaign and enforces its
+// campaign-wide redemption cap
+type CampaignSource interface {
+	GetCampaignByID(id string) (*model.Campaign, error)
+	ClaimRedemption(campaignID string) error
+	ReleaseRedemption(campaignID string) error
+}
+
 // Service defines voucher business logic methods
 type Service interface {
+	GetVoucher(code string) (*model.Voucher, error)
 	RedeemVoucher(code string, userID string) (*model.Voucher, error)
+	RedeemForOrder(code string, userID string, order *eligibility.Order) (*model.Voucher, error)
+	CheckVoucher(code string, userID string, order *eligibility.Order) (*model.Voucher, error)
+	CancelRedemption(code string, userID string) error
+	RevokeVoucher(code string, reason string) (*model.Voucher, error)
+	ReserveVoucher(code string, userID string) (*model.Voucher, error)
+	ViewVoucher(code string, userID string, order *eligibility.Order) (*model.Voucher, error)
 }
 
 // service implements Service interface
 type service struct {
-	repo   Repository
-	logger logger.Logger
+	repo           Repository
+	userRepo       user.Repository
+	eligibility    eligibility.Checker
+	campaigns      CampaignSource
+	reservationTTL time.Duration
+	logger         logger.Logger
 }
 
-// NewService creates a new Voucher service
-func NewService(repo Repository) Service {
+// NewService creates a new Voucher service. Reservations hold a voucher
+// for reservationTTL.
+func NewService(repo Repository, userRepo user.Repository, reservationTTL time.Duration, checker eligibility.Checker, campaigns CampaignSource) Service {
 	return &service{
-		repo:   repo,
-		logger: logger.NewLogger("voucherService"),
+		repo:           repo,
+		userRepo:       userRepo,
+		eligibility:    checker,
+		campaigns:      campaigns,
+		reservationTTL: reservationTTL,
+		logger:         logger.NewLogger("voucherService"),
+	}
+}
+
+// GetVoucher retrieves a voucher by code
+func (s *service) GetVoucher(code string) (*model.Voucher, error) {
+	if err := checkCode(code); err != nil {
+		return nil, err
+	}
+
+	return s.lookupVoucher(code)
+}
+
+// checkCode rejects mistyped codes before the database is queried. Codes
+// shaped like the original check-less codes are let through, and
+// lookupVoucher decides about them once the voucher is read.
+func checkCode(code string) error {
+	if err := ValidateCode(code); err != nil && !hasLegacyShape(code) {
+		return err
+	}
+	return nil
+}
+
+// lookupVoucher reads the voucher with the code. A code failing the check
+// character test is only accepted for a voucher generated before codes
+// carried one, and is reported as mistyped otherwise.
+func (s *service) lookupVoucher(code string) (*model.Voucher, error) {
+	mistyped := ValidateCode(code) != nil
+	voucher, err := s.repo.GetVoucherByCode(code)
+	if err != nil {
+		if mistyped {
+			return nil, ErrCodeMistyped
+		}
+		s.logger.Errorf("Failed to get voucher: %v", err)
+		return nil, ErrVoucherNotFound
+	}
+	if mistyped && !voucher.Legacy {
+		return nil, ErrCodeMistyped
 	}
+	return voucher, nil
 }
 
-// RedeemVoucher redeems a voucher
+// ViewVoucher shows a voucher to the user and validates it against the
+// campaign's eligibility rules, including those about the order when one is
+// given. Personal vouchers are only shown to their user, and the first view
+// starts the validity of vouchers whose campaign counts it from then.
+func (s *service) ViewVoucher(code string, userID string, order *eligibility.Order) (*model.Voucher, error) {
+	if err := checkCode(code); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.getCustomer(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	voucher, err := s.lookupVoucher(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !voucher.IsAssignedTo(userID) {
+		return nil, ErrVoucherNotAssigned
+	}
+
+	if voucher, err = s.startValidity(voucher, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.eligibility.Check(voucher.CampaignID, customer, order); err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+// getCustomer loads the user a voucher is used by. A missing user is
+// reported as user.ErrUserNotFound; other failures are passed through.
+func (s *service) getCustomer(userID string) (*model.User, error) {
+	customer, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if !errors.Is(err, user.ErrUserNotFound) {
+			s.logger.Errorf("Failed to get user %s: %v", userID, err)
+		}
+		return nil, err
+	}
+	return customer, nil
+}
+
+// startValidity starts the validity clock of a voucher on its first view
+func (s *service) startValidity(voucher *model.Voucher, now time.Time) (*model.Voucher, error) {
+	if !voucher.StartsOnView() {
+		return voucher, nil
+	}
+
+	started, err := s.repo.StartValidity(voucher.Id, now, voucher.ExpiryFromView(now))
+	if err != nil {
+		s.logger.Errorf("Failed to start voucher validity: %v", err)
+		return nil, err
+	}
+	return started, nil
+}
+
+// RedeemVoucher redeems a voucher. Every redemption counts against the
+// campaign's MaxUsers. Single-use vouchers are claimed by one user;
+// multi-use vouchers take a slot from both the global and the per-user
+// limit, each enforced by an atomic conditional update. Every redemption is
+// recorded in the redemptions collection.
 func (s *service) RedeemVoucher(code string, userID string) (*model.Voucher, error) {
+	return s.RedeemForOrder(code, userID, nil)
+}
+
+// RedeemForOrder redeems a voucher as part of an order, which the
+// campaign's eligibility rules about plans and order amounts are checked
+// against. Without an order those rules are skipped.
+func (s *service) RedeemForOrder(code string, userID string, order *eligibility.Order) (*model.Voucher, error) {
+	// Reject typos before touching the database
+	if err := checkCode(code); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.getCustomer(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	voucher, err := s.redeemableVoucher(code, customer, order)
+	if err != nil {
+		return nil, err
+	}
+
+	// Redeeming a voucher that was never viewed counts as its first view
+	if voucher, err = s.startValidity(voucher, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// The campaign's redemption is claimed first and given back if the
+	// voucher itself cannot be claimed, so a full campaign uses no voucher
+	if err := s.campaigns.ClaimRedemption(voucher.CampaignID); err != nil {
+		if !errors.Is(err, ErrCampaignFull) {
+			s.logger.Errorf("Failed to claim a redemption of campaign %s: %v", voucher.CampaignID, err)
+		}
+		return nil, err
+	}
+
+	if voucher.IsMultiUse() {
+		err = s.redeemShared(voucher, userID)
+	} else {
+		err = s.repo.ClaimVoucher(voucher.Id, userID)
+		voucher.Used = true
+		voucher.UserId = userID
+	}
+	if err != nil {
+		if releaseErr := s.campaigns.ReleaseRedemption(voucher.CampaignID); releaseErr != nil {
+			s.logger.Errorf("Failed to release redemption of campaign %s: %v", voucher.CampaignID, releaseErr)
+		}
+		if !errors.Is(err, ErrVoucherUsed) && !errors.Is(err, ErrRedemptionLimitReached) && !errors.Is(err, ErrUserLimitReached) {
+			s.logger.Errorf("Failed to update voucher: %v", err)
+			return nil, errors.New("failed to update voucher")
+		}
+		return nil, err
+	}
+	voucher.RedemptionCount++
+
+	// The counters above are authoritative; a missing record is logged
+	// rather than undoing a redemption the user already holds
+	redemption := &model.Redemption{
+		VoucherID:  voucher.Id,
+		Code:       voucher.Code,
+		CampaignID: voucher.CampaignID,
+		UserId:     userID,
+		Variant:    s.variantFor(voucher.CampaignID, userID),
+		RedeemedAt: time.Now(),
+	}
+	if err := s.repo.CreateRedemption(redemption); err != nil {
+		s.logger.Errorf("Failed to record redemption of %s by %s: %v", voucher.Id, userID, err)
+	}
+
+	return voucher, nil
+}
+
+// CancelRedemption undoes a redemption of the voucher by the user, for a
+// purchase that did not go through. The voucher, its per-user count and
+// the campaign's redemption are given back and the redemption record is
+// removed, so the code can be redeemed again.
+func (s *service) CancelRedemption(code string, userID string) error {
 	voucher, err := s.repo.GetVoucherByCode(code)
 	if err != nil {
 		s.logger.Errorf("Failed to get voucher: %v", err)
-		return nil, errors.New("invalid voucher code")
+		return ErrVoucherNotFound
+	}
+
+	if voucher.IsMultiUse() {
+		if err := s.repo.ReleaseUserRedemption(voucher.Id, userID); err != nil {
+			s.logger.Errorf("Failed to release redemption of %s by %s: %v", voucher.Id, userID, err)
+			return err
+		}
+		err = s.repo.RemoveRedemption(voucher.Id)
+	} else {
+		err = s.repo.UnclaimVoucher(voucher.Id, userID)
+	}
+	if err != nil {
+		s.logger.Errorf("Failed to give back voucher %s: %v", voucher.Id, err)
+		return err
+	}
+
+	if err := s.campaigns.ReleaseRedemption(voucher.CampaignID); err != nil {
+		s.logger.Errorf("Failed to release redemption of campaign %s: %v", voucher.CampaignID, err)
+		return err
+	}
+	if err := s.repo.DeleteLastRedemption(voucher.Id, userID); err != nil {
+		s.logger.Errorf("Failed to delete redemption of %s by %s: %v", voucher.Id, userID, err)
+	}
+	return nil
+}
+
+// variantFor returns the name of the campaign variant the user is bucketed
+// into, or an empty string when the campaign runs no A/B test. A campaign
+// that cannot be read leaves the redemption without a variant.
+func (s *service) variantFor(campaignID string, userID string) string {
+	campaign, err := s.campaigns.GetCampaignByID(campaignID)
+	if err != nil {
+		s.logger.Errorf("Failed to get campaign %s: %v", campaignID, err)
+		return ""
+	}
+	if variant := pricing.VariantFor(campaign, userID); variant != nil {
+		return variant.Name
+	}
+	return ""
+}
+
+// CheckVoucher returns the voucher if the user could redeem it for the
+// order right now, without redeeming it. Checkout uses it to price every
+// code before choosing which ones to redeem.
+func (s *service) CheckVoucher(code string, userID string, order *eligibility.Order) (*model.Voucher, error) {
+	if err := checkCode(code); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.getCustomer(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.redeemableVoucher(code, customer, order)
+}
+
+// ReserveVoucher holds a single-use voucher for the user during checkout so
+// nobody else can redeem it until the hold expires. Reserving again renews
+// the user's hold; redeeming the voucher converts it.
+func (s *service) ReserveVoucher(code string, userID string) (*model.Voucher, error) {
+	if err := checkCode(code); err != nil {
+		return nil, err
+	}
+
+	customer, err := s.getCustomer(userID)
+	if err != nil {
+		return nil, err
 	}
 
+	voucher, err := s.redeemableVoucher(code, customer, nil)
+	if err != nil {
+		return nil, err
+	}
+	if voucher.IsMultiUse() {
+		return nil, ErrNotReservable
+	}
+
+	until := time.Now().Add(s.reservationTTL)
+	if err := s.repo.ReserveVoucher(voucher.Id, userID, until); err != nil {
+		if !errors.Is(err, ErrVoucherReserved) {
+			s.logger.Errorf("Failed to reserve voucher: %v", err)
+		}
+		return nil, err
+	}
+
+	voucher.ReservedBy = userID
+	voucher.ReservedUntil = &until
+	return voucher, nil
+}
+
+// redeemableVoucher looks up a voucher and checks that the user could
+// redeem it right now, for the order if one is given. It only reads, so
+// checking a voucher does not start its validity.
+func (s *service) redeemableVoucher(code string, customer *model.User, order *eligibility.Order) (*model.Voucher, error) {
+	userID := customer.Id
+	voucher, err := s.lookupVoucher(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if voucher.IsRevoked() {
+		return nil, ErrVoucherRevoked
+	}
+
+	// Personal vouchers can only be redeemed by the user they were issued to
+	if !voucher.IsAssignedTo(userID) {
+		return nil, ErrVoucherNotAssigned
+	}
+
+	now := time.Now()
 	if voucher.Used {
-		return nil, errors.New("voucher already used")
+		if voucher.IsMultiUse() {
+			return nil, ErrRedemptionLimitReached
+		}
+		return nil, ErrVoucherUsed
 	}
 
-	if time.Now().After(voucher.ExpiryDate) {
-		return nil, errors.New("voucher expired")
+	if now.After(voucher.ExpiryDate) {
+		return nil, ErrVoucherExpired
 	}
 
-	// Mark voucher as used
-	voucher.Used = true
-	voucher.UserId = userID
+	if voucher.IsHeldByOther(userID, now) {
+		return nil, ErrVoucherReserved
+	}
 
-	err = s.repo.UpdateVoucher(voucher)
+	if err := s.eligibility.Check(voucher.CampaignID, customer, order); err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+// redeemShared takes the user's slot first so a user at their limit never
+// consumes a global redemption, and gives it back if the voucher is full
+func (s *service) redeemShared(voucher *model.Voucher, userID string) error {
+	limit := voucher.PerUserLimit
+	if limit <= 0 {
+		limit = 1
+	}
+
+	if err := s.repo.ClaimUserRedemption(voucher.Id, userID, limit); err != nil {
+		return err
+	}
+
+	if err := s.repo.AddRedemption(voucher.Id, voucher.MaxRedemptions); err != nil {
+		if releaseErr := s.repo.ReleaseUserRedemption(voucher.Id, userID); releaseErr != nil {
+			s.logger.Errorf("Failed to release redemption of %s by %s: %v", voucher.Id, userID, releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// RevokeVoucher voids a voucher so it can no longer be redeemed
+func (s *service) RevokeVoucher(code string, reason string) (*model.Voucher, error) {
+	voucher, err := s.repo.GetVoucherByCode(code)
 	if err != nil {
-		s.logger.Errorf("Failed to update voucher: %v", err)
-		return nil, errors.New("failed to update voucher")
+		s.logger.Errorf("Failed to get voucher: %v", err)
+		return nil, ErrVoucherNotFound
+	}
+	if voucher.IsRevoked() {
+		return nil, ErrVoucherRevoked
+	}
+
+	now := time.Now()
+	if err := s.repo.RevokeVoucher(code, reason, now); err != nil {
+		if errors.Is(err, ErrVoucherNotFound) {
+			// Revoked by a concurrent request since it was read
+			return nil, ErrVoucherRevoked
+		}
+		s.logger.Errorf("Failed to revoke voucher: %v", err)
+		return nil, err
 	}
 
+	s.logger.Infof("Revoked voucher %s: %s", voucher.Id, reason)
+	voucher.RevokedAt = &now
+	voucher.RevokedReason = reason
 	return voucher, nil
 }
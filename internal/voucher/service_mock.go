@@ -1,6 +1,7 @@
 package voucher
 
 import (
+	"trinity/internal/eligibility"
 	"trinity/internal/model"
 
 	"github.com/stretchr/testify/mock"
@@ -19,3 +20,62 @@ func (m *MockService) RedeemVoucher(code string, userID string) (*model.Voucher,
 	}
 	return voucher.(*model.Voucher), args.Error(1)
 }
+
+func (m *MockService) GetVoucher(code string) (*model.Voucher, error) {
+	args := m.Called(code)
+	voucher := args.Get(0)
+	if voucher == nil {
+		return nil, args.Error(1)
+	}
+	return voucher.(*model.Voucher), args.Error(1)
+}
+
+func (m *MockService) RevokeVoucher(code string, reason string) (*model.Voucher, error) {
+	args := m.Called(code, reason)
+	voucher := args.Get(0)
+	if voucher == nil {
+		return nil, args.Error(1)
+	}
+	return voucher.(*model.Voucher), args.Error(1)
+}
+
+func (m *MockService) ReserveVoucher(code string, userID string) (*model.Voucher, error) {
+	args := m.Called(code, userID)
+	voucher := args.Get(0)
+	if voucher == nil {
+		return nil, args.Error(1)
+	}
+	return voucher.(*model.Voucher), args.Error(1)
+}
+
+func (m *MockService) ViewVoucher(code string, userID string, order *eligibility.Order) (*model.Voucher, error) {
+	args := m.Called(code, userID, order)
+	voucher := args.Get(0)
+	if voucher == nil {
+		return nil, args.Error(1)
+	}
+	return voucher.(*model.Voucher), args.Error(1)
+}
+
+func (m *MockService) RedeemForOrder(code string, userID string, order *eligibility.Order) (*model.Voucher, error) {
+	args := m.Called(code, userID, order)
+	voucher := args.Get(0)
+	if voucher == nil {
+		return nil, args.Error(1)
+	}
+	return voucher.(*model.Voucher), args.Error(1)
+}
+
+func (m *MockService) CheckVoucher(code string, userID string, order *eligibility.Order) (*model.Voucher, error) {
+	args := m.Called(code, userID, order)
+	voucher := args.Get(0)
+	if voucher == nil {
+		return nil, args.Error(1)
+	}
+	return voucher.(*model.Voucher), args.Error(1)
+}
+
+func (m *MockService) CancelRedemption(code string, userID string) error {
+	args := m.Called(code, userID)
+	return args.Error(0)
+}
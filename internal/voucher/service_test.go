@@ -2,19 +2,45 @@ package voucher
 
 import (
 	"errors"
+	"strings"
 	"testing"
 	"time"
+	"trinity/internal/eligibility"
 	"trinity/internal/model"
+	"trinity/internal/user"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
+// testReservationTTL is the hold duration used by the service tests
+const testReservationTTL = 15 * time.Minute
+
+// allowAll returns an eligibility checker that lets every user through
+func allowAll() *eligibility.MockChecker {
+	checker := new(eligibility.MockChecker)
+	checker.On("Check", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return checker
+}
+
+// uncapped returns a campaign source for campaigns that never fill up
+// and run no A/B test
+func uncapped() *MockCampaignSource {
+	campaigns := new(MockCampaignSource)
+	campaigns.On("GetCampaignByID", mock.Anything).Return(&model.Campaign{}, nil).Maybe()
+	campaigns.On("ClaimRedemption", mock.Anything).Return(nil)
+	campaigns.On("ReleaseRedemption", mock.Anything).Return(nil)
+	return campaigns
+}
+
 func TestServiceRedeemVoucher_Success(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewService(mockRepo)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
 
-	code := "VALIDCODE"
+	code := WithCheckCharacter("VALIDCODE")
 	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
 
 	voucher := &model.Voucher{
 		Code:       code,
@@ -26,11 +52,9 @@ func TestServiceRedeemVoucher_Success(t *testing.T) {
 	// Mock GetVoucherByCode
 	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
 
-	// Mock UpdateVoucher
-	updatedVoucher := *voucher
-	updatedVoucher.Used = true
-	updatedVoucher.UserId = userID
-	mockRepo.On("UpdateVoucher", &updatedVoucher).Return(nil)
+	// Mock the atomic claim and the redemption record
+	mockRepo.On("ClaimVoucher", voucher.Id, userID).Return(nil)
+	mockRepo.On("CreateRedemption", mock.AnythingOfType("*model.Redemption")).Return(nil)
 
 	result, err := service.RedeemVoucher(code, userID)
 	assert.NoError(t, err, "Redeeming a valid voucher should not return an error")
@@ -42,10 +66,12 @@ func TestServiceRedeemVoucher_Success(t *testing.T) {
 
 func TestServiceRedeemVoucher_InvalidCode(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewService(mockRepo)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
 
-	code := "INVALIDCODE"
+	code := WithCheckCharacter("INVALIDCODE")
 	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
 
 	// Mock GetVoucherByCode to return error
 	mockRepo.On("GetVoucherByCode", code).Return(nil, errors.New("voucher not found"))
@@ -59,10 +85,12 @@ func TestServiceRedeemVoucher_InvalidCode(t *testing.T) {
 
 func TestServiceRedeemVoucher_AlreadyUsed(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewService(mockRepo)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
 
-	code := "USEDVOUCHER"
+	code := WithCheckCharacter("USEDVOUCHER")
 	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
 
 	voucher := &model.Voucher{
 		Code:       code,
@@ -83,10 +111,12 @@ func TestServiceRedeemVoucher_AlreadyUsed(t *testing.T) {
 
 func TestServiceRedeemVoucher_Expired(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewService(mockRepo)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
 
-	code := "EXPIREDVOUCHER"
+	code := WithCheckCharacter("EXPIREDVOUCHER")
 	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
 
 	voucher := &model.Voucher{
 		Code:       code,
@@ -107,10 +137,12 @@ func TestServiceRedeemVoucher_Expired(t *testing.T) {
 
 func TestServiceRedeemVoucher_UpdateError(t *testing.T) {
 	mockRepo := new(MockRepository)
-	service := NewService(mockRepo)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
 
-	code := "UPDATEERROR"
+	code := WithCheckCharacter("UPDATEERROR")
 	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
 
 	voucher := &model.Voucher{
 		Code:       code,
@@ -122,11 +154,8 @@ func TestServiceRedeemVoucher_UpdateError(t *testing.T) {
 	// Mock GetVoucherByCode
 	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
 
-	// Mock UpdateVoucher to return error
-	updatedVoucher := *voucher
-	updatedVoucher.Used = true
-	updatedVoucher.UserId = userID
-	mockRepo.On("UpdateVoucher", &updatedVoucher).Return(errors.New("update failed"))
+	// Mock the claim to return error
+	mockRepo.On("ClaimVoucher", voucher.Id, userID).Return(errors.New("update failed"))
 
 	result, err := service.RedeemVoucher(code, userID)
 	assert.Error(t, err, "Redeeming should return an error if update fails")
@@ -134,3 +163,592 @@ func TestServiceRedeemVoucher_UpdateError(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestServiceRedeemVoucher_UnknownUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("VALIDCODE")
+	userID := "ghost"
+
+	// Mock GetUserByID to report a missing user
+	mockUserRepo.On("GetUserByID", userID).Return(nil, user.ErrUserNotFound)
+
+	result, err := service.RedeemVoucher(code, userID)
+	assert.ErrorIs(t, err, user.ErrUserNotFound, "Redeeming for an unknown user should return ErrUserNotFound")
+	assert.Nil(t, result, "Result should be nil for unknown user")
+
+	mockRepo.AssertNotCalled(t, "GetVoucherByCode", code)
+	mockUserRepo.AssertExpectations(t)
+}
+
+func TestServiceRedeemVoucher_UserLookupFails(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("VALIDCODE")
+	dbErr := errors.New("database error")
+	mockUserRepo.On("GetUserByID", "user123").Return(nil, dbErr)
+
+	_, err := service.RedeemVoucher(code, "user123")
+
+	assert.ErrorIs(t, err, dbErr, "A failed lookup should not be reported as a missing user")
+	assert.NotErrorIs(t, err, user.ErrUserNotFound)
+	mockRepo.AssertNotCalled(t, "GetVoucherByCode", code)
+}
+
+func TestServiceRedeemVoucher_Mistyped(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("SUMMER7KQ2MX9")
+	mistyped := strings.Replace(code, "K", "X", 1)
+
+	result, err := service.RedeemVoucher(mistyped, "user123")
+	assert.ErrorIs(t, err, ErrCodeMistyped, "A mistyped code should be reported as such")
+	assert.Nil(t, result, "Result should be nil for a mistyped code")
+
+	mockRepo.AssertNotCalled(t, "GetVoucherByCode", mock.Anything)
+	mockUserRepo.AssertNotCalled(t, "GetUserByID", mock.Anything)
+}
+
+// legacyCode returns a code shaped like those generated before check
+// characters, which fails the check character test
+func legacyCode() string {
+	body := "0A1B2C3D4"
+	check := WithCheckCharacter(body)[len(body)]
+	for _, r := range HexAlphabet {
+		if byte(r) != check {
+			return body + string(r)
+		}
+	}
+	return ""
+}
+
+func TestServiceRedeemVoucher_LegacyCode(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := legacyCode()
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, CampaignID: "campaign123", Legacy: true, ExpiryDate: time.Now().Add(time.Hour)}, nil)
+	mockRepo.On("ClaimVoucher", "voucher123", "user123").Return(nil)
+	mockRepo.On("CreateRedemption", mock.AnythingOfType("*model.Redemption")).Return(nil)
+
+	result, err := service.RedeemVoucher(code, "user123")
+
+	assert.NoError(t, err, "Vouchers generated before check characters should be redeemable")
+	assert.True(t, result.Used)
+}
+
+func TestServiceRedeemVoucher_MistypedLegacyShape(t *testing.T) {
+	for name, stored := range map[string]*model.Voucher{
+		"unknown":    nil,
+		"not legacy": {Id: "voucher123", CampaignID: "campaign123", ExpiryDate: time.Now().Add(time.Hour)},
+	} {
+		mockRepo := new(MockRepository)
+		mockUserRepo := new(user.MockRepository)
+		service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+		code := legacyCode()
+		mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+		if stored == nil {
+			mockRepo.On("GetVoucherByCode", code).Return(nil, errors.New("no documents in result"))
+		} else {
+			mockRepo.On("GetVoucherByCode", code).Return(stored, nil)
+		}
+
+		result, err := service.RedeemVoucher(code, "user123")
+
+		assert.ErrorIs(t, err, ErrCodeMistyped, "A %s voucher failing the check should be reported as mistyped", name)
+		assert.Nil(t, result)
+		mockRepo.AssertNotCalled(t, "ClaimVoucher", mock.Anything, mock.Anything)
+	}
+}
+
+func TestServiceRedeemVoucher_CampaignFull(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	counter := new(MockCampaignSource)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), counter)
+
+	code := WithCheckCharacter("LATECODE")
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, CampaignID: "campaign123", ExpiryDate: time.Now().Add(time.Hour)}, nil)
+	counter.On("ClaimRedemption", "campaign123").Return(ErrCampaignFull)
+
+	result, err := service.RedeemVoucher(code, "user123")
+
+	assert.ErrorIs(t, err, ErrCampaignFull, "Issued codes should stop working once the campaign is fully redeemed")
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "ClaimVoucher", mock.Anything, mock.Anything)
+}
+
+func TestServiceRedeemVoucher_ReleasesCampaignRedemption(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	counter := uncapped()
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), counter)
+
+	code := WithCheckCharacter("RACEDCODE")
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, CampaignID: "campaign123", ExpiryDate: time.Now().Add(time.Hour)}, nil)
+	mockRepo.On("ClaimVoucher", "voucher123", "user123").Return(ErrVoucherUsed)
+
+	_, err := service.RedeemVoucher(code, "user123")
+
+	assert.ErrorIs(t, err, ErrVoucherUsed)
+	counter.AssertCalled(t, "ReleaseRedemption", "campaign123")
+}
+
+func TestServiceRedeemVoucher_ClaimedConcurrently(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("RACEDCODE")
+	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
+
+	voucher := &model.Voucher{Id: "voucher123", Code: code, ExpiryDate: time.Now().Add(24 * time.Hour)}
+	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
+	// Another request claimed the voucher between the read and the update
+	mockRepo.On("ClaimVoucher", "voucher123", userID).Return(ErrVoucherUsed)
+
+	result, err := service.RedeemVoucher(code, userID)
+
+	assert.ErrorIs(t, err, ErrVoucherUsed, "The losing request should see the voucher as used")
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "CreateRedemption", mock.Anything)
+}
+
+func sharedVoucher(code string) *model.Voucher {
+	return &model.Voucher{
+		Id:             "shared123",
+		Code:           code,
+		CampaignID:     "campaign123",
+		Mode:           model.VoucherMultiUse,
+		MaxRedemptions: 100,
+		PerUserLimit:   1,
+		ExpiryDate:     time.Now().Add(24 * time.Hour),
+	}
+}
+
+func TestServiceRedeemVoucher_MultiUse(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("WELCOME2026")
+	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(sharedVoucher(code), nil)
+	mockRepo.On("ClaimUserRedemption", "shared123", userID, 1).Return(nil)
+	mockRepo.On("AddRedemption", "shared123", 100).Return(nil)
+	mockRepo.On("CreateRedemption", mock.MatchedBy(func(r *model.Redemption) bool {
+		return r.VoucherID == "shared123" && r.UserId == userID && r.CampaignID == "campaign123"
+	})).Return(nil)
+
+	result, err := service.RedeemVoucher(code, userID)
+
+	assert.NoError(t, err, "Redeeming a shared code should not return an error")
+	assert.False(t, result.Used, "A shared code stays available for other users")
+	assert.Equal(t, 1, result.RedemptionCount, "Expected the redemption to be counted")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestServiceRedeemVoucher_MultiUseUserLimit(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("WELCOME2026")
+	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(sharedVoucher(code), nil)
+	mockRepo.On("ClaimUserRedemption", "shared123", userID, 1).Return(ErrUserLimitReached)
+
+	result, err := service.RedeemVoucher(code, userID)
+
+	assert.ErrorIs(t, err, ErrUserLimitReached, "A second redemption by the same user should be refused")
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "AddRedemption", mock.Anything, mock.Anything)
+}
+
+func TestServiceRedeemVoucher_MultiUseExhausted(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("WELCOME2026")
+	userID := "user123"
+	mockUserRepo.On("GetUserByID", userID).Return(&model.User{Id: userID}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(sharedVoucher(code), nil)
+	mockRepo.On("ClaimUserRedemption", "shared123", userID, 1).Return(nil)
+	mockRepo.On("AddRedemption", "shared123", 100).Return(ErrRedemptionLimitReached)
+	// The user's slot is given back so they are not charged for a failed redemption
+	mockRepo.On("ReleaseUserRedemption", "shared123", userID).Return(nil)
+
+	result, err := service.RedeemVoucher(code, userID)
+
+	assert.ErrorIs(t, err, ErrRedemptionLimitReached, "Redemptions past the global limit should be refused")
+	assert.Nil(t, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestServiceRedeemVoucher_AssignedToAnotherUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("PERSONAL1")
+	mockUserRepo.On("GetUserByID", "intruder").Return(&model.User{Id: "intruder"}, nil)
+	voucher := &model.Voucher{Id: "voucher123", Code: code, AssignedUserId: "owner", ExpiryDate: time.Now().Add(24 * time.Hour)}
+	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
+
+	result, err := service.RedeemVoucher(code, "intruder")
+
+	assert.ErrorIs(t, err, ErrVoucherNotAssigned, "Only the assigned user may redeem a personal voucher")
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "ClaimVoucher", mock.Anything, mock.Anything)
+}
+
+func TestServiceRedeemVoucher_AssignedUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("PERSONAL1")
+	mockUserRepo.On("GetUserByID", "owner").Return(&model.User{Id: "owner"}, nil)
+	voucher := &model.Voucher{Id: "voucher123", Code: code, AssignedUserId: "owner", ExpiryDate: time.Now().Add(24 * time.Hour)}
+	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
+	mockRepo.On("ClaimVoucher", "voucher123", "owner").Return(nil)
+	mockRepo.On("CreateRedemption", mock.AnythingOfType("*model.Redemption")).Return(nil)
+
+	result, err := service.RedeemVoucher(code, "owner")
+
+	assert.NoError(t, err, "The assigned user should be able to redeem")
+	assert.Equal(t, "owner", result.UserId)
+}
+
+func TestServiceRedeemVoucher_Revoked(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("LEAKED1")
+	revokedAt := time.Now().Add(-time.Hour)
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	voucher := &model.Voucher{Id: "voucher123", Code: code, RevokedAt: &revokedAt, ExpiryDate: time.Now().Add(24 * time.Hour)}
+	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
+
+	result, err := service.RedeemVoucher(code, "user123")
+
+	assert.ErrorIs(t, err, ErrVoucherRevoked, "Revoked vouchers should be reported as revoked")
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "ClaimVoucher", mock.Anything, mock.Anything)
+}
+
+func TestServiceRevokeVoucher_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	voucher := &model.Voucher{Id: "voucher123", Code: "LEAKED1"}
+	mockRepo.On("GetVoucherByCode", "LEAKED1").Return(voucher, nil)
+	mockRepo.On("RevokeVoucher", "LEAKED1", "posted online", mock.AnythingOfType("time.Time")).Return(nil)
+
+	result, err := service.RevokeVoucher("LEAKED1", "posted online")
+
+	assert.NoError(t, err)
+	assert.True(t, result.IsRevoked())
+	assert.Equal(t, "posted online", result.RevokedReason)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestServiceRevokeVoucher_AlreadyRevoked(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	revokedAt := time.Now()
+	mockRepo.On("GetVoucherByCode", "LEAKED1").Return(&model.Voucher{Code: "LEAKED1", RevokedAt: &revokedAt}, nil)
+
+	_, err := service.RevokeVoucher("LEAKED1", "posted online")
+
+	assert.ErrorIs(t, err, ErrVoucherRevoked)
+	mockRepo.AssertNotCalled(t, "RevokeVoucher", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceReserveVoucher_Success(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("CHECKOUT1")
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, ExpiryDate: time.Now().Add(24 * time.Hour)}, nil)
+	mockRepo.On("ReserveVoucher", "voucher123", "user123", mock.AnythingOfType("time.Time")).Return(nil)
+
+	result, err := service.ReserveVoucher(code, "user123")
+
+	assert.NoError(t, err, "Reserving an available voucher should succeed")
+	assert.Equal(t, "user123", result.ReservedBy)
+	assert.WithinDuration(t, time.Now().Add(testReservationTTL), *result.ReservedUntil, time.Minute, "The hold should last the configured TTL")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestServiceReserveVoucher_MultiUse(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("WELCOME2026")
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, Mode: model.VoucherMultiUse, MaxRedemptions: 10, ExpiryDate: time.Now().Add(24 * time.Hour)}, nil)
+
+	_, err := service.ReserveVoucher(code, "user123")
+
+	assert.ErrorIs(t, err, ErrNotReservable, "Shared codes should not be held for one user")
+	mockRepo.AssertNotCalled(t, "ReserveVoucher", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceRedeemVoucher_HeldByAnotherUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("CHECKOUT1")
+	until := time.Now().Add(5 * time.Minute)
+	mockUserRepo.On("GetUserByID", "user2").Return(&model.User{Id: "user2"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, ReservedBy: "user1", ReservedUntil: &until, ExpiryDate: time.Now().Add(24 * time.Hour)}, nil)
+
+	result, err := service.RedeemVoucher(code, "user2")
+
+	assert.ErrorIs(t, err, ErrVoucherReserved, "A held voucher should not be redeemable by others")
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "ClaimVoucher", mock.Anything, mock.Anything)
+}
+
+func TestServiceRedeemVoucher_ExpiredHold(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("CHECKOUT1")
+	until := time.Now().Add(-time.Minute)
+	mockUserRepo.On("GetUserByID", "user2").Return(&model.User{Id: "user2"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, ReservedBy: "user1", ReservedUntil: &until, ExpiryDate: time.Now().Add(24 * time.Hour)}, nil)
+	mockRepo.On("ClaimVoucher", "voucher123", "user2").Return(nil)
+	mockRepo.On("CreateRedemption", mock.AnythingOfType("*model.Redemption")).Return(nil)
+
+	result, err := service.RedeemVoucher(code, "user2")
+
+	assert.NoError(t, err, "An expired hold should release the voucher")
+	assert.Equal(t, "user2", result.UserId)
+}
+
+func TestServiceViewVoucher_StartsValidity(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	code := WithCheckCharacter("RELATIVE1")
+	campaignEnd := time.Now().Add(30 * 24 * time.Hour)
+	voucher := &model.Voucher{Id: "voucher123", Code: code, ValidityDays: 7, ExpiryDate: campaignEnd}
+	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
+	inAWeek := mock.MatchedBy(func(expiry time.Time) bool {
+		return expiry.Sub(time.Now().AddDate(0, 0, 7)).Abs() < time.Minute
+	})
+	viewedAt := time.Now()
+	started := &model.Voucher{Id: "voucher123", Code: code, ValidityDays: 7, FirstViewedAt: &viewedAt, ExpiryDate: viewedAt.AddDate(0, 0, 7)}
+	mockRepo.On("StartValidity", "voucher123", mock.AnythingOfType("time.Time"), inAWeek).Return(started, nil)
+
+	result, err := service.ViewVoucher(code, "user123", nil)
+
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 7), result.ExpiryDate, time.Minute, "Validity should count from the first view")
+	assert.False(t, result.StartsOnView())
+}
+
+func TestServiceCheckVoucher_LeavesValidityUnstarted(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	code := WithCheckCharacter("RELATIVE1")
+	voucher := &model.Voucher{Id: "voucher123", Code: code, ValidityDays: 7, ExpiryDate: time.Now().Add(30 * 24 * time.Hour)}
+	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
+
+	result, err := service.CheckVoucher(code, "user123", nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, result.FirstViewedAt, "Quoting a price should not start the validity")
+	assert.True(t, result.StartsOnView())
+	mockRepo.AssertNotCalled(t, "StartValidity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceRedeemVoucher_StartsValidity(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	code := WithCheckCharacter("RELATIVE1")
+	voucher := &model.Voucher{Id: "voucher123", Code: code, ValidityDays: 7, ExpiryDate: time.Now().Add(30 * 24 * time.Hour)}
+	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
+	viewedAt := time.Now()
+	started := &model.Voucher{Id: "voucher123", Code: code, ValidityDays: 7, FirstViewedAt: &viewedAt, ExpiryDate: viewedAt.AddDate(0, 0, 7)}
+	mockRepo.On("StartValidity", "voucher123", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(started, nil).Once()
+	mockRepo.On("ClaimVoucher", "voucher123", "user123").Return(nil)
+	mockRepo.On("CreateRedemption", mock.AnythingOfType("*model.Redemption")).Return(nil)
+
+	result, err := service.RedeemVoucher(code, "user123")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result.FirstViewedAt, "Redeeming an unviewed voucher should start its validity")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestServiceViewVoucher_AlreadyViewed(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	code := WithCheckCharacter("RELATIVE1")
+	viewedAt := time.Now().Add(-48 * time.Hour)
+	voucher := &model.Voucher{Id: "voucher123", Code: code, ValidityDays: 7, FirstViewedAt: &viewedAt, ExpiryDate: viewedAt.AddDate(0, 0, 7)}
+	mockRepo.On("GetVoucherByCode", code).Return(voucher, nil)
+
+	result, err := service.ViewVoucher(code, "user123", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, voucher.ExpiryDate, result.ExpiryDate, "Later views should not move the expiry")
+	mockRepo.AssertNotCalled(t, "StartValidity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceViewVoucher_AssignedToAnotherUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	mockUserRepo.On("GetUserByID", "intruder").Return(&model.User{Id: "intruder"}, nil)
+	code := WithCheckCharacter("PERSONAL1")
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, AssignedUserId: "owner", ValidityDays: 7}, nil)
+
+	_, err := service.ViewVoucher(code, "intruder", nil)
+
+	assert.ErrorIs(t, err, ErrVoucherNotAssigned, "Only the assigned user should see a personal voucher")
+	mockRepo.AssertNotCalled(t, "StartValidity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExpiryFromView_CappedByCampaignEnd(t *testing.T) {
+	campaignEnd := time.Now().Add(48 * time.Hour)
+	voucher := &model.Voucher{ValidityDays: 7, ExpiryDate: campaignEnd}
+
+	assert.Equal(t, campaignEnd, voucher.ExpiryFromView(time.Now()), "Validity should not outlast the campaign")
+}
+
+func TestServiceRedeemForOrder_NotEligible(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	checker := new(eligibility.MockChecker)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, checker, uncapped())
+
+	code := WithCheckCharacter("GOLDONLY1")
+	customer := &model.User{Id: "user123"}
+	order := &eligibility.Order{Plan: model.PlanSilver, Amount: 100}
+	mockUserRepo.On("GetUserByID", "user123").Return(customer, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, CampaignID: "campaign123", ExpiryDate: time.Now().Add(24 * time.Hour)}, nil)
+	checker.On("Check", "campaign123", customer, order).Return(&eligibility.RuleError{Rule: eligibility.RulePlans, Reason: "not valid for the silver plan"})
+
+	result, err := service.RedeemForOrder(code, "user123", order)
+
+	assert.ErrorIs(t, err, eligibility.ErrNotEligible, "The failed rule should be reported")
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "ClaimVoucher", mock.Anything, mock.Anything)
+}
+
+func TestServiceCheckVoucher_DoesNotRedeem(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), uncapped())
+
+	code := WithCheckCharacter("CHECKME")
+	mockUserRepo.On("GetUserByID", "user1").Return(&model.User{Id: "user1"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Code: code, ExpiryDate: time.Now().Add(time.Hour)}, nil)
+
+	voucher, err := service.CheckVoucher(code, "user1", &eligibility.Order{Plan: model.PlanGold, Amount: 200})
+
+	assert.NoError(t, err, "A redeemable voucher should pass the check")
+	assert.False(t, voucher.Used, "Checking should not redeem the voucher")
+	mockRepo.AssertNotCalled(t, "ClaimVoucher", mock.Anything, mock.Anything)
+}
+
+func TestServiceRedeemVoucher_RecordsVariant(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockUserRepo := new(user.MockRepository)
+	campaigns := new(MockCampaignSource)
+	service := NewService(mockRepo, mockUserRepo, testReservationTTL, allowAll(), campaigns)
+
+	code := WithCheckCharacter("ABTESTCODE")
+	mockUserRepo.On("GetUserByID", "user123").Return(&model.User{Id: "user123"}, nil)
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, CampaignID: "campaign123", ExpiryDate: time.Now().Add(time.Hour)}, nil)
+	mockRepo.On("ClaimVoucher", "voucher123", "user123").Return(nil)
+	campaigns.On("ClaimRedemption", "campaign123").Return(nil)
+	// A lone variant takes every user
+	campaigns.On("GetCampaignByID", "campaign123").Return(&model.Campaign{Id: "campaign123", Variants: []model.CampaignVariant{
+		{Name: "treatment", Weight: 1, Discount: 25},
+	}}, nil)
+	mockRepo.On("CreateRedemption", mock.MatchedBy(func(r *model.Redemption) bool {
+		return r.Variant == "treatment"
+	})).Return(nil)
+
+	_, err := service.RedeemVoucher(code, "user123")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestServiceCancelRedemption_SingleUse(t *testing.T) {
+	mockRepo := new(MockRepository)
+	campaigns := uncapped()
+	service := NewService(mockRepo, new(user.MockRepository), testReservationTTL, allowAll(), campaigns)
+
+	code := WithCheckCharacter("GIVEBACK")
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, CampaignID: "campaign123", Used: true, UserId: "user123"}, nil)
+	mockRepo.On("UnclaimVoucher", "voucher123", "user123").Return(nil)
+	mockRepo.On("DeleteLastRedemption", "voucher123", "user123").Return(nil)
+
+	err := service.CancelRedemption(code, "user123")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	campaigns.AssertCalled(t, "ReleaseRedemption", "campaign123")
+}
+
+func TestServiceCancelRedemption_MultiUse(t *testing.T) {
+	mockRepo := new(MockRepository)
+	campaigns := uncapped()
+	service := NewService(mockRepo, new(user.MockRepository), testReservationTTL, allowAll(), campaigns)
+
+	code := WithCheckCharacter("SHAREDCODE")
+	mockRepo.On("GetVoucherByCode", code).Return(&model.Voucher{Id: "voucher123", Code: code, CampaignID: "campaign123", Mode: model.VoucherMultiUse, MaxRedemptions: 10, RedemptionCount: 3}, nil)
+	mockRepo.On("ReleaseUserRedemption", "voucher123", "user123").Return(nil)
+	mockRepo.On("RemoveRedemption", "voucher123").Return(nil)
+	mockRepo.On("DeleteLastRedemption", "voucher123", "user123").Return(nil)
+
+	err := service.CancelRedemption(code, "user123")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UnclaimVoucher", mock.Anything, mock.Anything)
+	campaigns.AssertCalled(t, "ReleaseRedemption", "campaign123")
+}
@@ -5,8 +5,35 @@ import "trinity/pkg/localization"
 // Define the reason constants using the LocalizedString type
 var (
 	// Error messages
-	InvalidRequestFormat localization.LocalizedString = "error.invalid_request_format"
-	InvalidRequest       localization.LocalizedString = "error.invalid_request"
-	InternalServerError  localization.LocalizedString = "error.internal_server_error"
-	InvalidToken         localization.LocalizedString = "error.invalid_token"
+	InvalidRequestFormat   localization.LocalizedString = "error.invalid_request_format"
+	InvalidRequest         localization.LocalizedString = "error.invalid_request"
+	InternalServerError    localization.LocalizedString = "error.internal_server_error"
+	InvalidToken           localization.LocalizedString = "error.invalid_token"
+	Unauthorized           localization.LocalizedString = "error.unauthorized"
+	Forbidden              localization.LocalizedString = "error.forbidden"
+	UserNotFound           localization.LocalizedString = "error.user_not_found"
+	EmailAlreadyRegistered localization.LocalizedString = "error.email_already_registered"
+	InvalidCredentials     localization.LocalizedString = "error.invalid_credentials"
+	APIKeyNotFound         localization.LocalizedString = "error.api_key_not_found"
+	InvalidCodeFormat      localization.LocalizedString = "error.invalid_code_format"
+	CodeMistyped           localization.LocalizedString = "error.code_mistyped"
+	JobNotFound            localization.LocalizedString = "error.job_not_found"
+	VoucherFullyRedeemed   localization.LocalizedString = "error.voucher_fully_redeemed"
+	VoucherUserLimit       localization.LocalizedString = "error.voucher_user_limit"
+	VoucherCodeTaken       localization.LocalizedString = "error.voucher_code_taken"
+	NotEnoughVouchers      localization.LocalizedString = "error.not_enough_vouchers"
+	VoucherNotAssigned     localization.LocalizedString = "error.voucher_not_assigned"
+	VoucherNotFound        localization.LocalizedString = "error.voucher_not_found"
+	VoucherUsed            localization.LocalizedString = "error.voucher_used"
+	VoucherExpired         localization.LocalizedString = "error.voucher_expired"
+	VoucherRevoked         localization.LocalizedString = "error.voucher_revoked"
+	VoucherReserved        localization.LocalizedString = "error.voucher_reserved"
+	NotEligible            localization.LocalizedString = "error.not_eligible"
+	InvalidCondition       localization.LocalizedString = "error.invalid_condition"
+	CampaignFull           localization.LocalizedString = "error.campaign_full"
+	BudgetExhausted        localization.LocalizedString = "error.budget_exhausted"
+	CampaignNotFound       localization.LocalizedString = "error.campaign_not_found"
+	TemplateNotFound       localization.LocalizedString = "error.template_not_found"
+	TemplateExists         localization.LocalizedString = "error.template_exists"
+	NoVariants             localization.LocalizedString = "error.no_variants"
 )
@@ -4,3 +4,9 @@ package response
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
+
+// RuleErrorResponse represents an error caused by a failed business rule
+type RuleErrorResponse struct {
+	Error string `json:"error"`
+	Rule  string `json:"rule"`
+}